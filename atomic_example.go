@@ -2,9 +2,13 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"strings"
 	"sync"
 	"sync/atomic"
-	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/pkg/balancer"
+	"github.com/akshaykumarthakur/load-balancer/pkg/bench"
 )
 
 // Example 1: Demonstrate race condition without atomic
@@ -72,111 +76,44 @@ func exampleWithAtomic() {
 	}
 }
 
-// Example 3: Demonstrate round-robin behavior
-func exampleRoundRobin() {
-	fmt.Println("🔄 ROUND-ROBIN WITH ATOMIC")
+// Example 3: Distribution of the real strategies, measured by package bench
+func exampleDistribution(strategy string, weights ...int) {
+	fmt.Printf("🔄 %s DISTRIBUTION\n", strings.ToUpper(strategy))
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-	var index atomic.Uint64
-	backends := []string{"Backend A", "Backend B", "Backend C"}
-
-	fmt.Printf("Total backends: %d\n\n", len(backends))
-
-	// Simulate 15 requests
-	for i := 0; i < 15; i++ {
-		// This is what SelectBackend() does
-		idx := index.Add(1) - 1
-		idx = idx % uint64(len(backends))
-
-		fmt.Printf("Request %2d: index.Add(1) returned %d → idx = %d → %s\n",
-			i+1, index.Load(), idx, backends[idx])
+	s, err := balancer.StrategyByName(strategy)
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	fmt.Println()
-}
-
-// Example 4: Compare performance
-func examplePerformance() {
-	fmt.Println("⚡ PERFORMANCE COMPARISON")
-	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-
-	iterations := 1000000
-
-	// With Mutex
-	var mutexCounter uint64 = 0
-	var mu sync.Mutex
-
-	start := time.Now()
-	for i := 0; i < iterations; i++ {
-		mu.Lock()
-		mutexCounter++
-		mu.Unlock()
+	backends := bench.NewBackends(3, weights...)
+	lb, err := balancer.New(backends, balancer.WithStrategy(s))
+	if err != nil {
+		log.Fatal(err)
 	}
-	mutexDuration := time.Since(start)
 
-	// With Atomic
-	var atomicCounter atomic.Uint64
-
-	start = time.Now()
-	for i := 0; i < iterations; i++ {
-		atomicCounter.Add(1)
+	requests, concurrency := 300, 10
+	d := bench.Run(lb, requests, concurrency)
+	fmt.Printf("%d requests from %d goroutines over %d backends\n\n", requests, concurrency, len(backends))
+	for _, b := range backends {
+		count := d.Counts[b]
+		fmt.Printf("  %s (weight %d): %3d requests (%.1f%%) %s\n",
+			b.URL.Host, b.Weight(), count, d.Share(b)*100, strings.Repeat("█", count/10))
 	}
-	atomicDuration := time.Since(start)
-
-	fmt.Printf("Mutex:    %v (%d ns/op)\n", mutexDuration, mutexDuration.Nanoseconds()/int64(iterations))
-	fmt.Printf("Atomic:   %v (%d ns/op)\n", atomicDuration, atomicDuration.Nanoseconds()/int64(iterations))
-	fmt.Printf("Speedup:  %.1fx faster\n\n", float64(mutexDuration)/float64(atomicDuration))
+	fmt.Printf("Skew from a split by weight: %.1f%%\n\n", d.Skew(backends)*100)
 }
 
-// Example 5: Concurrent requests to load balancer simulation
-func exampleConcurrentRequests() {
-	fmt.Println("🎯 SIMULATING CONCURRENT REQUESTS")
+// Example 4: Selection throughput under concurrency
+func exampleThroughput() {
+	fmt.Println("⚡ SELECTION THROUGHPUT")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-	var index atomic.Uint64
-	backends := []string{"Backend 1", "Backend 2", "Backend 3"}
-	var wg sync.WaitGroup
-
-	// Track which backends got selected
-	selections := make([]int, len(backends))
-	var mu sync.Mutex
-
-	numRequests := 300
-	numConcurrent := 10
-
-	fmt.Printf("Simulating %d concurrent requests to %d backends\n\n", numRequests, len(backends))
-
-	for i := 0; i < numRequests; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-
-			// Get next backend using atomic counter
-			idx := index.Add(1) - 1
-			idx = idx % uint64(len(backends))
-
-			// Record selection
-			mu.Lock()
-			selections[idx]++
-			mu.Unlock()
-		}()
-
-		// Limit concurrent goroutines for demo
-		if (i+1)%numConcurrent == 0 {
-			wg.Wait()
-		}
+	lb, err := balancer.New(bench.NewBackends(3))
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	wg.Wait()
-
-	fmt.Println("Distribution (should be roughly equal):")
-	for i, count := range selections {
-		percentage := float64(count) / float64(numRequests) * 100
-		bar := ""
-		for j := 0; j < count/10; j++ {
-			bar += "█"
-		}
-		fmt.Printf("  %s: %3d requests (%.1f%%) %s\n", backends[i], count, percentage, bar)
+	for _, concurrency := range []int{1, 10, 100} {
+		d := bench.Run(lb, 1000000, concurrency)
+		fmt.Printf("%3d goroutines: %.0f selections/s\n", concurrency, d.Throughput())
 	}
 	fmt.Println()
 }
@@ -189,13 +126,12 @@ func main() {
 
 	exampleWithoutAtomic()
 	exampleWithAtomic()
-	exampleRoundRobin()
-	examplePerformance()
-	exampleConcurrentRequests()
+	exampleDistribution("round-robin")
+	exampleDistribution("weighted-round-robin", 1, 2, 3)
+	exampleThroughput()
 
 	fmt.Println(divider)
 	fmt.Println("KEY TAKEAWAY:")
 	fmt.Println("atomic.Uint64 ensures thread-safe, fair, fast round-robin distribution!")
 	fmt.Println(divider + "\n")
 }
-
@@ -3,6 +3,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -29,10 +30,13 @@ func runHealthCheckerDemo() {
 	time.Sleep(500 * time.Millisecond)
 
 	// Create load balancer backends
-	lbBackends := []*backend.Backend{
-		backend.NewBackend("http://localhost:3000"),
-		backend.NewBackend("http://localhost:3001"),
-		backend.NewBackend("http://localhost:3002"),
+	var lbBackends []*backend.Backend
+	for _, u := range []string{"http://localhost:3000", "http://localhost:3001", "http://localhost:3002"} {
+		b, err := backend.NewBackend(u)
+		if err != nil {
+			log.Fatalf("Failed to create backend: %v", err)
+		}
+		lbBackends = append(lbBackends, b)
 	}
 
 	// Create load balancer
@@ -49,7 +53,9 @@ func runHealthCheckerDemo() {
 	fmt.Println("=== Health Checker Demo ===\n")
 
 	// Wait for initial health check
-	time.Sleep(500 * time.Millisecond)
+	if err := healthChecker.WaitForFirstCheck(context.Background()); err != nil {
+		log.Fatalf("Initial health check failed: %v", err)
+	}
 
 	// Test 1: All servers healthy
 	fmt.Println("Test 1: Round-robin with all servers healthy")
@@ -66,8 +72,8 @@ func runHealthCheckerDemo() {
 	fmt.Println("\nTest 2: Crashing Backend-2 (:3001)...")
 	backend2.Stop()
 
-	// Wait for health checker to detect the failure
-	time.Sleep(3 * time.Second)
+	// Re-check right away instead of waiting for the next tick
+	healthChecker.CheckAllNow()
 
 	fmt.Println("After health check detected failure (should skip :3001):")
 	for i := 7; i <= 12; i++ {
@@ -83,8 +89,8 @@ func runHealthCheckerDemo() {
 	fmt.Println("\nTest 3: Recovering Backend-2 (:3001)...")
 	backend2.Resume()
 
-	// Wait for health checker to detect recovery
-	time.Sleep(3 * time.Second)
+	// Re-check right away instead of waiting for the next tick
+	healthChecker.CheckAllNow()
 
 	fmt.Println("After health check detected recovery (should include :3001 again):")
 	for i := 13; i <= 18; i++ {
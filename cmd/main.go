@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -12,10 +13,13 @@ import (
 
 func main() {
 	// Create backends
-	backends := []*backend.Backend{
-		backend.NewBackend("http://localhost:3000"),
-		backend.NewBackend("http://localhost:3001"),
-		backend.NewBackend("http://localhost:3002"),
+	var backends []*backend.Backend
+	for _, u := range []string{"http://localhost:3000", "http://localhost:3001", "http://localhost:3002"} {
+		b, err := backend.NewBackend(u)
+		if err != nil {
+			log.Fatalf("Failed to create backend: %v", err)
+		}
+		backends = append(backends, b)
 	}
 
 	// Create load balancer
@@ -32,8 +36,14 @@ func main() {
 	fmt.Println("=== Load Balancer Demo ===")
 	fmt.Println()
 
-	// Initial health check happens immediately
-	fmt.Println("Test 1: Initial round-robin (servers being checked...)")
+	// Hold traffic back until every server has been checked once
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := healthChecker.WaitForFirstCheck(ctx); err != nil {
+		log.Printf("Initial health check did not finish: %v", err)
+	}
+	cancel()
+
+	fmt.Println("Test 1: Initial round-robin")
 	for i := 1; i <= 6; i++ {
 		selected, err := lb.SelectBackend()
 		if err != nil {
@@ -1,43 +1,627 @@
 package backend
 
 import (
+	"crypto/tls"
+	"fmt"
 	"log"
+	"maps"
+	"math"
+	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+	"github.com/akshaykumarthakur/load-balancer/internal/ratelimit"
+	"github.com/akshaykumarthakur/load-balancer/pkg/logging"
+)
+
+// stateGeneration counts changes to whether any backend may take traffic,
+// so views of the healthy backends can be cached until it moves.
+var stateGeneration atomic.Uint64
+
+// stateChange is closed and replaced each time stateGeneration moves.
+var (
+	stateChangeMu sync.Mutex
+	stateChange   chan struct{}
 )
 
+// StateGeneration returns a counter that increases whenever any backend's
+// health state, enabled flag or ejection changes. Ejections that merely
+// expire don't move it.
+func StateGeneration() uint64 {
+	return stateGeneration.Load()
+}
+
+// StateChanged returns a channel that is closed the next time
+// StateGeneration moves, so callers can wait for a backend to come back
+// without polling.
+func StateChanged() <-chan struct{} {
+	stateChangeMu.Lock()
+	defer stateChangeMu.Unlock()
+	if stateChange == nil {
+		stateChange = make(chan struct{})
+	}
+	return stateChange
+}
+
+// bumpStateGeneration moves StateGeneration and wakes StateChanged waiters.
+func bumpStateGeneration() {
+	stateGeneration.Add(1)
+	stateChangeMu.Lock()
+	defer stateChangeMu.Unlock()
+	if stateChange != nil {
+		close(stateChange)
+		stateChange = nil
+	}
+}
+
 // Backend represents a single backend server in the load balancer.
 type Backend struct {
 	URL          *url.URL
 	ReverseProxy *httputil.ReverseProxy
 	mu           sync.RWMutex
-	alive        bool
+	state        HealthState
+	recoveredAt  time.Time
+	weight       int
+	priority     int
+	labels       map[string]string // immutable after construction
+	maxRPS       *ratelimit.TokenBucket
+	rateLimit    *ratelimit.TokenBucket
+	maxConns     int
+	draining     bool
+	disabled     bool
+	reserved     map[string]float64 // request class -> fraction of maxConns
+	healthPath   string
+	healthEvery  time.Duration
+	stripPrefix  string
+	pathPrefix   string
+	h2c          bool
+	tlsConfig    *tls.Config
+
+	// Static headers added to proxied requests; see SetRequestHeaders
+	requestHeaders  http.Header
+	overrideHeaders bool
+
+	// Requests currently being proxied to the backend, and ever proxied to it
+	activeConns   atomic.Int64
+	totalRequests atomic.Uint64
+	selections    atomic.Uint64
+	onRelease     atomic.Pointer[func()]
+	proxyHooked   atomic.Bool // see MarkProxyHooked
+
+	// Passive health: the outcome of the latest real request proxied here
+	passiveOK bool
+	passiveAt time.Time
+
+	// Why and when the latest health check failed
+	lastErr   error
+	lastErrAt time.Time
+
+	// Outlier detection: recent request outcomes and any ejection in force
+	outcomes     outcomeWindow
+	ejectedUntil time.Time
+
+	latencies latencyWindow
+
+	// Time source for recoveries, passive outcomes and ejections; nil means
+	// the real clock
+	clock atomic.Pointer[clock.Clock]
+
+	// Health score from latency and error rate; see HealthScore
+	scoring ScoreCoefficients
+	score   atomic.Int64
 }
 
 // NewBackend creates a new Backend instance for the given URL.
-func NewBackend(urlStr string) *Backend {
+// The URL must be absolute, with both a scheme and a host.
+func NewBackend(urlStr string) (*Backend, error) {
+	return NewBackendWithLabels(urlStr, nil)
+}
+
+// NewBackendWithLabels is like NewBackend but attaches labels, such as
+// "region" or "version", that selection can filter on. The labels are copied
+// and cannot change afterwards.
+func NewBackendWithLabels(urlStr string, labels map[string]string) (*Backend, error) {
 	serverURL, err := url.Parse(urlStr)
 	if err != nil {
-		log.Fatalf("Error parsing backend URL: %v", err)
+		return nil, fmt.Errorf("parsing backend URL %q: %w", urlStr, err)
 	}
-	return &Backend{
+	if serverURL.Scheme == "" || serverURL.Host == "" {
+		return nil, fmt.Errorf("backend URL %q must include a scheme and host", urlStr)
+	}
+	b := &Backend{
 		URL:          serverURL,
 		ReverseProxy: httputil.NewSingleHostReverseProxy(serverURL),
-		alive:        false,
+		state:        Unknown,
+		weight:       1,
+		labels:       maps.Clone(labels),
+	}
+	b.score.Store(100)
+	director := b.ReverseProxy.Director
+	b.ReverseProxy.Director = func(r *http.Request) {
+		b.rewritePath(r.URL)
+		director(r)
+		b.addRequestHeaders(r.Header)
+	}
+	return b, nil
+}
+
+// HealthState is a backend's health as reported by its health checks.
+type HealthState int
+
+const (
+	// Dead backends fail their health checks and receive no traffic.
+	Dead HealthState = iota
+	// Degraded backends still serve but report being under stress; they
+	// only receive traffic when no healthy backend can take it.
+	Degraded
+	// Healthy backends pass their health checks without reservations.
+	Healthy
+	// Unknown backends have not been health checked yet, which is how every
+	// backend starts. Like dead ones they receive no traffic by default.
+	Unknown
+)
+
+func (s HealthState) String() string {
+	switch s {
+	case Dead:
+		return "dead"
+	case Degraded:
+		return "degraded"
+	case Healthy:
+		return "healthy"
+	case Unknown:
+		return "unknown"
+	default:
+		return "unknown"
 	}
 }
 
-// IsAlive returns whether the backend is currently healthy.
+// IsAlive returns whether the backend is currently healthy or degraded.
 func (b *Backend) IsAlive() bool {
+	return b.HealthState().alive()
+}
+
+// alive reports whether backends in state s serve traffic.
+func (s HealthState) alive() bool {
+	return s == Healthy || s == Degraded
+}
+
+// IsDegraded returns whether the backend is alive but degraded.
+func (b *Backend) IsDegraded() bool {
+	return b.HealthState() == Degraded
+}
+
+// SetAlive marks the backend healthy or dead.
+func (b *Backend) SetAlive(alive bool) {
+	state := Dead
+	if alive {
+		state = Healthy
+	}
+	b.SetHealthState(state)
+}
+
+// HealthState returns the backend's current health state.
+func (b *Backend) HealthState() HealthState {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	return b.alive
+	return b.state
 }
 
-// SetAlive sets the alive status of the backend.
-func (b *Backend) SetAlive(alive bool) {
+// SetHealthState sets the backend's health state.
+func (b *Backend) SetHealthState(state HealthState) {
+	b.mu.Lock()
+	// Coming up from Unknown is a boot, not a recovery, and doesn't ramp
+	recovered := state.alive() && b.state == Dead
+	if recovered {
+		b.recoveredAt = b.now()
+	}
+	if state != b.state {
+		bumpStateGeneration()
+	}
+	b.state = state
+	b.mu.Unlock()
+
+	if recovered {
+		b.latencies.resetAverage()
+		b.updateScore()
+	}
+}
+
+// SetClock makes the backend read the time from c, e.g. a clock.Fake in
+// tests, when it records recoveries, passive outcomes and ejections. A nil
+// clock restores the real one.
+func (b *Backend) SetClock(c clock.Clock) {
+	if c == nil {
+		b.clock.Store(nil)
+		return
+	}
+	b.clock.Store(&c)
+}
+
+// now reads the backend's clock.
+func (b *Backend) now() time.Time {
+	if c := b.clock.Load(); c != nil {
+		return (*c).Now()
+	}
+	return time.Now()
+}
+
+// RecoveredAt returns when the backend last transitioned from dead to
+// alive. It is zero if it never has, e.g. while it has stayed up since its
+// first health check.
+func (b *Backend) RecoveredAt() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.recoveredAt
+}
+
+// ReportPassive records whether the latest real request proxied to the
+// backend succeeded at the transport level.
+func (b *Backend) ReportPassive(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.passiveOK = ok
+	b.passiveAt = b.now()
+}
+
+// PassiveHealth returns the latest passive outcome and when it was reported.
+// The time is zero if no traffic has been proxied to the backend yet.
+func (b *Backend) PassiveHealth() (ok bool, at time.Time) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.passiveOK, b.passiveAt
+}
+
+// SetLastError records why a health check of the backend failed at at.
+func (b *Backend) SetLastError(err error, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastErr = err
+	b.lastErrAt = at
+}
+
+// LastError returns why the latest failed health check of the backend failed
+// and when. It is kept after the backend recovers; the error is nil if no
+// check has failed yet.
+func (b *Backend) LastError() (error, time.Time) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lastErr, b.lastErrAt
+}
+
+// Labels returns a copy of the labels the backend was created with.
+func (b *Backend) Labels() map[string]string {
+	return maps.Clone(b.labels)
+}
+
+// MatchesLabels reports whether the backend carries every key and value in
+// selector. An empty selector matches any backend.
+func (b *Backend) MatchesLabels(selector map[string]string) bool {
+	for key, value := range selector {
+		if v, ok := b.labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Weight returns the backend's relative share for weighted strategies.
+func (b *Backend) Weight() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.weight
+}
+
+// SetWeight sets the backend's relative share for weighted strategies.
+// Weights below 1 are treated as 1. It may be called while the backend
+// serves; weighted strategies use the new weight from their next pick.
+func (b *Backend) SetWeight(weight int) {
+	if weight < 1 {
+		weight = 1
+	}
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.alive = alive
+	b.weight = weight
+}
+
+// Priority returns the backend's failover tier; lower values are preferred.
+func (b *Backend) Priority() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.priority
+}
+
+// SetPriority places the backend in a failover tier. The balancer only sends
+// traffic to the lowest tier that has a backend in rotation, so backends with
+// a higher priority value act as fallbacks. Backends start in tier 0.
+func (b *Backend) SetPriority(priority int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.priority = priority
+}
+
+// SetMaxRPS declares the most requests per second the backend can handle.
+// Weighted strategies derive the backend's share from it and selection never
+// sends more than this rate. Zero or less removes the cap.
+func (b *Backend) SetMaxRPS(rps float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if rps <= 0 {
+		b.maxRPS = nil
+		return
+	}
+	// Allow bursts of up to 100ms worth of requests
+	b.maxRPS = ratelimit.NewTokenBucket(rps, int(math.Ceil(rps/10)))
+}
+
+// MaxRPS returns the declared capacity in requests per second, or 0 if none.
+func (b *Backend) MaxRPS() float64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.maxRPS == nil {
+		return 0
+	}
+	return b.maxRPS.Rate()
+}
+
+// SetRateLimit caps the rate of requests sent to the backend at rps per
+// second with bursts of up to burst, to protect a fragile backend. Unlike
+// SetMaxRPS it does not affect the backend's weight. Zero or less removes it.
+func (b *Backend) SetRateLimit(rps float64, burst int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if rps <= 0 {
+		b.rateLimit = nil
+		return
+	}
+	b.rateLimit = ratelimit.NewTokenBucket(rps, burst)
+}
+
+// AllowRequest reports whether the backend may take one more request without
+// exceeding its declared capacity or rate limit, and accounts for it if so.
+func (b *Backend) AllowRequest() bool {
+	b.mu.RLock()
+	capacity, limit := b.maxRPS, b.rateLimit
+	b.mu.RUnlock()
+	return (capacity == nil || capacity.Allow()) && (limit == nil || limit.Allow())
+}
+
+// SetHealthPath sets the path active health checks probe on this backend,
+// overriding the checker's default. It must start with "/".
+func (b *Backend) SetHealthPath(path string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.healthPath = path
+}
+
+// HealthPath returns the backend's own health check path, or "" if it uses
+// the checker's default.
+func (b *Backend) HealthPath() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.healthPath
+}
+
+// SetHealthCheckInterval sets how often active health checks probe this
+// backend, overriding the checker's interval. Zero or less restores it.
+func (b *Backend) SetHealthCheckInterval(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.healthEvery = d
+}
+
+// HealthCheckInterval returns the backend's own probe interval, or 0 if it
+// uses the checker's.
+func (b *Backend) HealthCheckInterval() time.Duration {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.healthEvery
+}
+
+// SetMaxConnections caps how many requests may be in flight to the backend at
+// once; a backend at its cap is skipped during selection. Zero or less
+// removes the cap.
+func (b *Backend) SetMaxConnections(n int) {
+	if n < 0 {
+		n = 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.maxConns = n
+}
+
+// MaxConnections returns the in-flight request cap, or 0 if none.
+func (b *Backend) MaxConnections() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.maxConns
+}
+
+// ReserveCapacity holds back fraction of the backend's MaxConnections for
+// requests of the given class, so other classes can never use it up. It has
+// no effect without a MaxConnections cap. A fraction of zero or less removes
+// the reservation.
+func (b *Backend) ReserveCapacity(class string, fraction float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if fraction <= 0 {
+		delete(b.reserved, class)
+		return
+	}
+	if b.reserved == nil {
+		b.reserved = make(map[string]float64)
+	}
+	b.reserved[class] = min(fraction, 1)
+}
+
+// AtCapacityFor reports whether a request of the given class would exceed
+// the backend's capacity: the MaxConnections cap minus whatever is reserved
+// for other classes. Connections of every class count against the limit.
+func (b *Backend) AtCapacityFor(class string) bool {
+	limit, capped := b.limitFor(class)
+	return capped && float64(b.ActiveConnections()) >= limit
+}
+
+// limitFor returns how many connections requests of the given class may
+// fill, and whether there is a cap at all.
+func (b *Backend) limitFor(class string) (float64, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	limit := float64(b.maxConns)
+	for c, fraction := range b.reserved {
+		if c != class {
+			limit -= fraction * float64(b.maxConns)
+		}
+	}
+	return limit, b.maxConns > 0
+}
+
+// ActiveConnections returns how many requests are currently in flight to the backend.
+func (b *Backend) ActiveConnections() int64 {
+	return b.activeConns.Load()
+}
+
+// IncrementConnections marks the start of a request proxied to the backend.
+func (b *Backend) IncrementConnections() {
+	b.activeConns.Add(1)
+	b.totalRequests.Add(1)
+}
+
+// TotalRequests returns how many requests have been proxied to the backend,
+// counting each attempt of a retried request.
+func (b *Backend) TotalRequests() uint64 {
+	return b.totalRequests.Load()
+}
+
+// TryIncrementConnectionsFor is IncrementConnections for a request of the
+// given class that only succeeds while the backend is below its capacity
+// for that class (see AtCapacityFor). Checking and counting is one atomic
+// step, so concurrent requests can never take the backend past its cap
+// together. It reports whether the request was counted; if so it must be
+// ended with DecrementConnections.
+func (b *Backend) TryIncrementConnectionsFor(class string) bool {
+	limit, capped := b.limitFor(class)
+	for {
+		active := b.activeConns.Load()
+		if capped && float64(active) >= limit {
+			return false
+		}
+		if b.activeConns.CompareAndSwap(active, active+1) {
+			b.totalRequests.Add(1)
+			return true
+		}
+	}
+}
+
+// MarkSelected counts a selection of the backend by a balancer.
+func (b *Backend) MarkSelected() {
+	b.selections.Add(1)
+}
+
+// Selections returns how many times the backend has been selected. Unlike
+// TotalRequests it also counts selections that were never proxied, e.g. by
+// callers of SelectBackend that route requests themselves.
+func (b *Backend) Selections() uint64 {
+	return b.selections.Load()
+}
+
+// DecrementConnections marks the end of a request started with
+// IncrementConnections and calls the OnConnectionReleased hook, if any.
+func (b *Backend) DecrementConnections() {
+	b.activeConns.Add(-1)
+	if fn := b.onRelease.Load(); fn != nil {
+		(*fn)()
+	}
+}
+
+// OnConnectionReleased registers fn to be called every time a connection to
+// the backend is released, e.g. to wake requests waiting for capacity. It
+// replaces any previous hook; nil removes it. fn must not block.
+func (b *Backend) OnConnectionReleased(fn func()) {
+	if fn == nil {
+		b.onRelease.Store(nil)
+		return
+	}
+	b.onRelease.Store(&fn)
+}
+
+// MarkProxyHooked records that a balancer has wrapped the ReverseProxy's
+// Director and ModifyResponse, and reports whether this is the first time.
+// A balancer only wraps them when it is, so a backend that is removed and
+// added back, or shared by two balancers, never runs the hooks twice.
+func (b *Backend) MarkProxyHooked() bool {
+	return b.proxyHooked.CompareAndSwap(false, true)
+}
+
+// AtCapacity reports whether the backend has reached its MaxConnections cap.
+func (b *Backend) AtCapacity() bool {
+	limit := b.MaxConnections()
+	return limit > 0 && b.ActiveConnections() >= int64(limit)
+}
+
+// Drain stops the balancer from selecting the backend for new requests while
+// letting in-flight ones finish. A draining backend keeps its health status;
+// it is simply no longer offered traffic.
+func (b *Backend) Drain() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.draining = true
+}
+
+// Undrain puts a draining backend back into rotation.
+func (b *Backend) Undrain() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.draining = false
+}
+
+// IsDraining reports whether Drain has been called on the backend.
+func (b *Backend) IsDraining() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.draining
+}
+
+// SetEnabled takes the backend out of rotation (false) or puts it back (true)
+// without touching its health status, e.g. to debug it. Health checks keep
+// probing a disabled backend so its true health is known when it returns.
+func (b *Backend) SetEnabled(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.disabled == enabled {
+		bumpStateGeneration()
+	}
+	b.disabled = !enabled
+}
+
+// IsEnabled reports whether the backend is in rotation. Backends start enabled.
+func (b *Backend) IsEnabled() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return !b.disabled
+}
+
+// SetLogger sends errors reported by the backend's ReverseProxy, such as a
+// response body that failed mid-copy, to l tagged with the backend's host.
+func (b *Backend) SetLogger(l logging.Logger) {
+	b.ReverseProxy.ErrorLog = log.New(proxyLogWriter{logger: l, backend: b.URL.Host}, "", 0)
+}
+
+// proxyLogWriter adapts a Logger to the *log.Logger ReverseProxy expects.
+type proxyLogWriter struct {
+	logger  logging.Logger
+	backend string
+}
+
+func (w proxyLogWriter) Write(p []byte) (int, error) {
+	w.logger.Warn("reverse proxy error", "backend", w.backend, "error", strings.TrimSpace(string(p)))
+	return len(p), nil
 }
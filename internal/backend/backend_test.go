@@ -0,0 +1,184 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+)
+
+// TestNewBackendValidation tests that malformed URLs are rejected instead of crashing
+func TestNewBackendValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{"Valid URL", "http://localhost:3000", false},
+		{"Unparseable URL", "http://[::1", true},
+		{"Missing Scheme", "localhost:3000/health", true},
+		{"Missing Host", "http://", true},
+		{"Relative Path", "/health", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBackend(tt.url)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error for %q, got backend %v", tt.url, b.URL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", tt.url, err)
+			}
+			if b.ReverseProxy == nil {
+				t.Error("Expected ReverseProxy to be initialized")
+			}
+		})
+	}
+}
+
+// TestMatchesLabels tests label selector matching and that labels are fixed at construction
+func TestMatchesLabels(t *testing.T) {
+	labels := map[string]string{"region": "us-east", "version": "v2"}
+	b, err := NewBackendWithLabels("http://localhost:3000", labels)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		selector map[string]string
+		want     bool
+	}{
+		{"Empty Selector", nil, true},
+		{"Single Label", map[string]string{"region": "us-east"}, true},
+		{"All Labels", map[string]string{"region": "us-east", "version": "v2"}, true},
+		{"Wrong Value", map[string]string{"region": "eu-west"}, false},
+		{"Missing Key", map[string]string{"tier": "canary"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.MatchesLabels(tt.selector); got != tt.want {
+				t.Errorf("MatchesLabels(%v) = %v, want %v", tt.selector, got, tt.want)
+			}
+		})
+	}
+
+	labels["region"] = "eu-west"
+	b.Labels()["version"] = "v3"
+	if got := b.Labels(); got["region"] != "us-east" || got["version"] != "v2" {
+		t.Errorf("Expected labels to be immutable, got %v", got)
+	}
+}
+
+// TestEffectiveState tests that operator and outlier flags override the health check result
+func TestEffectiveState(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(*Backend)
+		expected EffectiveState
+	}{
+		{"Not Checked Yet", func(b *Backend) {}, EffectiveUnknown},
+		{"Healthy", func(b *Backend) { b.SetHealthState(Healthy) }, EffectiveHealthy},
+		{"Degraded", func(b *Backend) { b.SetHealthState(Degraded) }, EffectiveDegraded},
+		{"Dead", func(b *Backend) { b.SetHealthState(Dead) }, EffectiveDead},
+		{"Ejected", func(b *Backend) {
+			b.SetHealthState(Healthy)
+			b.Eject(time.Minute)
+		}, EffectiveEjected},
+		{"Draining While Healthy", func(b *Backend) {
+			b.SetHealthState(Healthy)
+			b.Drain()
+		}, EffectiveDraining},
+		{"Disabled While Draining", func(b *Backend) {
+			b.SetHealthState(Healthy)
+			b.Drain()
+			b.SetEnabled(false)
+		}, EffectiveDisabled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBackend("http://localhost:3000")
+			if err != nil {
+				t.Fatalf("Failed to create backend: %v", err)
+			}
+			tt.setup(b)
+			if got := b.EffectiveState(); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+			if inRotation := tt.expected == EffectiveHealthy || tt.expected == EffectiveDegraded; b.EffectiveState().InRotation() != inRotation {
+				t.Errorf("Expected InRotation %v for %v", inRotation, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSetClock tests that recoveries and ejections are timed on the backend's clock
+func TestSetClock(t *testing.T) {
+	b, err := NewBackend("http://localhost:3000")
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	b.SetClock(clk)
+
+	b.SetAlive(true)
+	if got := b.RecoveredAt(); !got.IsZero() {
+		t.Errorf("Expected no recovery when coming up from unknown, got %v", got)
+	}
+	b.SetAlive(false)
+	b.SetAlive(true)
+	if got := b.RecoveredAt(); !got.Equal(start) {
+		t.Errorf("Expected recovery at %v, got %v", start, got)
+	}
+
+	b.Eject(time.Minute)
+	if !b.IsEjected() {
+		t.Fatal("Expected the backend to be ejected")
+	}
+	clk.Advance(time.Minute)
+	if b.IsEjected() {
+		t.Error("Expected the ejection to end once the fake clock passed it")
+	}
+
+	b.SetClock(nil)
+	b.SetAlive(false)
+	b.SetAlive(true)
+	if got := b.RecoveredAt(); got.Year() == 2024 {
+		t.Errorf("Expected the real clock after SetClock(nil), got %v", got)
+	}
+}
+
+// TestStateChanged tests that state changes wake waiters on StateChanged
+func TestStateChanged(t *testing.T) {
+	b, err := NewBackend("http://localhost:3000")
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	changed := StateChanged()
+	select {
+	case <-changed:
+		t.Fatal("Expected no state change yet")
+	default:
+	}
+
+	b.SetAlive(true)
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected SetAlive to close the channel")
+	}
+
+	changed = StateChanged()
+	b.SetWeight(3)
+	select {
+	case <-changed:
+		t.Error("Expected a weight change not to count as a state change")
+	default:
+	}
+}
@@ -0,0 +1,78 @@
+package backend
+
+// EffectiveState is a backend's standing in the rotation, combining its
+// health check result with the operator and outlier flags that override it.
+// A draining backend that passes its checks is EffectiveDraining, not
+// EffectiveHealthy, since it takes no new requests either way.
+type EffectiveState int
+
+const (
+	// EffectiveDead backends fail their health checks.
+	EffectiveDead EffectiveState = iota
+	// EffectiveDegraded backends pass their checks under stress and only
+	// take traffic no healthy backend can.
+	EffectiveDegraded
+	// EffectiveHealthy backends are in rotation.
+	EffectiveHealthy
+	// EffectiveUnknown backends have not been health checked yet.
+	EffectiveUnknown
+	// EffectiveEjected backends are held out by outlier detection.
+	EffectiveEjected
+	// EffectiveDraining backends finish their in-flight requests but take
+	// no new ones; see Drain.
+	EffectiveDraining
+	// EffectiveDisabled backends were taken out of rotation with SetEnabled.
+	EffectiveDisabled
+)
+
+func (s EffectiveState) String() string {
+	switch s {
+	case EffectiveDead:
+		return "dead"
+	case EffectiveDegraded:
+		return "degraded"
+	case EffectiveHealthy:
+		return "healthy"
+	case EffectiveEjected:
+		return "ejected"
+	case EffectiveDraining:
+		return "draining"
+	case EffectiveDisabled:
+		return "disabled"
+	default:
+		return "unknown"
+	}
+}
+
+// InRotation reports whether backends in state s may be sent new requests.
+func (s EffectiveState) InRotation() bool {
+	return s == EffectiveHealthy || s == EffectiveDegraded
+}
+
+// EffectiveState returns the backend's standing in the rotation: disabled
+// first, then draining, then ejected, and otherwise its health state. Health
+// checks keep probing a disabled or draining backend and record the results
+// in its HealthState, but a passing probe never puts it back into rotation.
+func (b *Backend) EffectiveState() EffectiveState {
+	ejected := b.IsEjected()
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	switch {
+	case b.disabled:
+		return EffectiveDisabled
+	case b.draining:
+		return EffectiveDraining
+	case ejected:
+		return EffectiveEjected
+	}
+	switch b.state {
+	case Healthy:
+		return EffectiveHealthy
+	case Degraded:
+		return EffectiveDegraded
+	case Dead:
+		return EffectiveDead
+	default:
+		return EffectiveUnknown
+	}
+}
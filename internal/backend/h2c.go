@@ -0,0 +1,39 @@
+package backend
+
+import (
+	"net/http"
+	"sync"
+)
+
+// h2cTransport is shared by every backend proxied over h2c so they pool
+// their connections like backends on http.DefaultTransport do.
+var h2cTransport = sync.OnceValue(func() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Protocols = new(http.Protocols)
+	transport.Protocols.SetUnencryptedHTTP2(true)
+	return transport
+})
+
+// EnableH2C makes the backend's ReverseProxy speak HTTP/2 over cleartext
+// (h2c, with prior knowledge) instead of HTTP/1.1, so streams to it are
+// multiplexed over a few connections. Request bodies and trailers stream
+// through unchanged. It only applies to http backends, replaces any
+// Transport set on the ReverseProxy and must be called before the backend
+// serves traffic. HTTP health checks still use HTTP/1.1, so an h2c-only
+// backend needs tcp or grpc checks.
+func (b *Backend) EnableH2C() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.URL.Scheme != "http" {
+		return
+	}
+	b.ReverseProxy.Transport = h2cTransport()
+	b.h2c = true
+}
+
+// H2C returns whether EnableH2C switched the backend to h2c.
+func (b *Backend) H2C() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.h2c
+}
@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"net/http"
+	"slices"
+)
+
+// SetRequestHeaders sets static headers, such as an internal API key or an
+// X-Backend-Zone tag, on every request proxied to the backend. A header the
+// client already sent is left alone unless override is true, in which case
+// the configured value replaces it. The balancer's X-Forwarded-* and
+// X-Real-IP headers are set afterwards and win over headers configured here.
+// A nil or empty map removes any headers set before.
+func (b *Backend) SetRequestHeaders(headers map[string]string, override bool) {
+	var h http.Header
+	if len(headers) > 0 {
+		h = make(http.Header, len(headers))
+		for name, value := range headers {
+			h.Set(name, value)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.requestHeaders = h
+	b.overrideHeaders = override
+}
+
+// RequestHeaders returns a copy of the headers set with SetRequestHeaders
+// and whether they override the client's.
+func (b *Backend) RequestHeaders() (headers map[string]string, override bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.requestHeaders == nil {
+		return nil, b.overrideHeaders
+	}
+	headers = make(map[string]string, len(b.requestHeaders))
+	for name := range b.requestHeaders {
+		headers[name] = b.requestHeaders.Get(name)
+	}
+	return headers, b.overrideHeaders
+}
+
+// addRequestHeaders applies the backend's static headers to an outbound request.
+func (b *Backend) addRequestHeaders(h http.Header) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for name, values := range b.requestHeaders {
+		if _, sent := h[name]; sent && !b.overrideHeaders {
+			continue
+		}
+		h[name] = slices.Clone(values)
+	}
+}
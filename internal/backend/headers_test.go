@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRequestHeaders tests that static headers reach the backend without clobbering the client's unless told to
+func TestRequestHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		override bool
+		sent     map[string]string
+		expected map[string]string
+	}{
+		{"Added", map[string]string{"x-backend-zone": "us-east-1a"}, false, nil,
+			map[string]string{"X-Backend-Zone": "us-east-1a"}},
+		{"Client Value Kept", map[string]string{"X-Api-Key": "internal"}, false, map[string]string{"X-Api-Key": "client"},
+			map[string]string{"X-Api-Key": "client"}},
+		{"Client Value Overridden", map[string]string{"X-Api-Key": "internal"}, true, map[string]string{"X-Api-Key": "client"},
+			map[string]string{"X-Api-Key": "internal"}},
+		{"Other Client Headers Untouched", map[string]string{"X-Api-Key": "internal"}, true, map[string]string{"X-Trace": "abc"},
+			map[string]string{"X-Api-Key": "internal", "X-Trace": "abc"}},
+		{"Cleared", nil, true, nil, map[string]string{"X-Api-Key": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBackend(server.URL)
+			if err != nil {
+				t.Fatalf("Failed to create backend: %v", err)
+			}
+			b.SetRequestHeaders(map[string]string{"X-Api-Key": "stale"}, true)
+			b.SetRequestHeaders(tt.headers, tt.override)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range tt.sent {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			b.ReverseProxy.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", rec.Code)
+			}
+			for name, value := range tt.expected {
+				if got := received.Get(name); got != value {
+					t.Errorf("%s = %q, expected %q", name, got, value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyWindowSize is how many recent samples feed the percentile estimates.
+// Memory per backend stays fixed no matter how much traffic it serves.
+const latencyWindowSize = 1024
+
+// DefaultLatencySmoothing is the EWMA weight given to each new latency sample.
+const DefaultLatencySmoothing = 0.3
+
+// LatencyPercentiles summarizes the recent response latencies of a backend.
+type LatencyPercentiles struct {
+	P50     time.Duration
+	P95     time.Duration
+	P99     time.Duration
+	Samples int
+}
+
+// latencyWindow is a fixed-size ring buffer of the most recent latencies.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples [latencyWindowSize]time.Duration
+	next    int
+	count   int
+
+	// Exponentially weighted moving average; alpha of 0 means the default
+	alpha float64
+	ewma  float64
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ewma == 0 {
+		w.ewma = float64(d)
+	} else {
+		alpha := w.alpha
+		if alpha == 0 {
+			alpha = DefaultLatencySmoothing
+		}
+		w.ewma += alpha * (float64(d) - w.ewma)
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencyWindowSize
+	if w.count < latencyWindowSize {
+		w.count++
+	}
+}
+
+// resetAverage forgets the moving average, so the next sample starts it
+// afresh. The samples behind the percentiles are kept.
+func (w *latencyWindow) resetAverage() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.ewma = 0
+}
+
+func (w *latencyWindow) percentiles() LatencyPercentiles {
+	w.mu.Lock()
+	sorted := make([]time.Duration, w.count)
+	copy(sorted, w.samples[:w.count])
+	w.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return LatencyPercentiles{}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return LatencyPercentiles{
+		P50:     nearestRank(sorted, 0.50),
+		P95:     nearestRank(sorted, 0.95),
+		P99:     nearestRank(sorted, 0.99),
+		Samples: len(sorted),
+	}
+}
+
+// nearestRank returns the q-th quantile of sorted using the nearest-rank method.
+func nearestRank(sorted []time.Duration, q float64) time.Duration {
+	rank := int(q*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// RecordLatency records how long a proxied request to the backend took.
+func (b *Backend) RecordLatency(d time.Duration) {
+	b.latencies.add(d)
+	b.updateScore()
+}
+
+// AvgLatency returns the exponentially weighted moving average of the
+// backend's response latency. It is zero until the first sample is recorded,
+// and again after the backend recovers from being dead until it serves its
+// next request, so latencies from before the outage don't count against it.
+func (b *Backend) AvgLatency() time.Duration {
+	b.latencies.mu.Lock()
+	defer b.latencies.mu.Unlock()
+	return time.Duration(b.latencies.ewma)
+}
+
+// SetLatencySmoothing sets the weight in (0, 1] given to each new sample in
+// AvgLatency; higher values react faster but are noisier. Values outside
+// that range restore DefaultLatencySmoothing.
+func (b *Backend) SetLatencySmoothing(alpha float64) {
+	if alpha <= 0 || alpha > 1 {
+		alpha = DefaultLatencySmoothing
+	}
+	b.latencies.mu.Lock()
+	defer b.latencies.mu.Unlock()
+	b.latencies.alpha = alpha
+}
+
+// LatencyPercentiles returns p50/p95/p99 over the most recent requests.
+func (b *Backend) LatencyPercentiles() LatencyPercentiles {
+	return b.latencies.percentiles()
+}
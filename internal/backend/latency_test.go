@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// TestLatencyPercentiles tests percentile estimates against a known distribution
+func TestLatencyPercentiles(t *testing.T) {
+	b, err := NewBackend("http://localhost:3000")
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	t.Run("No Samples", func(t *testing.T) {
+		if p := b.LatencyPercentiles(); p.Samples != 0 || p.P99 != 0 {
+			t.Errorf("Expected empty percentiles, got %+v", p)
+		}
+	})
+
+	t.Run("Uniform Distribution", func(t *testing.T) {
+		// 1ms..1000ms, each exactly once, in random order
+		for _, i := range rand.Perm(1000) {
+			b.RecordLatency(time.Duration(i+1) * time.Millisecond)
+		}
+
+		p := b.LatencyPercentiles()
+		checks := []struct {
+			name     string
+			got      time.Duration
+			expected time.Duration
+		}{
+			{"p50", p.P50, 500 * time.Millisecond},
+			{"p95", p.P95, 950 * time.Millisecond},
+			{"p99", p.P99, 990 * time.Millisecond},
+		}
+		for _, c := range checks {
+			tolerance := c.expected / 50 // 2%
+			if c.got < c.expected-tolerance || c.got > c.expected+tolerance {
+				t.Errorf("%s = %v, expected %v (±%v)", c.name, c.got, c.expected, tolerance)
+			}
+		}
+	})
+
+	t.Run("Memory Stays Bounded", func(t *testing.T) {
+		for i := 0; i < 10*latencyWindowSize; i++ {
+			b.RecordLatency(5 * time.Millisecond)
+		}
+
+		p := b.LatencyPercentiles()
+		if p.Samples != latencyWindowSize {
+			t.Errorf("Expected window capped at %d samples, got %d", latencyWindowSize, p.Samples)
+		}
+		// Old samples have rolled out of the window
+		if p.P99 != 5*time.Millisecond {
+			t.Errorf("Expected p99 of recent samples to be 5ms, got %v", p.P99)
+		}
+	})
+}
+
+// TestAvgLatency tests that the EWMA follows the smoothing factor
+func TestAvgLatency(t *testing.T) {
+	b, err := NewBackend("http://localhost:3000")
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+
+	if avg := b.AvgLatency(); avg != 0 {
+		t.Errorf("Expected zero average before any samples, got %v", avg)
+	}
+
+	b.SetLatencySmoothing(0.5)
+	b.RecordLatency(100 * time.Millisecond)
+	if avg := b.AvgLatency(); avg != 100*time.Millisecond {
+		t.Errorf("Expected the first sample to seed the average, got %v", avg)
+	}
+
+	b.RecordLatency(200 * time.Millisecond)
+	if avg := b.AvgLatency(); avg != 150*time.Millisecond {
+		t.Errorf("Expected average of 150ms with alpha 0.5, got %v", avg)
+	}
+
+	b.SetAlive(false)
+	b.SetAlive(true)
+	if avg := b.AvgLatency(); avg != 0 {
+		t.Errorf("Expected recovery to reset the average, got %v", avg)
+	}
+	if p := b.LatencyPercentiles(); p.Samples != 2 {
+		t.Errorf("Expected recovery to keep the percentile samples, got %d", p.Samples)
+	}
+
+	b.RecordLatency(40 * time.Millisecond)
+	if avg := b.AvgLatency(); avg != 40*time.Millisecond {
+		t.Errorf("Expected the first sample after recovery to seed the average, got %v", avg)
+	}
+}
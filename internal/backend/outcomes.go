@@ -0,0 +1,96 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// outcomeBuckets is how many one-second buckets of request outcomes each
+// backend keeps, which bounds the longest usable error-rate window.
+const outcomeBuckets = 60
+
+// outcomeBucket counts the requests that completed within one second.
+type outcomeBucket struct {
+	second   int64
+	requests int
+	errors   int
+}
+
+// outcomeWindow is a ring of per-second request and error counts.
+type outcomeWindow struct {
+	mu      sync.Mutex
+	buckets [outcomeBuckets]outcomeBucket
+}
+
+func (w *outcomeWindow) add(now time.Time, failed bool) {
+	sec := now.Unix()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	bucket := &w.buckets[sec%outcomeBuckets]
+	if bucket.second != sec {
+		*bucket = outcomeBucket{second: sec}
+	}
+	bucket.requests++
+	if failed {
+		bucket.errors++
+	}
+}
+
+func (w *outcomeWindow) rate(now time.Time, window time.Duration) (requests, errors int) {
+	seconds := min(int64(window/time.Second), outcomeBuckets)
+	oldest := now.Unix() - max(seconds, 1) + 1
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, bucket := range w.buckets {
+		if bucket.second >= oldest {
+			requests += bucket.requests
+			errors += bucket.errors
+		}
+	}
+	return requests, errors
+}
+
+func (w *outcomeWindow) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buckets = [outcomeBuckets]outcomeBucket{}
+}
+
+// RecordOutcome records whether a request proxied to the backend failed,
+// i.e. ended in a transport error or a 5xx response.
+func (b *Backend) RecordOutcome(failed bool) {
+	b.outcomes.add(b.now(), failed)
+	b.updateScore()
+}
+
+// ErrorRate returns how many requests completed in the last window and how
+// many of them failed. The window is counted in whole seconds, from one up to
+// a minute.
+func (b *Backend) ErrorRate(window time.Duration) (requests, errors int) {
+	return b.outcomes.rate(b.now(), window)
+}
+
+// Eject takes the backend out of rotation for d because of its error rate
+// and forgets its recorded outcomes, so it is judged afresh once re-admitted.
+func (b *Backend) Eject(d time.Duration) {
+	b.outcomes.reset()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ejectedUntil = b.now().Add(d)
+	bumpStateGeneration()
+}
+
+// EjectedUntil returns when the backend's current or last ejection ends. It
+// is zero if the backend has never been ejected.
+func (b *Backend) EjectedUntil() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.ejectedUntil
+}
+
+// IsEjected reports whether the backend is within an ejection period.
+func (b *Backend) IsEjected() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.now().Before(b.ejectedUntil)
+}
@@ -0,0 +1,36 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+// TestOutcomeWindow tests that error rates only count outcomes inside the window
+func TestOutcomeWindow(t *testing.T) {
+	var w outcomeWindow
+	start := time.Unix(1000, 0)
+
+	w.add(start, true)
+	w.add(start, false)
+	w.add(start.Add(5*time.Second), true)
+	w.add(start.Add(9*time.Second), false)
+
+	now := start.Add(9 * time.Second)
+	if req, errs := w.rate(now, 10*time.Second); req != 4 || errs != 2 {
+		t.Errorf("10s window: expected 4 requests and 2 errors, got %d and %d", req, errs)
+	}
+	if req, errs := w.rate(now, 5*time.Second); req != 2 || errs != 1 {
+		t.Errorf("5s window: expected 2 requests and 1 error, got %d and %d", req, errs)
+	}
+
+	// A bucket reused a minute later must not carry old counts
+	w.add(start.Add(outcomeBuckets*time.Second), false)
+	if req, errs := w.rate(start.Add(outcomeBuckets*time.Second), time.Second); req != 1 || errs != 0 {
+		t.Errorf("Reused bucket: expected 1 request and 0 errors, got %d and %d", req, errs)
+	}
+
+	w.reset()
+	if req, _ := w.rate(now, time.Minute); req != 0 {
+		t.Errorf("Expected no requests after reset, got %d", req)
+	}
+}
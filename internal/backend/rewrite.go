@@ -0,0 +1,56 @@
+package backend
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SetPathRewrite rewrites the path of requests proxied to the backend: the
+// stripPrefix path segments are removed if present and pathPrefix is put in
+// front, e.g. stripPrefix "/api/v1" and pathPrefix "/svc" send /api/v1/users
+// to /svc/users. Either may be empty. Query strings and percent-encoding are
+// kept as sent. Any path in the backend's URL still comes first.
+func (b *Backend) SetPathRewrite(stripPrefix, pathPrefix string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stripPrefix = strings.TrimSuffix(stripPrefix, "/")
+	b.pathPrefix = strings.TrimSuffix(pathPrefix, "/")
+}
+
+// PathRewrite returns the prefixes set with SetPathRewrite.
+func (b *Backend) PathRewrite() (stripPrefix, pathPrefix string) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.stripPrefix, b.pathPrefix
+}
+
+// rewritePath applies the backend's path rewrite to u in place.
+func (b *Backend) rewritePath(u *url.URL) {
+	strip, prefix := b.PathRewrite()
+	if strip == "" && prefix == "" {
+		return
+	}
+
+	// Work on the escaped form so encoded slashes and the like survive
+	path := u.EscapedPath()
+	if strip != "" {
+		escaped := (&url.URL{Path: strip}).EscapedPath()
+		if rest, ok := strings.CutPrefix(path, escaped); ok && (rest == "" || rest[0] == '/') {
+			path = rest
+		}
+	}
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+	path = (&url.URL{Path: prefix}).EscapedPath() + path
+
+	unescaped, err := url.PathUnescape(path)
+	if err != nil {
+		return // u came from a parsed request, so this can't happen
+	}
+	u.Path = unescaped
+	u.RawPath = ""
+	if u.EscapedPath() != path {
+		u.RawPath = path
+	}
+}
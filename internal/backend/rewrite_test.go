@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestPathRewrite tests that prefixes are stripped and prepended with queries and escaping intact
+func TestPathRewrite(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RequestURI()))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		base       string
+		strip      string
+		prefix     string
+		requestURI string
+		expected   string
+	}{
+		{"No Rewrite", "", "", "", "/api/v1/users?id=7", "/api/v1/users?id=7"},
+		{"Strip Prefix", "", "/api/v1", "", "/api/v1/users?id=7", "/users?id=7"},
+		{"Strip To Root", "", "/api/v1/", "", "/api/v1", "/"},
+		{"Strip Only Whole Segments", "", "/api/v1", "", "/api/v10/users", "/api/v10/users"},
+		{"Prepend Prefix", "", "", "/svc", "/users", "/svc/users"},
+		{"Strip And Prepend", "", "/api/v1", "/svc/", "/api/v1/users", "/svc/users"},
+		{"Escaping Preserved", "", "/api/v1", "/svc", "/api/v1/a%2Fb/c%20d?q=x%26y", "/svc/a%2Fb/c%20d?q=x%26y"},
+		{"Backend Path Comes First", "/base", "/api", "/svc", "/api/users", "/base/svc/users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBackend(server.URL + tt.base)
+			if err != nil {
+				t.Fatalf("Failed to create backend: %v", err)
+			}
+			b.SetPathRewrite(tt.strip, tt.prefix)
+
+			rec := httptest.NewRecorder()
+			b.ReverseProxy.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.requestURI, nil))
+			if got := rec.Body.String(); got != tt.expected {
+				t.Errorf("Expected backend to see %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
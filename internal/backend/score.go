@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"math"
+	"time"
+)
+
+// ScoreCoefficients tune how HealthScore weighs a backend's latency against
+// its error rate. Zero fields take the DefaultScoreCoefficients value.
+type ScoreCoefficients struct {
+	// LatencyWeight is the share of the score latency can take away: a
+	// backend at LatencyTarget loses half of it.
+	LatencyWeight float64
+	// LatencyTarget is the smoothed latency (see AvgLatency) that costs half
+	// of LatencyWeight; slower backends lose more, approaching all of it.
+	LatencyTarget time.Duration
+	// ErrorWeight is the share of the score errors can take away: a backend
+	// failing every request in ErrorWindow loses all of it.
+	ErrorWeight float64
+	// ErrorWindow is how far back the error rate looks, up to a minute.
+	ErrorWindow time.Duration
+}
+
+// DefaultScoreCoefficients weigh errors somewhat more than latency.
+var DefaultScoreCoefficients = ScoreCoefficients{
+	LatencyWeight: 0.4,
+	LatencyTarget: 100 * time.Millisecond,
+	ErrorWeight:   0.6,
+	ErrorWindow:   10 * time.Second,
+}
+
+// withDefaults fills in the zero fields of c.
+func (c ScoreCoefficients) withDefaults() ScoreCoefficients {
+	d := DefaultScoreCoefficients
+	if c.LatencyWeight <= 0 {
+		c.LatencyWeight = d.LatencyWeight
+	}
+	if c.LatencyTarget <= 0 {
+		c.LatencyTarget = d.LatencyTarget
+	}
+	if c.ErrorWeight <= 0 {
+		c.ErrorWeight = d.ErrorWeight
+	}
+	if c.ErrorWindow <= 0 {
+		c.ErrorWindow = d.ErrorWindow
+	}
+	return c
+}
+
+// SetScoreCoefficients changes how HealthScore is computed from the next
+// sample on.
+func (b *Backend) SetScoreCoefficients(c ScoreCoefficients) {
+	c = c.withDefaults()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.scoring = c
+}
+
+// HealthScore rates the backend from 0 to 100 by its recent latency and error
+// rate, so one number captures both the latency and the outlier signals. It
+// is 0 while the backend is dead and 100 before it has served any requests.
+// The score is recomputed as latency samples and request outcomes come in.
+func (b *Backend) HealthScore() int {
+	if !b.IsAlive() {
+		return 0
+	}
+	return int(b.score.Load())
+}
+
+// updateScore recomputes the health score from the current samples.
+func (b *Backend) updateScore() {
+	b.mu.RLock()
+	c := b.scoring
+	b.mu.RUnlock()
+	if c == (ScoreCoefficients{}) {
+		c = DefaultScoreCoefficients
+	}
+
+	score := 1.0
+	if avg := b.AvgLatency(); avg > 0 {
+		score -= c.LatencyWeight * float64(avg) / float64(avg+c.LatencyTarget)
+	}
+	if requests, errors := b.ErrorRate(c.ErrorWindow); requests > 0 {
+		score -= c.ErrorWeight * float64(errors) / float64(requests)
+	}
+	b.score.Store(int64(math.Round(100 * max(score, 0))))
+}
@@ -0,0 +1,47 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+// TestHealthScore tests that the score falls with latency and errors as weighted by the coefficients
+func TestHealthScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		coeffs   *ScoreCoefficients
+		dead     bool
+		latency  time.Duration
+		outcomes []bool // true for a failed request
+		expected int
+	}{
+		{"Fresh Backend", nil, false, 0, nil, 100},
+		{"Dead Backend", nil, true, 0, nil, 0},
+		{"Latency At Target", nil, false, 100 * time.Millisecond, nil, 80},
+		{"Every Request Failing", nil, false, 0, []bool{true, true}, 40},
+		{"Half Failing And Slow", nil, false, 100 * time.Millisecond, []bool{true, false}, 50},
+		{"Custom Coefficients", &ScoreCoefficients{LatencyWeight: 1, LatencyTarget: 50 * time.Millisecond}, false, 50 * time.Millisecond, nil, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBackend("http://localhost:3000")
+			if err != nil {
+				t.Fatalf("Failed to create backend: %v", err)
+			}
+			b.SetAlive(!tt.dead)
+			if tt.coeffs != nil {
+				b.SetScoreCoefficients(*tt.coeffs)
+			}
+			if tt.latency > 0 {
+				b.RecordLatency(tt.latency)
+			}
+			for _, failed := range tt.outcomes {
+				b.RecordOutcome(failed)
+			}
+
+			if got := b.HealthScore(); got != tt.expected {
+				t.Errorf("Expected score %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
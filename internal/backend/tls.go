@@ -0,0 +1,53 @@
+package backend
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+)
+
+// errTLSNeedsHTTPS is returned by SetTLS for backends that don't speak TLS.
+var errTLSNeedsHTTPS = errors.New("TLS settings only apply to https backends")
+
+// TLSConfig customizes how the backend's certificate is verified, for
+// backends reached by IP address whose certificate names a host.
+type TLSConfig struct {
+	// ServerName is sent as SNI and checked against the certificate
+	// instead of the URL's host.
+	ServerName string
+
+	// RootCAs verifies the certificate instead of the system roots, e.g.
+	// with a private CA bundle; nil keeps the system roots.
+	RootCAs *x509.CertPool
+}
+
+// SetTLS gives the backend's ReverseProxy its own transport that verifies
+// the backend with cfg, replacing any Transport already set. Health checkers
+// probe it with the same settings. It fails for backends whose URL is not
+// https, and must be called before the backend serves traffic.
+func (b *Backend) SetTLS(cfg TLSConfig) error {
+	if b.URL.Scheme != "https" {
+		return errTLSNeedsHTTPS
+	}
+	tlsConfig := &tls.Config{ServerName: cfg.ServerName, RootCAs: cfg.RootCAs}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tlsConfig = tlsConfig
+	b.ReverseProxy.Transport = transport
+	return nil
+}
+
+// TLSClientConfig returns a copy of the TLS settings given to SetTLS, or nil if
+// the backend uses the defaults.
+func (b *Backend) TLSClientConfig() *tls.Config {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.tlsConfig == nil {
+		return nil
+	}
+	return b.tlsConfig.Clone()
+}
@@ -0,0 +1,64 @@
+package backend
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSetTLS tests that the proxy verifies the backend with its own server name and CAs
+func TestSetTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.ServerName))
+	}))
+	defer server.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	t.Run("Rejects HTTP", func(t *testing.T) {
+		b, err := NewBackend("http://localhost:3000")
+		if err != nil {
+			t.Fatalf("Failed to create backend: %v", err)
+		}
+		if err := b.SetTLS(TLSConfig{ServerName: "example.com"}); err == nil {
+			t.Error("Expected SetTLS to fail for an http backend")
+		}
+		if b.TLSClientConfig() != nil {
+			t.Error("Expected no TLS settings after a failed SetTLS")
+		}
+	})
+
+	tests := []struct {
+		name       string
+		serverName string
+		expected   int
+	}{
+		// The httptest certificate is issued for example.com
+		{"Name On Certificate", "example.com", http.StatusOK},
+		{"Name Not On Certificate", "api.internal", http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewBackend(server.URL)
+			if err != nil {
+				t.Fatalf("Failed to create backend: %v", err)
+			}
+			if err := b.SetTLS(TLSConfig{ServerName: tt.serverName, RootCAs: roots}); err != nil {
+				t.Fatalf("Failed to set TLS: %v", err)
+			}
+			if got := b.TLSClientConfig().ServerName; got != tt.serverName {
+				t.Errorf("Expected server name %q, got %q", tt.serverName, got)
+			}
+
+			rec := httptest.NewRecorder()
+			b.ReverseProxy.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d", tt.expected, rec.Code)
+			}
+			if tt.expected == http.StatusOK && rec.Body.String() != tt.serverName {
+				t.Errorf("Expected SNI %q, got %q", tt.serverName, rec.Body.String())
+			}
+		})
+	}
+}
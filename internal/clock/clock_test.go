@@ -0,0 +1,68 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// TestFake tests that the fake clock only fires timers and tickers when advanced
+func TestFake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("After Fires Once Due", func(t *testing.T) {
+		clk := NewFake(start)
+		c := clk.After(time.Second)
+
+		clk.Advance(999 * time.Millisecond)
+		select {
+		case <-c:
+			t.Fatal("Expected After not to fire early")
+		default:
+		}
+
+		clk.Advance(time.Millisecond)
+		if got := <-c; !got.Equal(start.Add(time.Second)) {
+			t.Errorf("Expected fire time %v, got %v", start.Add(time.Second), got)
+		}
+		if clk.Waiters() != 0 {
+			t.Errorf("Expected a fired After to stop waiting, got %d waiters", clk.Waiters())
+		}
+	})
+
+	t.Run("Ticker Drops Ticks For Slow Receivers", func(t *testing.T) {
+		clk := NewFake(start)
+		ticker := clk.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		clk.Advance(3 * time.Second)
+		if got := <-ticker.C(); !got.Equal(start.Add(time.Second)) {
+			t.Errorf("Expected the first tick to be kept, got %v", got)
+		}
+		select {
+		case got := <-ticker.C():
+			t.Errorf("Expected later ticks to be dropped, got %v", got)
+		default:
+		}
+
+		clk.Advance(time.Second)
+		if got := <-ticker.C(); !got.Equal(start.Add(4 * time.Second)) {
+			t.Errorf("Expected the next tick at 4s, got %v", got)
+		}
+	})
+
+	t.Run("Stopped Ticker Stays Quiet", func(t *testing.T) {
+		clk := NewFake(start)
+		ticker := clk.NewTicker(time.Second)
+		ticker.Stop()
+
+		clk.Advance(time.Minute)
+		select {
+		case <-ticker.C():
+			t.Error("Expected no ticks after Stop")
+		default:
+		}
+		if clk.Waiters() != 0 {
+			t.Errorf("Expected no waiters after Stop, got %d", clk.Waiters())
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package healthcheck
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// probeBackoff tracks how many upcoming ticks a failing backend sits out.
+type probeBackoff struct {
+	failures  int
+	skipTicks int
+}
+
+// due reports whether b should be probed on this tick, consuming one skipped
+// tick if it is backing off.
+func (hc *HealthChecker) due(b *backend.Backend) bool {
+	if hc.maxBackoff == 0 {
+		return true
+	}
+
+	hc.backoffMu.Lock()
+	defer hc.backoffMu.Unlock()
+	state := hc.backoff[b]
+	if state == nil || state.skipTicks == 0 {
+		return true
+	}
+	state.skipTicks--
+	return false
+}
+
+// recordOutcome clears b's backoff after a successful probe, even if b needs
+// more of them to come back up, and grows it after a failed probe once b is
+// dead. The gap between probes grows by the backoff multiplier with every
+// consecutive failure until it reaches the configured maximum, counted in
+// ticks of the backend's own interval.
+func (hc *HealthChecker) recordOutcome(b *backend.Backend, probeOK bool) {
+	interval := hc.intervalFor(b)
+	if hc.maxBackoff == 0 || interval <= 0 {
+		return
+	}
+	maxTicks := int(hc.maxBackoff / interval)
+	if maxTicks <= 1 {
+		return
+	}
+
+	hc.backoffMu.Lock()
+	defer hc.backoffMu.Unlock()
+	if probeOK || b.IsAlive() {
+		delete(hc.backoff, b)
+		return
+	}
+
+	state := hc.backoff[b]
+	if state == nil {
+		state = &probeBackoff{}
+		hc.backoff[b] = state
+	}
+	state.failures++
+	gap := math.Ceil(math.Pow(hc.backoffMultiplier, float64(state.failures)))
+	state.skipTicks = int(min(gap, float64(maxTicks))) - 1
+}
+
+// ResetBackoff clears the backoff accumulated by the backend whose URL host
+// matches host, so it is probed again on the next tick and at the base
+// interval after that. Use it once an operator has fixed a backend.
+func (hc *HealthChecker) ResetBackoff(host string) error {
+	b := hc.lookup(host)
+	if b == nil {
+		return fmt.Errorf("backend %s not found", host)
+	}
+
+	hc.backoffMu.Lock()
+	defer hc.backoffMu.Unlock()
+	delete(hc.backoff, b)
+	return nil
+}
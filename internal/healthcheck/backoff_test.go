@@ -0,0 +1,153 @@
+package healthcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestResetBackoff tests that a backed-off backend returns to the base probe cadence
+func TestResetBackoff(t *testing.T) {
+	var probes atomic.Int64
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithBackoff(8*time.Second))
+
+	// ticks drives n health check rounds and returns how many probes were sent
+	ticks := func(n int) int64 {
+		before := probes.Load()
+		for i := 0; i < n; i++ {
+			hc.checkAllBackends()
+		}
+		return probes.Load() - before
+	}
+
+	t.Run("Dead Backend Backs Off", func(t *testing.T) {
+		// Gaps of 2, 4 and then 8 ticks after consecutive failures
+		if got := ticks(15); got != 4 {
+			t.Errorf("Expected 4 probes over 15 ticks while backing off, got %d", got)
+		}
+	})
+
+	t.Run("Reset Restores Base Cadence", func(t *testing.T) {
+		healthy.Store(true)
+		if err := hc.ResetBackoff(b.URL.Host); err != nil {
+			t.Fatalf("ResetBackoff failed: %v", err)
+		}
+
+		if got := ticks(3); got != 3 {
+			t.Errorf("Expected a probe on every tick after reset, got %d over 3 ticks", got)
+		}
+		if !b.IsAlive() {
+			t.Error("Expected backend to be alive after reset probe")
+		}
+	})
+
+	t.Run("CheckNow Ignores Backoff", func(t *testing.T) {
+		healthy.Store(false)
+		ticks(4) // back off again
+
+		healthy.Store(true)
+		if err := hc.CheckNow(b.URL.Host, true); err != nil {
+			t.Fatalf("CheckNow failed: %v", err)
+		}
+		if !b.IsAlive() {
+			t.Error("Expected CheckNow to mark the backend alive")
+		}
+		if got := ticks(2); got != 2 {
+			t.Errorf("Expected base cadence after CheckNow, got %d probes over 2 ticks", got)
+		}
+	})
+
+	t.Run("Unknown Host", func(t *testing.T) {
+		if err := hc.ResetBackoff("localhost:1"); err == nil {
+			t.Error("Expected error for unknown host")
+		}
+	})
+}
+
+// TestBackoffMultiplier tests the growth of probe gaps and that backoff waits until the backend is marked dead
+func TestBackoffMultiplier(t *testing.T) {
+	var probes atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		ticks  int
+		probes int64
+	}{
+		// Gaps of 3, 9 and 27 ticks: probes on ticks 1, 4, 13 and 40
+		{"Custom Multiplier", []Option{WithBackoff(27 * time.Second), WithBackoffMultiplier(3)}, 40, 4},
+		// Ticks 1-3 mark it dead, then gaps of 2 and 4: probes on ticks 1, 2, 3, 5 and 9
+		{"Waits For Unhealthy Threshold", []Option{WithBackoff(time.Minute), WithThresholds(1, 3)}, 10, 5},
+		{"Multiplier Of One Ignored", []Option{WithBackoff(8 * time.Second), WithBackoffMultiplier(1)}, 15, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBackend(t, server.URL)
+			b.SetAlive(true)
+			hc := NewHealthChecker([]*backend.Backend{b}, time.Second, tt.opts...)
+
+			before := probes.Load()
+			for i := 0; i < tt.ticks; i++ {
+				hc.checkAllBackends()
+			}
+			if got := probes.Load() - before; got != tt.probes {
+				t.Errorf("Expected %d probes over %d ticks, got %d", tt.probes, tt.ticks, got)
+			}
+		})
+	}
+}
+
+// TestBackoffRecovery tests that a dead backend passing probes stops backing off
+// before it has passed enough of them to come back up
+func TestBackoffRecovery(t *testing.T) {
+	var probes atomic.Int64
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	b.SetAlive(true)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithBackoff(time.Minute), WithThresholds(3, 1))
+
+	// Failures on ticks 1, 3, 7 and 15 leave a gap of 16 ticks
+	for i := 0; i < 15; i++ {
+		hc.checkAllBackends()
+	}
+
+	// Ticks 31, 32 and 33 bring it back
+	healthy.Store(true)
+	before := probes.Load()
+	for i := 0; i < 18; i++ {
+		hc.checkAllBackends()
+	}
+	if got := probes.Load() - before; got != 3 {
+		t.Errorf("Expected 3 probes once the backend passes, got %d", got)
+	}
+	if !b.IsAlive() {
+		t.Errorf("Expected the backend back up, got %v", b.HealthState())
+	}
+}
@@ -0,0 +1,123 @@
+package healthcheck
+
+import (
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// CheckType selects how backends are probed.
+type CheckType int
+
+const (
+	// CheckHTTP sends GET to the health path and expects 200 OK. It is the default.
+	CheckHTTP CheckType = iota
+
+	// CheckTCP only requires a TCP connection to the backend's host and port
+	// to succeed.
+	CheckTCP
+
+	// CheckGRPC calls grpc.health.v1.Health/Check on the backend's host and
+	// port and expects SERVING. Backends with an https URL are dialed over TLS.
+	CheckGRPC
+)
+
+func (t CheckType) String() string {
+	switch t {
+	case CheckHTTP:
+		return "http"
+	case CheckTCP:
+		return "tcp"
+	case CheckGRPC:
+		return "grpc"
+	default:
+		return "unknown"
+	}
+}
+
+// checkTCP probes b by opening and closing a TCP connection
+func (hc *HealthChecker) checkTCP(b *backend.Backend) {
+	ctx, cancel := hc.probeContext(b)
+	defer cancel()
+
+	start := hc.clock.Now()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", b.URL.Host)
+	probe := hc.timeProbe(ctx, b, start)
+	if err != nil {
+		if hc.ctx.Err() != nil {
+			return
+		}
+		hc.markDown(b, probe, transportFailure(err))
+		return
+	}
+	conn.Close()
+	hc.markUp(b, probe)
+}
+
+// checkGRPC probes b with the standard gRPC health checking protocol
+func (hc *HealthChecker) checkGRPC(b *backend.Backend) {
+	conn, err := hc.grpcConn(b)
+	if err != nil {
+		hc.logger.Error("could not create gRPC health check client", "backend", b.URL.Host, "error", err)
+		return
+	}
+
+	ctx, cancel := hc.probeContext(b)
+	defer cancel()
+
+	start := hc.clock.Now()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: hc.grpcService})
+	probe := hc.timeProbe(ctx, b, start)
+	if err != nil {
+		if hc.ctx.Err() != nil {
+			return
+		}
+		hc.markDown(b, probe, transportFailure(err))
+		return
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		hc.markDown(b, probe, statusFailure(resp.GetStatus()))
+		return
+	}
+	hc.markUp(b, probe)
+}
+
+// grpcConn returns the cached client connection for b, creating it on first
+// use. Connections are closed by Stop.
+func (hc *HealthChecker) grpcConn(b *backend.Backend) (*grpc.ClientConn, error) {
+	hc.grpcMu.Lock()
+	defer hc.grpcMu.Unlock()
+	if conn, ok := hc.grpcConns[b]; ok {
+		return conn, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if b.URL.Scheme == "https" {
+		creds = credentials.NewTLS(b.TLSClientConfig())
+	}
+	conn, err := grpc.NewClient(b.URL.Host, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	if hc.grpcConns == nil {
+		hc.grpcConns = make(map[*backend.Backend]*grpc.ClientConn)
+	}
+	hc.grpcConns[b] = conn
+	return conn, nil
+}
+
+// closeGRPCConns closes every cached gRPC connection.
+func (hc *HealthChecker) closeGRPCConns() {
+	hc.grpcMu.Lock()
+	defer hc.grpcMu.Unlock()
+	for b, conn := range hc.grpcConns {
+		conn.Close()
+		delete(hc.grpcConns, b)
+	}
+}
@@ -0,0 +1,98 @@
+package healthcheck
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// newListener listens on a free local port, closed when the test ends
+func newListener(t *testing.T) net.Listener {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+	return lis
+}
+
+// TestTCPCheck tests that a TCP check only needs the port to accept connections
+func TestTCPCheck(t *testing.T) {
+	lis := newListener(t)
+	open := newTestBackend(t, "http://"+lis.Addr().String())
+
+	closedLis := newListener(t)
+	closed := newTestBackend(t, "http://"+closedLis.Addr().String())
+	closedLis.Close()
+
+	hc := NewHealthChecker([]*backend.Backend{open, closed}, time.Second, WithCheckType(CheckTCP))
+	hc.checkAllBackends()
+
+	if !open.IsAlive() {
+		t.Error("Expected backend with a listening port to be alive")
+	}
+	if closed.IsAlive() {
+		t.Error("Expected backend with a closed port to be dead")
+	}
+}
+
+// TestGRPCCheck tests probing backends with the grpc.health.v1 protocol
+func TestGRPCCheck(t *testing.T) {
+	lis := newListener(t)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("payments", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	gs := grpc.NewServer()
+	healthpb.RegisterHealthServer(gs, healthServer)
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	check := func(t *testing.T, service string) bool {
+		t.Helper()
+		b := newTestBackend(t, "http://"+lis.Addr().String())
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second,
+			WithCheckType(CheckGRPC), WithGRPCService(service))
+		defer hc.Stop()
+		hc.checkAllBackends()
+		return b.IsAlive()
+	}
+
+	t.Run("Overall Server Serving", func(t *testing.T) {
+		if !check(t, "") {
+			t.Error("Expected backend to be alive when the server is SERVING")
+		}
+	})
+
+	t.Run("Named Service Serving", func(t *testing.T) {
+		if !check(t, "orders") {
+			t.Error("Expected backend to be alive when its service is SERVING")
+		}
+	})
+
+	t.Run("Named Service Not Serving", func(t *testing.T) {
+		if check(t, "payments") {
+			t.Error("Expected backend to be dead when its service is NOT_SERVING")
+		}
+	})
+
+	t.Run("Unknown Service", func(t *testing.T) {
+		if check(t, "inventory") {
+			t.Error("Expected backend to be dead for a service the server doesn't know")
+		}
+	})
+
+	t.Run("Server Down", func(t *testing.T) {
+		gs.Stop()
+		if check(t, "") {
+			t.Error("Expected backend to be dead once the server stops")
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package healthcheck
+
+import (
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// defaultDrainGrace is how long requests already in flight to a backend that
+// just went dead may take to finish before a warning is logged.
+const defaultDrainGrace = 30 * time.Second
+
+// drainPollInterval is how often a dead backend's in-flight count is checked.
+const drainPollInterval = 10 * time.Millisecond
+
+// watchDrain follows the requests still in flight to b after it went dead.
+// Selection already skips b, so this only reports: once they have all
+// finished b is logged as drained and safe to recycle, and if some are still
+// open after the grace period a warning is logged instead. The watch ends early if b recovers or
+// the checker is stopped.
+func (hc *HealthChecker) watchDrain(b *backend.Backend) {
+	if hc.drainGrace <= 0 || b.ActiveConnections() == 0 {
+		return
+	}
+
+	hc.watches.Add(1)
+	go func() {
+		defer hc.watches.Done()
+		ticker := hc.clock.NewTicker(drainPollInterval)
+		defer ticker.Stop()
+		deadline := hc.clock.After(hc.drainGrace)
+
+		for {
+			select {
+			case <-hc.ctx.Done():
+				return
+			case <-deadline:
+				hc.logger.Warn("dead backend still has requests in flight after the drain grace period",
+					"backend", b.URL.Host, "active", b.ActiveConnections(), "grace", hc.drainGrace)
+				return
+			case <-ticker.C():
+				if b.IsAlive() {
+					return
+				}
+				if b.ActiveConnections() == 0 {
+					hc.logger.Info("dead backend drained", "backend", b.URL.Host)
+					return
+				}
+			}
+		}
+	}()
+}
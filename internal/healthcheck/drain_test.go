@@ -0,0 +1,81 @@
+package healthcheck
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// waitForEntry polls logger until an entry with the given prefix is logged
+func waitForEntry(t *testing.T, logger *recordingLogger, prefix string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		logger.mu.Lock()
+		found := slices.ContainsFunc(logger.entries, func(e string) bool { return strings.HasPrefix(e, prefix) })
+		logger.mu.Unlock()
+		if found {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	t.Fatalf("Expected an entry starting with %q, got %q", prefix, logger.entries)
+}
+
+// TestDrainOnDeath tests that requests in flight to a backend that goes dead are watched until they finish
+func TestDrainOnDeath(t *testing.T) {
+	server := newHealthServer(t, http.StatusServiceUnavailable)
+
+	t.Run("Logs Once Drained", func(t *testing.T) {
+		b := newTestBackend(t, server.URL)
+		b.SetAlive(true)
+		b.IncrementConnections()
+		logger := &recordingLogger{}
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithLogger(logger))
+		defer hc.Stop()
+
+		hc.checkBackend(b)
+		time.Sleep(30 * time.Millisecond)
+		b.DecrementConnections()
+
+		waitForEntry(t, logger, "INFO dead backend drained")
+	})
+
+	t.Run("Warns After Grace Period", func(t *testing.T) {
+		b := newTestBackend(t, server.URL)
+		b.SetAlive(true)
+		b.IncrementConnections()
+		defer b.DecrementConnections()
+		logger := &recordingLogger{}
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithLogger(logger), WithDrainGrace(30*time.Millisecond))
+		defer hc.Stop()
+
+		hc.checkBackend(b)
+
+		waitForEntry(t, logger, "WARN dead backend still has requests in flight")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		b := newTestBackend(t, server.URL)
+		b.SetAlive(true)
+		b.IncrementConnections()
+		logger := &recordingLogger{}
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithLogger(logger), WithDrainGrace(0))
+
+		hc.checkBackend(b)
+		b.DecrementConnections()
+		hc.Stop()
+
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		if slices.ContainsFunc(logger.entries, func(e string) bool { return strings.Contains(e, "drain") }) {
+			t.Errorf("Expected no drain entries with the watch disabled, got %q", logger.entries)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FailureKind says why a health probe failed.
+type FailureKind int
+
+const (
+	// FailureConnection covers transport errors not classified below.
+	FailureConnection FailureKind = iota
+	// FailureDNS means the backend's hostname could not be resolved.
+	FailureDNS
+	// FailureRefused means nothing accepted the connection.
+	FailureRefused
+	// FailureTimeout means the probe ran past its timeout.
+	FailureTimeout
+	// FailureStatus means the backend answered with an unhealthy status.
+	FailureStatus
+	// FailureBody means the body failed WithBodyValidator.
+	FailureBody
+)
+
+func (k FailureKind) String() string {
+	switch k {
+	case FailureDNS:
+		return "dns failure"
+	case FailureRefused:
+		return "connection refused"
+	case FailureTimeout:
+		return "timeout"
+	case FailureStatus:
+		return "bad status"
+	case FailureBody:
+		return "body mismatch"
+	default:
+		return "connection error"
+	}
+}
+
+// ProbeError is the classified cause of a failed probe, recorded on the
+// backend (see backend.Backend.LastError) and in its history.
+type ProbeError struct {
+	Kind FailureKind
+	Err  error
+}
+
+func (e *ProbeError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Kind, e.Err)
+}
+
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// errUnexpectedBody is the cause of probes whose body fails WithBodyValidator.
+var errUnexpectedBody = errors.New("unexpected health response body")
+
+// transportFailure classifies an error returned while reaching a backend.
+func transportFailure(err error) *ProbeError {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	kind := FailureConnection
+	switch {
+	case errors.As(err, &dnsErr):
+		kind = FailureDNS
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout(),
+		status.Code(err) == codes.DeadlineExceeded:
+		kind = FailureTimeout
+	case errors.Is(err, syscall.ECONNREFUSED):
+		kind = FailureRefused
+	}
+	return &ProbeError{Kind: kind, Err: err}
+}
+
+// statusFailure reports a backend that answered with an unhealthy status.
+func statusFailure(code any) *ProbeError {
+	return &ProbeError{Kind: FailureStatus, Err: fmt.Errorf("%v", code)}
+}
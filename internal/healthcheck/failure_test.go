@@ -0,0 +1,70 @@
+package healthcheck
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestLastError tests that failed probes are classified and recorded on the backend
+func TestLastError(t *testing.T) {
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hung.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		opts []Option
+		kind FailureKind
+	}{
+		{"Connection Refused", closed.URL, nil, FailureRefused},
+		{"Timeout", hung.URL, []Option{WithTimeout(20 * time.Millisecond)}, FailureTimeout},
+		{"DNS Failure", "http://backend.invalid", nil, FailureDNS},
+		{"Bad Status", newHealthServer(t, http.StatusServiceUnavailable).URL, nil, FailureStatus},
+		{"Body Mismatch", newHealthServer(t, http.StatusOK).URL, []Option{WithBodyValidator(BodyContains("UP"))}, FailureBody},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBackend(t, tt.url)
+			hc := NewHealthChecker([]*backend.Backend{b}, time.Second, tt.opts...)
+
+			before := time.Now()
+			hc.checkBackend(b)
+
+			err, at := b.LastError()
+			var probeErr *ProbeError
+			if !errors.As(err, &probeErr) {
+				t.Fatalf("Expected a ProbeError, got %v", err)
+			}
+			if probeErr.Kind != tt.kind {
+				t.Errorf("Expected kind %q, got %q (%v)", tt.kind, probeErr.Kind, err)
+			}
+			if at.Before(before) {
+				t.Errorf("Expected failure time after %v, got %v", before, at)
+			}
+			if history := hc.HealthHistory(b); len(history) != 1 || history[0].Error != err.Error() {
+				t.Errorf("Expected the history to carry %q, got %+v", err, history)
+			}
+		})
+	}
+
+	t.Run("Kept After Recovery", func(t *testing.T) {
+		b := newTestBackend(t, newHealthServer(t, http.StatusOK).URL)
+		b.SetLastError(errors.New("earlier failure"), time.Now())
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second)
+
+		hc.checkBackend(b)
+
+		if err, _ := b.LastError(); !b.IsAlive() || err == nil {
+			t.Errorf("Expected the backend alive with its last error kept, got alive=%v error=%v", b.IsAlive(), err)
+		}
+	})
+}
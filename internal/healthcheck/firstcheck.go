@@ -0,0 +1,92 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// ErrStopped is returned by WaitForFirstCheck when the checker is stopped
+// before every backend was probed.
+var ErrStopped = errors.New("health checker stopped")
+
+// firstCheck tracks which backends have been probed at least once.
+type firstCheck struct {
+	pending map[*backend.Backend]bool
+	done    chan struct{} // closed once pending is empty
+}
+
+func newFirstCheck(backends []*backend.Backend) *firstCheck {
+	fc := &firstCheck{
+		pending: make(map[*backend.Backend]bool, len(backends)),
+		done:    make(chan struct{}),
+	}
+	for _, b := range backends {
+		fc.pending[b] = true
+	}
+	if len(fc.pending) == 0 {
+		close(fc.done)
+	}
+	return fc
+}
+
+// markProbed records that a probe of b completed, whatever its result.
+// Probes aborted by Stop don't count.
+func (hc *HealthChecker) markProbed(b *backend.Backend) {
+	if hc.ctx.Err() != nil {
+		return
+	}
+	hc.forgetFirstCheck(b)
+}
+
+// forgetFirstCheck stops WaitForFirstCheck waiting for b.
+func (hc *HealthChecker) forgetFirstCheck(b *backend.Backend) {
+	hc.firstCheckMu.Lock()
+	defer hc.firstCheckMu.Unlock()
+	if !hc.firstCheck.pending[b] {
+		return
+	}
+	delete(hc.firstCheck.pending, b)
+	if len(hc.firstCheck.pending) == 0 {
+		close(hc.firstCheck.done)
+	}
+}
+
+// expectFirstCheck makes WaitForFirstCheck also wait for b, a backend added
+// while it still waits for others. Once it has returned it stays done.
+func (hc *HealthChecker) expectFirstCheck(b *backend.Backend) {
+	hc.firstCheckMu.Lock()
+	defer hc.firstCheckMu.Unlock()
+	if len(hc.firstCheck.pending) > 0 {
+		hc.firstCheck.pending[b] = true
+	}
+}
+
+// WaitForFirstCheck blocks until every backend has been probed at least once,
+// so their states reflect a real check rather than backend.Unknown, and
+// returns nil. Call it after Start to hold traffic back until then:
+//
+//	hc.Start()
+//	if err := hc.WaitForFirstCheck(ctx); err != nil { ... }
+//
+// It returns the context's error if ctx is done first, or ErrStopped if the
+// checker is stopped. A backend counts as probed after its first check
+// whatever the result, even if the healthy or unhealthy threshold needs
+// more before its state changes.
+func (hc *HealthChecker) WaitForFirstCheck(ctx context.Context) error {
+	select {
+	case <-hc.firstCheck.done:
+		return nil
+	default:
+	}
+
+	select {
+	case <-hc.firstCheck.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-hc.ctx.Done():
+		return ErrStopped
+	}
+}
@@ -0,0 +1,74 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestWaitForFirstCheck tests that callers can hold traffic until every backend has been probed once
+func TestWaitForFirstCheck(t *testing.T) {
+	t.Run("Returns Once Every Backend Is Probed", func(t *testing.T) {
+		up := newTestBackend(t, newHealthServer(t, http.StatusOK).URL)
+		down := newTestBackend(t, newHealthServer(t, http.StatusServiceUnavailable).URL)
+		hc := NewHealthChecker([]*backend.Backend{up, down}, time.Minute, WithLogger(&recordingLogger{}))
+		if up.HealthState() != backend.Unknown || down.HealthState() != backend.Unknown {
+			t.Fatal("Expected backends to start out unknown")
+		}
+
+		hc.Start()
+		defer hc.Stop()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := hc.WaitForFirstCheck(ctx); err != nil {
+			t.Fatalf("WaitForFirstCheck failed: %v", err)
+		}
+		if up.HealthState() != backend.Healthy || down.HealthState() != backend.Dead {
+			t.Errorf("Expected healthy and dead after the first check, got %v and %v", up.HealthState(), down.HealthState())
+		}
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+
+	t.Run("Honors The Context", func(t *testing.T) {
+		b := newTestBackend(t, slow.URL)
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Minute, WithLogger(&recordingLogger{}))
+		hc.Start()
+		defer hc.Stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if err := hc.WaitForFirstCheck(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("Stop Unblocks Waiters", func(t *testing.T) {
+		b := newTestBackend(t, slow.URL)
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Minute, WithLogger(&recordingLogger{}))
+		hc.Start()
+
+		errc := make(chan error, 1)
+		go func() { errc <- hc.WaitForFirstCheck(context.Background()) }()
+		hc.Stop()
+		if err := <-errc; !errors.Is(err, ErrStopped) {
+			t.Errorf("Expected ErrStopped, got %v", err)
+		}
+		if b.HealthState() != backend.Unknown {
+			t.Errorf("Expected an aborted probe to leave the backend unknown, got %v", b.HealthState())
+		}
+	})
+}
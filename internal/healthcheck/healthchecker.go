@@ -1,130 +1,627 @@
 package healthcheck
 
 import (
+	"cmp"
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+
 	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+	"github.com/akshaykumarthakur/load-balancer/internal/metrics"
+	"github.com/akshaykumarthakur/load-balancer/pkg/logging"
 )
 
+// defaultTimeout bounds each health probe when the client sets no timeout.
+const defaultTimeout = 2 * time.Second
+
+// defaultHealthPath is probed on backends that don't set their own path.
+const defaultHealthPath = "/health"
+
+// DefaultMaxBodySize is how much of a health response body is read unless
+// WithMaxBodySize says otherwise.
+const DefaultMaxBodySize = 4 << 10
+
+// defaultBackoffMultiplier is how much the gap between probes of a dead
+// backend grows per failure with WithBackoff.
+const defaultBackoffMultiplier = 2
+
 // HealthChecker periodically checks the health of backends
 type HealthChecker struct {
-	backends []*backend.Backend
-	interval time.Duration
-	ctx      context.Context
-	cancel   context.CancelFunc
-	client   *http.Client
+	backends  []*backend.Backend // guarded by backendsMu
+	interval  time.Duration
+	ctx       context.Context
+	cancel    context.CancelFunc
+	running   atomic.Bool
+	clock     clock.Clock
+	loops     sync.WaitGroup // probe loops started by Start
+	watches   sync.WaitGroup // drain watches of backends gone dead
+	client    *http.Client
+	transport TransportConfig
+	insecure  bool // skip TLS verification in the default client
+	headers   http.Header
+	host      string
+
+	validateBody    func([]byte) bool
+	maxBodySize     int64
+	followRedirects bool
+	logger          logging.Logger
+	tracer          Tracer
+	healthPath      string
+	timeout         time.Duration
+
+	// Consecutive probe results needed to flip a backend's state
+	healthyThreshold   int
+	unhealthyThreshold int
+	streakMu           sync.Mutex
+	streaks            map[*backend.Backend]*probeStreak
+
+	history probeHistory
+
+	// Per-host probe throttling; zero means unlimited
+	perHostLimit int
+	hostSemMu    sync.Mutex
+	hostSems     map[string]chan struct{}
+
+	// Limit on probes in flight across all backends; nil means unlimited
+	checkSem chan struct{}
+
+	// Check type and the state of non-HTTP checks
+	checkType   CheckType
+	grpcService string
+	grpcMu      sync.Mutex
+	grpcConns   map[*backend.Backend]*grpc.ClientConn
+
+	// Probe backoff for failing backends; zero maxBackoff disables it
+	maxBackoff        time.Duration
+	backoffMultiplier float64
+	backoffMu         sync.Mutex
+	backoff           map[*backend.Backend]*probeBackoff
+
+	// How long a dead backend's in-flight requests get; zero disables the watch
+	drainGrace time.Duration
+
+	// Backends still waiting for their first probe; see WaitForFirstCheck
+	firstCheckMu sync.Mutex
+	firstCheck   *firstCheck
+
+	// Probes in progress, closed when done, so overlapping requests share one
+	inflightMu sync.Mutex
+	inflight   map[*backend.Backend]chan struct{}
+
+	// Probe loops per backend, cancelled by RemoveBackend
+	backendsMu  sync.Mutex
+	loopCancels map[*backend.Backend]context.CancelFunc
+
+	// Probe clients of backends with their own TLS settings
+	tlsMu      sync.Mutex
+	tlsClients map[*backend.Backend]tlsClient
+}
+
+// TransportConfig tunes the connection pool of the default probe client.
+// Zero fields keep the values from DefaultTransportConfig. The timeout of a
+// whole probe is set with WithTimeout.
+type TransportConfig struct {
+	MaxIdleConns        int           // idle connections kept across all backends
+	MaxIdleConnsPerHost int           // idle connections kept per backend
+	MaxConnsPerHost     int           // concurrent connections per backend
+	IdleConnTimeout     time.Duration // how long an idle connection is kept
+	DialTimeout         time.Duration // how long to wait for a TCP connection
+}
+
+// DefaultTransportConfig returns the pool settings used unless
+// WithTransportConfig overrides them.
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		MaxConnsPerHost:     10,
+		IdleConnTimeout:     90 * time.Second,
+		DialTimeout:         5 * time.Second,
+	}
 }
 
 // NewHealthChecker creates a new HealthChecker instance with connection pooling
-func NewHealthChecker(backends []*backend.Backend, interval time.Duration) *HealthChecker {
+func NewHealthChecker(backends []*backend.Backend, interval time.Duration, opts ...Option) *HealthChecker {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// Create HTTP client with connection pooling for optimal performance
-	client := &http.Client{
-		Timeout: 2 * time.Second,
-		Transport: &http.Transport{
-			// Connection pooling settings
-			MaxIdleConns:        100,              // Total idle connections to keep alive
-			MaxIdleConnsPerHost: 10,               // Per-host idle connections
-			IdleConnTimeout:     90 * time.Second, // Keep connections alive for 90 seconds
-			DisableKeepAlives:   false,            // Enable Keep-Alive (reuse connections)
-			DisableCompression:  true,             // Disable gzip (not needed for health checks)
-			MaxConnsPerHost:     10,               // Max concurrent connections per host
-			DialContext:         (&net.Dialer{Timeout: 5 * time.Second}).DialContext,
-		},
+	hc := &HealthChecker{
+		backends:  backends,
+		interval:  interval,
+		ctx:       ctx,
+		cancel:    cancel,
+		clock:     clock.Real(),
+		transport: DefaultTransportConfig(),
+		logger:    logging.Default(),
+
+		healthPath:         defaultHealthPath,
+		maxBodySize:        DefaultMaxBodySize,
+		healthyThreshold:   1,
+		unhealthyThreshold: 1,
+		streaks:            make(map[*backend.Backend]*probeStreak),
+		history:            probeHistory{size: defaultHistorySize},
+		backoffMultiplier:  defaultBackoffMultiplier,
+		drainGrace:         defaultDrainGrace,
+		firstCheck:         newFirstCheck(backends),
+	}
+	for _, opt := range opts {
+		opt(hc)
+	}
+	if hc.client == nil {
+		hc.client = newPooledClient(hc.transport, hc.insecure)
+		if hc.insecure {
+			hc.logger.Warn("health probes skip TLS certificate verification; do not use this in production")
+		}
+	}
+	if hc.timeout > 0 {
+		withTimeout := *hc.client
+		withTimeout.Timeout = hc.timeout
+		hc.client = &withTimeout
+	}
+	if !hc.followRedirects && hc.client.CheckRedirect == nil {
+		noRedirects := *hc.client
+		noRedirects.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		hc.client = &noRedirects
 	}
 
-	return &HealthChecker{
-		backends: backends,
-		interval: interval,
-		ctx:      ctx,
-		cancel:   cancel,
-		client:   client,
+	return hc
+}
+
+// newPooledClient creates the default probe client, reusing connections
+// across probes. With insecure, it accepts any certificate the backend presents.
+func newPooledClient(cfg TransportConfig, insecure bool) *http.Client {
+	var tlsConfig *tls.Config
+	if insecure {
+		tlsConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &http.Client{
+		Timeout: defaultTimeout,
+		Transport: &http.Transport{
+			MaxIdleConns:        cfg.MaxIdleConns,
+			MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+			IdleConnTimeout:     cfg.IdleConnTimeout,
+			DisableKeepAlives:   false, // Enable Keep-Alive (reuse connections)
+			DisableCompression:  true,  // Disable gzip (not needed for health checks)
+			MaxConnsPerHost:     cfg.MaxConnsPerHost,
+			DialContext:         (&net.Dialer{Timeout: cfg.DialTimeout}).DialContext,
+			TLSClientConfig:     tlsConfig,
+		},
 	}
 }
 
-// Start begins the health checking loop in a goroutine
+// Start begins probing every backend, each in its own goroutine on its own
+// interval: the backend's HealthCheckInterval if set, otherwise the checker's.
 func (hc *HealthChecker) Start() {
-	go hc.healthCheckLoop()
-	log.Printf("✅ Health checker started (interval: %v)", hc.interval)
+	hc.backendsMu.Lock()
+	hc.running.Store(true)
+	for _, b := range hc.backends {
+		hc.startLoop(b)
+	}
+	hc.backendsMu.Unlock()
+	hc.logger.Info("health checker started", "interval", hc.interval)
 }
 
-// Stop stops the health checker gracefully
+// Stop stops the health checker and waits for every probe loop, including
+// any probe still in flight, to exit.
 func (hc *HealthChecker) Stop() {
+	// Under backendsMu so AddBackend can't start a loop once Stop waits
+	hc.backendsMu.Lock()
 	hc.cancel()
-	log.Println("⏹️  Health checker stopped")
+	hc.backendsMu.Unlock()
+	hc.loops.Wait()
+	hc.watches.Wait()
+	hc.closeGRPCConns()
+	hc.closeTLSClients()
+	hc.running.Store(false)
+	hc.logger.Info("health checker stopped")
+}
+
+// Running reports whether Start has been called and Stop has not.
+func (hc *HealthChecker) Running() bool {
+	return hc.running.Load()
 }
 
-// healthCheckLoop runs the health checks periodically
-func (hc *HealthChecker) healthCheckLoop() {
-	ticker := time.NewTicker(hc.interval)
+// Reconfigure switches the checker to interval and applies opts on top of
+// its current settings, e.g. after a config reload. Settings opts leave out,
+// such as WithClock or WithTracer, keep their effect, and so does what the
+// checker has learned about its backends: threshold streaks, backoff, probe
+// history and which are still waiting for their first check. A running
+// checker restarts its probe loops to pick up the new interval.
+func (hc *HealthChecker) Reconfigure(interval time.Duration, opts ...Option) {
+	// Stop the loops without dropping any state: forget keeps backends that
+	// are still checked
+	hc.backendsMu.Lock()
+	running := hc.running.Load() && hc.ctx.Err() == nil
+	hc.running.Store(false)
+	for _, cancel := range hc.loopCancels {
+		cancel()
+	}
+	clear(hc.loopCancels)
+	hc.backendsMu.Unlock()
+	hc.loops.Wait()
+
+	timeout := hc.timeout
+	hc.interval = interval
+	for _, opt := range opts {
+		opt(hc)
+	}
+	if hc.timeout != timeout {
+		withTimeout := *hc.client
+		withTimeout.Timeout = cmp.Or(hc.timeout, defaultTimeout)
+		hc.client = &withTimeout
+		hc.closeTLSClients() // rebuilt from the new client on their next probe
+	}
+
+	if running {
+		hc.Start()
+	}
+}
+
+// intervalFor returns how often b is probed.
+func (hc *HealthChecker) intervalFor(b *backend.Backend) time.Duration {
+	if d := b.HealthCheckInterval(); d > 0 {
+		return d
+	}
+	return hc.interval
+}
+
+// probeLoop probes b periodically until ctx is done: the checker is stopped
+// or b was removed, in which case the loop drops b's state on its way out.
+func (hc *HealthChecker) probeLoop(ctx context.Context, b *backend.Backend) {
+	defer hc.loops.Done()
+	interval := hc.intervalFor(b)
+	ticker := hc.clock.NewTicker(interval)
 	defer ticker.Stop()
 
-	// Run health check immediately on start
-	hc.checkAllBackends()
+	// Probe immediately on start
+	hc.timedProbe(b, interval, ticker.C())
 
 	for {
 		select {
-		case <-hc.ctx.Done():
+		case <-ctx.Done():
+			if hc.ctx.Err() == nil {
+				hc.forget(b)
+			}
 			return
-		case <-ticker.C:
-			hc.checkAllBackends()
+		case <-ticker.C():
+			if hc.due(b) {
+				hc.timedProbe(b, interval, ticker.C())
+			}
 		}
 	}
 }
 
-// checkAllBackends checks the health of all backends concurrently with proper synchronization
+// timedProbe probes b and, if that took longer than interval, logs it and
+// drops the tick that fired in the meantime, so the next probe waits for a
+// fresh tick instead of starting straight away.
+func (hc *HealthChecker) timedProbe(b *backend.Backend, interval time.Duration, ticks <-chan time.Time) {
+	start := hc.clock.Now()
+	hc.probeOnce(b)
+	elapsed := hc.clock.Now().Sub(start)
+	if elapsed <= interval {
+		return
+	}
+
+	hc.logger.Warn("health probe overran the interval, skipping a tick",
+		"backend", b.URL.Host, "duration", elapsed, "interval", interval)
+	select {
+	case <-ticks:
+	default:
+	}
+}
+
+// checkAllBackends checks the health of all due backends concurrently, at
+// most WithMaxConcurrentChecks at a time, and waits for every probe to finish.
+// A cycle that takes longer than the interval is logged, as the next one is
+// already late.
 func (hc *HealthChecker) checkAllBackends() {
-	var wg sync.WaitGroup
+	start := hc.clock.Now()
 
-	for _, b := range hc.backends {
+	var due []*backend.Backend
+	for _, b := range hc.checked() {
+		if hc.due(b) {
+			due = append(due, b)
+		}
+	}
+
+	hc.probeAll(due)
+
+	if elapsed := hc.clock.Now().Sub(start); elapsed > hc.interval {
+		hc.logger.Warn("health check cycle overran the interval",
+			"backends", len(due), "duration", elapsed, "interval", hc.interval)
+	}
+}
+
+// probeAll probes backends concurrently, at most WithMaxConcurrentChecks at
+// a time, and waits for every probe to finish.
+func (hc *HealthChecker) probeAll(backends []*backend.Backend) {
+	workers := len(backends)
+	if hc.checkSem != nil {
+		workers = min(workers, cap(hc.checkSem))
+	}
+	queue := make(chan *backend.Backend, len(backends))
+	for _, b := range backends {
+		queue <- b
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	for range workers {
 		wg.Add(1)
-		// Pass backend as parameter to avoid closure variable capture issues
-		go func(backend *backend.Backend) {
+		go func() {
 			defer wg.Done()
-			hc.checkBackend(backend)
-		}(b)
+			for b := range queue {
+				hc.probeOnce(b)
+			}
+		}()
 	}
-
-	// Wait for all health checks to complete before returning
 	wg.Wait()
 }
 
-// checkBackend checks the health of a single backend
+// probe checks b once, respecting the per-host and global limits, and updates
+// its backoff.
+func (hc *HealthChecker) probe(b *backend.Backend) {
+	release := hc.acquireHost(b)
+	defer release()
+	if hc.checkSem != nil {
+		hc.checkSem <- struct{}{}
+		defer func() { <-hc.checkSem }()
+	}
+	hc.checkBackend(b)
+	hc.markProbed(b)
+	hc.recordOutcome(b, hc.lastProbeHealthy(b))
+}
+
+// CheckNow probes the backend whose URL host matches host immediately,
+// regardless of its backoff, and returns once its status is updated. If a
+// probe of it is already running, CheckNow waits for that one instead. With
+// resetBackoff the accumulated backoff is cleared first, so a backend that is
+// still failing starts backing off again from the base interval.
+func (hc *HealthChecker) CheckNow(host string, resetBackoff bool) error {
+	b := hc.lookup(host)
+	if b == nil {
+		return fmt.Errorf("backend %s not found", host)
+	}
+	if resetBackoff {
+		hc.ResetBackoff(host)
+	}
+	hc.probeOnce(b)
+	return nil
+}
+
+// lookup returns the checked backend whose URL host matches host, or nil.
+func (hc *HealthChecker) lookup(host string) *backend.Backend {
+	for _, b := range hc.checked() {
+		if b.URL.Host == host {
+			return b
+		}
+	}
+	return nil
+}
+
+// acquireHost blocks until a probe slot for b's host is free and returns the
+// function that gives it back. Backends on different ports of the same
+// machine share a host.
+func (hc *HealthChecker) acquireHost(b *backend.Backend) (release func()) {
+	if hc.perHostLimit <= 0 {
+		return func() {}
+	}
+
+	hc.hostSemMu.Lock()
+	sem, ok := hc.hostSems[b.URL.Hostname()]
+	if !ok {
+		sem = make(chan struct{}, hc.perHostLimit)
+		hc.hostSems[b.URL.Hostname()] = sem
+	}
+	hc.hostSemMu.Unlock()
+
+	sem <- struct{}{}
+	return func() { <-sem }
+}
+
+// checkBackend checks the health of a single backend with the configured check type
 func (hc *HealthChecker) checkBackend(b *backend.Backend) {
-	resp, err := hc.client.Get(b.URL.String() + "/health")
+	switch hc.checkType {
+	case CheckTCP:
+		hc.checkTCP(b)
+	case CheckGRPC:
+		hc.checkGRPC(b)
+	default:
+		hc.checkHTTP(b)
+	}
+}
 
+// checkHTTP probes b's health path over HTTP
+func (hc *HealthChecker) checkHTTP(b *backend.Backend) {
+	path := b.HealthPath()
+	if path == "" {
+		path = hc.healthPath
+	}
+	ctx, cancel := hc.probeContext(b)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.URL.String()+path, nil)
 	if err != nil {
-		wasAlive := b.IsAlive()
-		b.SetAlive(false)
-		if wasAlive {
-			log.Printf("❌ Health check failed for %s: %v", b.URL, err)
+		hc.logger.Error("could not build health check request", "backend", b.URL.Host, "error", err)
+		return
+	}
+	for key, values := range hc.headers {
+		req.Header[key] = values
+	}
+	if hc.host != "" {
+		req.Host = hc.host
+	}
+
+	start := hc.clock.Now()
+	resp, err := hc.clientFor(b).Do(req)
+	probe := hc.timeProbe(ctx, b, start)
+	if err != nil {
+		// A probe aborted by Stop says nothing about the backend
+		if hc.ctx.Err() != nil {
+			return
 		}
+		hc.markDown(b, probe, transportFailure(err))
 		return
 	}
 	defer resp.Body.Close()
+	probe.Status = resp.StatusCode
 
 	// Read response body to enable connection reuse in the pool
-	_, _ = io.ReadAll(resp.Body)
+	var reader io.Reader = resp.Body
+	if hc.maxBodySize > 0 {
+		reader = io.LimitReader(resp.Body, hc.maxBodySize)
+	}
+	body, _ := io.ReadAll(reader)
 
 	// Check if response is successful
-	if resp.StatusCode == http.StatusOK {
-		wasAlive := b.IsAlive()
-		b.SetAlive(true)
-		if !wasAlive {
-			log.Printf("✅ %s is now healthy (recovered)", b.URL)
-		}
+	if resp.StatusCode != http.StatusOK {
+		hc.markDown(b, probe, statusFailure(resp.StatusCode))
+		return
+	}
+
+	// Front proxies can answer 200 while the app behind them is broken
+	if hc.validateBody != nil && !hc.validateBody(body) {
+		hc.markDown(b, probe, &ProbeError{Kind: FailureBody, Err: errUnexpectedBody})
+		return
+	}
+
+	probe.Degraded = isDegraded(body)
+	hc.markUp(b, probe)
+}
+
+// isDegraded reports whether a health response body is a JSON object with
+// "degraded": true, meaning the backend still serves but is under stress.
+func isDegraded(body []byte) bool {
+	var status struct {
+		Degraded bool `json:"degraded"`
+	}
+	return json.Unmarshal(body, &status) == nil && status.Degraded
+}
+
+// timeProbe records the duration of a probe of b that started at start and
+// returns the beginning of its ProbeResult. If the probe ran in a sampled
+// span, the observation carries its trace ID as an exemplar.
+func (hc *HealthChecker) timeProbe(ctx context.Context, b *backend.Backend, start time.Time) ProbeResult {
+	latency := hc.clock.Now().Sub(start)
+	observer := metrics.HealthCheckDuration.WithLabelValues(b.URL.Host)
+	if eo, ok := observer.(prometheus.ExemplarObserver); ok && probeTraceID(ctx) != "" {
+		eo.ObserveWithExemplar(latency.Seconds(), prometheus.Labels{"trace_id": probeTraceID(ctx)})
 	} else {
-		wasAlive := b.IsAlive()
-		b.SetAlive(false)
-		if wasAlive {
-			log.Printf("❌ %s is now unhealthy (status: %d)", b.URL, resp.StatusCode)
-		}
+		observer.Observe(latency.Seconds())
+	}
+	return ProbeResult{Time: start, Latency: latency}
+}
+
+// probeStreak counts consecutive probe results that agree.
+type probeStreak struct {
+	healthy bool
+	count   int
+}
+
+// reachedThreshold records a probe result for b and reports whether enough
+// consecutive results agree to set its state.
+func (hc *HealthChecker) reachedThreshold(b *backend.Backend, healthy bool) bool {
+	threshold := hc.unhealthyThreshold
+	if healthy {
+		threshold = hc.healthyThreshold
+	}
+
+	hc.streakMu.Lock()
+	defer hc.streakMu.Unlock()
+	streak, ok := hc.streaks[b]
+	if !ok {
+		streak = &probeStreak{}
+		hc.streaks[b] = streak
+	}
+	if streak.healthy != healthy {
+		streak.healthy, streak.count = healthy, 0
+	}
+	streak.count++
+	return streak.count >= threshold
+}
+
+// lastProbeHealthy reports whether the latest probe of b succeeded, which
+// its state doesn't show until the probes reach a threshold.
+func (hc *HealthChecker) lastProbeHealthy(b *backend.Backend) bool {
+	hc.streakMu.Lock()
+	defer hc.streakMu.Unlock()
+	streak, ok := hc.streaks[b]
+	return ok && streak.healthy
+}
+
+// markDown records a failed probe and takes b out of rotation once the
+// unhealthy threshold is reached. Every failure is recorded as b's LastError
+// and in its history, whether or not it flips b's state.
+func (hc *HealthChecker) markDown(b *backend.Backend, probe ProbeResult, failure *ProbeError) {
+	probe.Error = failure.Error()
+	hc.history.record(b, probe)
+	b.SetLastError(failure, probe.Time)
+	if !hc.reachedThreshold(b, false) {
+		return
+	}
+	wasAlive := b.IsAlive()
+	b.SetAlive(false)
+	if wasAlive {
+		hc.logger.Warn("backend is now unhealthy",
+			"backend", b.URL.Host, "state", "down", "reason", failure.Kind.String(), "error", failure.Err)
+		hc.watchDrain(b)
+	}
+}
+
+// markUp records a successful probe and puts b back into rotation, as healthy
+// or degraded according to the probe, once the healthy threshold is reached.
+// A draining or disabled backend only has its health recorded; it stays out
+// of rotation (see backend.Backend.EffectiveState).
+func (hc *HealthChecker) markUp(b *backend.Backend, probe ProbeResult) {
+	probe.Healthy = true
+	hc.history.record(b, probe)
+	if !hc.reachedThreshold(b, true) {
+		return
+	}
+	state := backend.Healthy
+	if probe.Degraded {
+		state = backend.Degraded
+	}
+	previous := b.HealthState()
+	b.SetHealthState(state)
+	switch effective := b.EffectiveState(); {
+	case previous == state:
+	case !effective.InRotation():
+		hc.logger.Info("backend passes its health checks but stays out of rotation",
+			"backend", b.URL.Host, "state", effective.String())
+	case state == backend.Degraded:
+		hc.logger.Warn("backend is degraded", "backend", b.URL.Host, "state", "degraded")
+	default:
+		hc.logger.Info("backend is now healthy", "backend", b.URL.Host, "state", "up")
+	}
+}
+
+// probeContext returns the context a single probe runs under. It is bounded
+// by the probe timeout and derived from the checker's context, so Stop
+// aborts the probe immediately instead of waiting for it to time out.
+// With WithTracer the probe also runs in a span, which cancel ends.
+func (hc *HealthChecker) probeContext(b *backend.Backend) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(hc.ctx, hc.client.Timeout)
+	if hc.tracer == nil {
+		return ctx, cancel
+	}
+	ctx, traceID, end := hc.tracer.StartProbe(ctx, b)
+	if traceID != "" {
+		ctx = context.WithValue(ctx, traceIDKey{}, traceID)
+	}
+	return ctx, func() {
+		end()
+		cancel()
 	}
 }
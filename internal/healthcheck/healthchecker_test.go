@@ -0,0 +1,815 @@
+package healthcheck
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+	"github.com/akshaykumarthakur/load-balancer/internal/metrics"
+)
+
+// newTestBackend creates a backend for the given URL, failing the test on error
+func newTestBackend(t *testing.T, urlStr string) *backend.Backend {
+	t.Helper()
+	b, err := backend.NewBackend(urlStr)
+	if err != nil {
+		t.Fatalf("Failed to create backend %s: %v", urlStr, err)
+	}
+	return b
+}
+
+// newHealthServer starts a server whose /health endpoint responds with status
+func newHealthServer(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// countingTransport counts the round trips made through it
+type countingTransport struct {
+	calls atomic.Int64
+}
+
+func (ct *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ct.calls.Add(1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// TestCustomHTTPClient tests that an injected client is used for probes
+func TestCustomHTTPClient(t *testing.T) {
+	server := newHealthServer(t, http.StatusOK)
+	b := newTestBackend(t, server.URL)
+
+	t.Run("Injected Client Is Used", func(t *testing.T) {
+		transport := &countingTransport{}
+		client := &http.Client{Transport: transport, Timeout: 5 * time.Second}
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithHTTPClient(client))
+
+		hc.checkBackend(b)
+
+		if transport.calls.Load() != 1 {
+			t.Errorf("Expected 1 probe through the injected client, got %d", transport.calls.Load())
+		}
+		if !b.IsAlive() {
+			t.Error("Expected backend to be marked alive")
+		}
+		if hc.client.Timeout != 5*time.Second {
+			t.Errorf("Expected the client's own timeout to be kept, got %v", hc.client.Timeout)
+		}
+	})
+
+	t.Run("Missing Timeout Gets Default", func(t *testing.T) {
+		client := &http.Client{}
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithHTTPClient(client))
+
+		if hc.client.Timeout != defaultTimeout {
+			t.Errorf("Expected default timeout %v, got %v", defaultTimeout, hc.client.Timeout)
+		}
+		if client.Timeout != 0 {
+			t.Error("Expected the caller's client to be left untouched")
+		}
+	})
+
+	t.Run("Nil Client Keeps Default", func(t *testing.T) {
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithHTTPClient(nil))
+
+		if hc.client == nil || hc.client.Timeout != defaultTimeout {
+			t.Error("Expected the default pooled client")
+		}
+	})
+}
+
+// TestInsecureSkipVerify tests that self-signed HTTPS backends only pass when verification is skipped
+func TestInsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	t.Run("Verified By Default", func(t *testing.T) {
+		b := newTestBackend(t, server.URL)
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second)
+
+		hc.checkBackend(b)
+
+		if b.IsAlive() {
+			t.Error("Expected backend with a self-signed certificate to fail its check")
+		}
+	})
+
+	t.Run("Skipped When Opted In", func(t *testing.T) {
+		b := newTestBackend(t, server.URL)
+		logger := &recordingLogger{}
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithInsecureSkipVerify(), WithLogger(logger))
+
+		hc.checkBackend(b)
+
+		if !b.IsAlive() {
+			t.Error("Expected backend to pass its check with verification skipped")
+		}
+		if len(logger.entries) == 0 || !strings.HasPrefix(logger.entries[0], "WARN health probes skip TLS certificate verification") {
+			t.Errorf("Expected a startup warning, got %q", logger.entries)
+		}
+	})
+}
+
+// TestCustomHeaders tests that configured headers and Host override reach the health endpoint
+func TestCustomHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Host != "health.internal" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Run("Probe Without Headers Is Rejected", func(t *testing.T) {
+		b := newTestBackend(t, server.URL)
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second)
+
+		hc.checkBackend(b)
+
+		if b.IsAlive() {
+			t.Error("Expected backend to be unhealthy without auth headers")
+		}
+	})
+
+	t.Run("Probe With Headers Passes", func(t *testing.T) {
+		b := newTestBackend(t, server.URL)
+		headers := http.Header{}
+		headers.Set("Authorization", "Bearer secret")
+		headers.Set("Host", "health.internal")
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithHeaders(headers))
+
+		hc.checkBackend(b)
+
+		if !b.IsAlive() {
+			t.Error("Expected backend to be healthy with auth headers")
+		}
+	})
+}
+
+// TestBodyValidation tests that a 200 with an unexpected body is treated as unhealthy
+func TestBodyValidation(t *testing.T) {
+	var body atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	validators := map[string]func([]byte) bool{
+		"Contains": BodyContains(`"status":"UP"`),
+		"Matches":  BodyMatches(regexp.MustCompile(`"status"\s*:\s*"UP"`)),
+	}
+
+	for name, validate := range validators {
+		t.Run(name, func(t *testing.T) {
+			b := newTestBackend(t, server.URL)
+			hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithBodyValidator(validate))
+
+			body.Store(`{"status":"UP"}`)
+			hc.checkBackend(b)
+			if !b.IsAlive() {
+				t.Error("Expected matching body to be healthy")
+			}
+
+			body.Store(`{"status":"DOWN"}`)
+			hc.checkBackend(b)
+			if b.IsAlive() {
+				t.Error("Expected non-matching body to be unhealthy")
+			}
+		})
+	}
+
+	t.Run("No Validator Accepts Any Body", func(t *testing.T) {
+		b := newTestBackend(t, server.URL)
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second)
+
+		body.Store("broken app behind proxy")
+		hc.checkBackend(b)
+		if !b.IsAlive() {
+			t.Error("Expected status-only check to pass")
+		}
+	})
+}
+
+// TestRedirectPolicy tests that redirects fail the check unless following them is enabled
+func TestRedirectPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		healthy bool
+	}{
+		{"Not Followed By Default", nil, false},
+		{"Followed When Enabled", []Option{WithFollowRedirects(true)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBackend(t, server.URL)
+			hc := NewHealthChecker([]*backend.Backend{b}, time.Second, append(tt.opts, WithLogger(&recordingLogger{}))...)
+
+			hc.checkBackend(b)
+			if b.IsAlive() != tt.healthy {
+				t.Errorf("Expected healthy %v, got state %v", tt.healthy, b.HealthState())
+			}
+		})
+	}
+}
+
+// TestMaxBodySize tests that only the configured amount of a health response body is read
+func TestMaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("x"), 2*DefaultMaxBodySize))
+		w.Write([]byte("UP"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		healthy bool
+	}{
+		{"Capped By Default", nil, false},
+		{"Unlimited When Disabled", []Option{WithMaxBodySize(0)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen int
+			validate := func(body []byte) bool {
+				seen = len(body)
+				return bytes.HasSuffix(body, []byte("UP"))
+			}
+			b := newTestBackend(t, server.URL)
+			hc := NewHealthChecker([]*backend.Backend{b}, time.Second, append(tt.opts, WithBodyValidator(validate), WithLogger(&recordingLogger{}))...)
+
+			hc.checkBackend(b)
+			if b.IsAlive() != tt.healthy {
+				t.Errorf("Expected healthy %v, got state %v", tt.healthy, b.HealthState())
+			}
+			if !tt.healthy && seen != DefaultMaxBodySize {
+				t.Errorf("Expected %d bytes read, got %d", DefaultMaxBodySize, seen)
+			}
+		})
+	}
+}
+
+// TestDegradedState tests that a "degraded": true health body keeps the backend alive but degraded
+func TestDegradedState(t *testing.T) {
+	var body atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second)
+
+	tests := []struct {
+		name string
+		body string
+		want backend.HealthState
+	}{
+		{"Degraded Field", `{"status":"UP","Degraded":true}`, backend.Degraded},
+		{"Recovers", `{"status":"UP","degraded":false}`, backend.Healthy},
+		{"Lowercase Field", `{"degraded":true}`, backend.Degraded},
+		{"Non-JSON Body", "OK", backend.Healthy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body.Store(tt.body)
+			hc.checkBackend(b)
+			if got := b.HealthState(); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+			if !b.IsAlive() {
+				t.Error("Expected backend to stay alive")
+			}
+		})
+	}
+}
+
+// TestCheckNow tests that CheckNow reprobes a backend without waiting for a tick
+func TestCheckNow(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Hour)
+	hc.checkAllBackends()
+	if b.IsAlive() {
+		t.Fatal("Expected the failing backend to be dead")
+	}
+
+	t.Run("Fixed Backend Comes Back", func(t *testing.T) {
+		healthy.Store(true)
+		if err := hc.CheckNow(b.URL.Host, false); err != nil {
+			t.Fatalf("CheckNow failed: %v", err)
+		}
+		if !b.IsAlive() {
+			t.Error("Expected CheckNow to mark the backend alive")
+		}
+	})
+
+	t.Run("Unknown Host", func(t *testing.T) {
+		if err := hc.CheckNow("localhost:1", false); err == nil {
+			t.Error("Expected error for unknown host")
+		}
+	})
+}
+
+// TestPerHostConcurrency tests that probes to backends on the same host don't overlap
+func TestPerHostConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Three backends served by the same host under different paths
+	backends := []*backend.Backend{
+		newTestBackend(t, server.URL+"/a"),
+		newTestBackend(t, server.URL+"/b"),
+		newTestBackend(t, server.URL+"/c"),
+	}
+
+	t.Run("Limit Of One Serializes Probes", func(t *testing.T) {
+		maxInFlight.Store(0)
+		hc := NewHealthChecker(backends, time.Second, WithPerHostConcurrency(1))
+
+		hc.checkAllBackends()
+
+		if got := maxInFlight.Load(); got != 1 {
+			t.Errorf("Expected at most 1 concurrent probe per host, saw %d", got)
+		}
+		for i, b := range backends {
+			if !b.IsAlive() {
+				t.Errorf("Backend %d was not probed", i)
+			}
+		}
+	})
+
+	t.Run("Unlimited By Default", func(t *testing.T) {
+		maxInFlight.Store(0)
+		hc := NewHealthChecker(backends, time.Second)
+
+		hc.checkAllBackends()
+
+		if got := maxInFlight.Load(); got != 3 {
+			t.Errorf("Expected all 3 probes to run concurrently, saw %d", got)
+		}
+	})
+}
+
+// TestMaxConcurrentChecks tests that the global limit bounds probes across all hosts
+func TestMaxConcurrentChecks(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var backends []*backend.Backend
+	for range 5 {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		backends = append(backends, newTestBackend(t, server.URL))
+	}
+
+	t.Run("Limit Bounds Concurrent Probes", func(t *testing.T) {
+		maxInFlight.Store(0)
+		hc := NewHealthChecker(backends, time.Second, WithMaxConcurrentChecks(2))
+
+		hc.checkAllBackends()
+
+		if got := maxInFlight.Load(); got != 2 {
+			t.Errorf("Expected at most 2 concurrent probes, saw %d", got)
+		}
+		for i, b := range backends {
+			if !b.IsAlive() {
+				t.Errorf("Backend %d was not probed", i)
+			}
+		}
+	})
+
+	t.Run("Overrun Is Logged", func(t *testing.T) {
+		logger := &recordingLogger{}
+		hc := NewHealthChecker(backends, 60*time.Millisecond, WithMaxConcurrentChecks(1), WithLogger(logger))
+
+		hc.checkAllBackends()
+
+		logger.mu.Lock()
+		defer logger.mu.Unlock()
+		if !slices.ContainsFunc(logger.entries, func(e string) bool {
+			return strings.HasPrefix(e, "WARN health check cycle overran the interval")
+		}) {
+			t.Errorf("Expected an overrun warning, got %q", logger.entries)
+		}
+	})
+}
+
+// TestHealthCheckDurationMetric tests that every probe is observed in the duration histogram
+func TestHealthCheckDurationMetric(t *testing.T) {
+	server := newHealthServer(t, http.StatusOK)
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second)
+
+	hc.checkBackend(b)
+	hc.checkBackend(b)
+
+	var m dto.Metric
+	histogram := metrics.HealthCheckDuration.WithLabelValues(b.URL.Host).(prometheus.Histogram)
+	if err := histogram.Write(&m); err != nil {
+		t.Fatalf("Failed to read histogram: %v", err)
+	}
+	if got := m.GetHistogram().GetSampleCount(); got != 2 {
+		t.Errorf("Expected 2 observed probes, got %d", got)
+	}
+}
+
+// recordingLogger keeps every entry logged through it
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *recordingLogger) record(level, msg string, kv []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, fmt.Sprint(level, " ", msg, " ", kv))
+}
+
+func (l *recordingLogger) Debug(msg string, kv ...any) { l.record("DEBUG", msg, kv) }
+func (l *recordingLogger) Info(msg string, kv ...any)  { l.record("INFO", msg, kv) }
+func (l *recordingLogger) Warn(msg string, kv ...any)  { l.record("WARN", msg, kv) }
+func (l *recordingLogger) Error(msg string, kv ...any) { l.record("ERROR", msg, kv) }
+
+// TestCustomLogger tests that health transitions are logged with structured fields
+func TestCustomLogger(t *testing.T) {
+	server := newHealthServer(t, http.StatusServiceUnavailable)
+	b := newTestBackend(t, server.URL)
+	b.SetAlive(true)
+
+	logger := &recordingLogger{}
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithLogger(logger))
+
+	hc.checkBackend(b)
+
+	expected := fmt.Sprint("WARN backend is now unhealthy ", []any{"backend", b.URL.Host, "state", "down", "reason", "bad status", "error", statusFailure(503).Err})
+	if len(logger.entries) != 1 || logger.entries[0] != expected {
+		t.Errorf("Expected single entry %q, got %q", expected, logger.entries)
+	}
+}
+
+// TestThresholds tests that state only flips after enough consecutive probe results
+func TestThresholds(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithThresholds(2, 3))
+
+	healthy.Store(true)
+	hc.checkBackend(b)
+	if b.IsAlive() {
+		t.Error("Expected backend to stay down after a single successful probe")
+	}
+	hc.checkBackend(b)
+	if !b.IsAlive() {
+		t.Error("Expected backend up after 2 successful probes")
+	}
+
+	healthy.Store(false)
+	hc.checkBackend(b)
+	hc.checkBackend(b)
+	if !b.IsAlive() {
+		t.Error("Expected backend to stay up after 2 failed probes")
+	}
+	hc.checkBackend(b)
+	if b.IsAlive() {
+		t.Error("Expected backend down after 3 failed probes")
+	}
+}
+
+// TestHealthPath tests the checker-wide default path and per-backend overrides
+func TestHealthPath(t *testing.T) {
+	var paths []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithHealthPath("/ready"))
+
+	hc.checkBackend(b)
+	b.SetHealthPath("/healthz")
+	hc.checkBackend(b)
+
+	if len(paths) != 2 || paths[0] != "/ready" || paths[1] != "/healthz" {
+		t.Errorf("Expected probes to /ready then /healthz, got %v", paths)
+	}
+}
+
+// waitUntil polls cond until it holds, failing the test after a second
+func waitUntil(t *testing.T, cond func() bool, format string, args ...any) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf(format, args...)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestPerBackendInterval tests that each backend is probed on its own interval
+// and that Stop ends every probe loop
+func TestPerBackendInterval(t *testing.T) {
+	var fastProbes, slowProbes atomic.Int64
+	counting := func(n *atomic.Int64) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n.Add(1)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	fast := newTestBackend(t, counting(&fastProbes).URL)
+	fast.SetHealthCheckInterval(time.Second)
+	slow := newTestBackend(t, counting(&slowProbes).URL)
+
+	clk := clock.NewFake(time.Now())
+	hc := NewHealthChecker([]*backend.Backend{fast, slow}, time.Hour, WithClock(clk))
+	hc.Start()
+	waitUntil(t, func() bool { return clk.Waiters() == 2 && fastProbes.Load() == 1 && slowProbes.Load() == 1 },
+		"Expected an initial probe of each backend")
+
+	for i := int64(2); i <= 6; i++ {
+		clk.Advance(time.Second)
+		waitUntil(t, func() bool { return fastProbes.Load() == i }, "Expected fast probe %d after a tick, got %d", i, fastProbes.Load())
+	}
+	if got := slowProbes.Load(); got != 1 {
+		t.Errorf("Expected only the initial probe of the slow backend, got %d", got)
+	}
+
+	hc.Stop()
+	if waiters := clk.Waiters(); waiters != 0 {
+		t.Errorf("Expected every ticker to be stopped, %d still waiting", waiters)
+	}
+	clk.Advance(time.Hour)
+	if got := fastProbes.Load() + slowProbes.Load(); got != 7 {
+		t.Errorf("Expected no probes after Stop, got %d more", got-7)
+	}
+}
+
+// TestReconfigure tests that a running checker picks up new settings and keeps probing
+func TestReconfigure(t *testing.T) {
+	var probes atomic.Int64
+	var path atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path.Store(r.URL.Path)
+		probes.Add(1)
+	}))
+	defer server.Close()
+
+	waitForProbes := func(n int64) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for probes.Load() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %d probes, got %d", n, probes.Load())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Hour)
+	hc.Start()
+	defer hc.Stop()
+	waitForProbes(1)
+
+	hc.Reconfigure(10*time.Millisecond, WithHealthPath("/ready"))
+	if !hc.Running() {
+		t.Fatal("Expected the checker to keep running")
+	}
+	waitForProbes(3)
+	if got := path.Load(); got != "/ready" {
+		t.Errorf("Expected probes of /ready, got %v", got)
+	}
+}
+
+// inFlightTransport tracks how many round trips are in progress through it
+type inFlightTransport struct {
+	active atomic.Int64
+}
+
+func (it *inFlightTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	it.active.Add(1)
+	defer it.active.Add(-1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// TestSlowProbeSkipsTick tests that a probe outlasting its interval is logged and
+// that the tick missed meanwhile is skipped rather than run back to back
+func TestSlowProbeSkipsTick(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+		starts      []time.Time
+		ends        []time.Time
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		starts = append(starts, time.Now())
+		mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		ends = append(ends, time.Now())
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	logger := &recordingLogger{}
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, 40*time.Millisecond, WithLogger(logger))
+
+	hc.Start()
+	time.Sleep(300 * time.Millisecond)
+	hc.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxInFlight != 1 {
+		t.Errorf("Expected probes never to overlap, saw %d at once", maxInFlight)
+	}
+	if len(starts) < 2 {
+		t.Fatalf("Expected several probes, got %d", len(starts))
+	}
+	// Without skipping, the tick that fired during a probe starts the next
+	// one immediately
+	for i := 1; i < len(starts) && i <= len(ends); i++ {
+		if gap := starts[i].Sub(ends[i-1]); gap < 10*time.Millisecond {
+			t.Errorf("Expected probe %d to wait for a fresh tick, it started %v after the last one", i, gap)
+		}
+	}
+
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if !slices.ContainsFunc(logger.entries, func(e string) bool {
+		return strings.HasPrefix(e, "WARN health probe overran the interval")
+	}) {
+		t.Errorf("Expected an overrun warning, got %q", logger.entries)
+	}
+}
+
+// TestStopWaitsForProbes tests that Stop returns only after in-flight probes
+// finish and that no goroutines outlive a start-stop cycle
+func TestStopWaitsForProbes(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+
+	transport := &inFlightTransport{}
+	client := &http.Client{Transport: transport, Timeout: 200 * time.Millisecond}
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, 10*time.Millisecond, WithHTTPClient(client))
+
+	before := runtime.NumGoroutine()
+	hc.Start()
+	time.Sleep(50 * time.Millisecond) // the probe is now blocked on the server
+
+	hc.Stop()
+	if active := transport.active.Load(); active != 0 {
+		t.Errorf("Expected no probes in flight after Stop, got %d", active)
+	}
+	if hc.Running() {
+		t.Error("Expected checker to report not running after Stop")
+	}
+
+	// The server's handler and connection goroutines wind down asynchronously
+	close(release)
+	http.DefaultTransport.(*http.Transport).CloseIdleConnections()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("Expected at most %d goroutines after Stop, got %d", before, after)
+	}
+}
+
+// TestStopAbortsProbes tests that Stop cancels a slow probe instead of waiting
+// for it to time out, without marking the backend down
+func TestStopAbortsProbes(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	b := newTestBackend(t, server.URL)
+	b.SetAlive(true)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithTimeout(5*time.Second))
+
+	hc.Start()
+	time.Sleep(50 * time.Millisecond) // the probe is now blocked on the server
+
+	start := time.Now()
+	hc.Stop()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Stop to abort the probe promptly, took %v", elapsed)
+	}
+	if !b.IsAlive() {
+		t.Error("Expected an aborted probe to leave the backend alive")
+	}
+}
+
+// TestTransportConfig tests that pool settings reach the default client and unset ones keep their defaults
+func TestTransportConfig(t *testing.T) {
+	hc := NewHealthChecker(nil, time.Second, WithTransportConfig(TransportConfig{
+		MaxConnsPerHost: 50,
+		MaxIdleConns:    1000,
+	}))
+
+	transport, ok := hc.client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected an *http.Transport, got %T", hc.client.Transport)
+	}
+	if transport.MaxConnsPerHost != 50 || transport.MaxIdleConns != 1000 {
+		t.Errorf("Expected tuned pool sizes 50/1000, got %d/%d", transport.MaxConnsPerHost, transport.MaxIdleConns)
+	}
+	defaults := DefaultTransportConfig()
+	if transport.MaxIdleConnsPerHost != defaults.MaxIdleConnsPerHost || transport.IdleConnTimeout != defaults.IdleConnTimeout {
+		t.Errorf("Expected unset fields to keep defaults, got %d and %v", transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	if hc.client.Timeout != defaultTimeout {
+		t.Errorf("Expected default probe timeout %v, got %v", defaultTimeout, hc.client.Timeout)
+	}
+}
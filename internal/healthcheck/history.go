@@ -0,0 +1,76 @@
+package healthcheck
+
+import (
+	"sync"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// defaultHistorySize is how many probe results are kept per backend unless
+// WithHistorySize says otherwise.
+const defaultHistorySize = 20
+
+// ProbeResult describes a single health probe.
+type ProbeResult struct {
+	Time     time.Time     `json:"time"`
+	Healthy  bool          `json:"healthy"`
+	Degraded bool          `json:"degraded,omitempty"` // the backend reported itself degraded
+	Status   int           `json:"status,omitempty"`   // HTTP status; 0 for TCP and gRPC checks or transport errors
+	Latency  time.Duration `json:"latency"`
+	Error    string        `json:"error,omitempty"` // why the probe failed
+}
+
+// probeHistory keeps the most recent probe results of every backend, each in
+// a fixed-size ring.
+type probeHistory struct {
+	mu    sync.Mutex
+	size  int
+	rings map[*backend.Backend]*probeRing
+}
+
+type probeRing struct {
+	results []ProbeResult
+	next    int
+}
+
+func (h *probeHistory) record(b *backend.Backend, result ProbeResult) {
+	if h.size <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.rings == nil {
+		h.rings = make(map[*backend.Backend]*probeRing)
+	}
+	ring, ok := h.rings[b]
+	if !ok {
+		ring = &probeRing{results: make([]ProbeResult, 0, h.size)}
+		h.rings[b] = ring
+	}
+	if len(ring.results) < h.size {
+		ring.results = append(ring.results, result)
+		return
+	}
+	ring.results[ring.next] = result
+	ring.next = (ring.next + 1) % h.size
+}
+
+func (h *probeHistory) get(b *backend.Backend) []ProbeResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ring, ok := h.rings[b]
+	if !ok {
+		return nil
+	}
+	history := make([]ProbeResult, 0, len(ring.results))
+	history = append(history, ring.results[ring.next:]...)
+	return append(history, ring.results[:ring.next]...)
+}
+
+// HealthHistory returns b's most recent probe results, oldest first. Probes
+// aborted by Stop are not recorded.
+func (hc *HealthChecker) HealthHistory(b *backend.Backend) []ProbeResult {
+	return hc.history.get(b)
+}
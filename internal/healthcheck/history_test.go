@@ -0,0 +1,99 @@
+package healthcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestHealthHistory tests that recent probes are kept oldest first and old ones roll off
+func TestHealthHistory(t *testing.T) {
+	var status atomic.Int64
+	status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithHistorySize(3))
+
+	if history := hc.HealthHistory(b); len(history) != 0 {
+		t.Fatalf("Expected no history before any probe, got %d entries", len(history))
+	}
+
+	hc.checkBackend(b)
+	status.Store(http.StatusServiceUnavailable)
+	hc.checkBackend(b)
+	hc.checkBackend(b)
+	status.Store(http.StatusOK)
+	hc.checkBackend(b)
+
+	history := hc.HealthHistory(b)
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(history))
+	}
+	wantHealthy := []bool{false, false, true}
+	for i, probe := range history {
+		if probe.Healthy != wantHealthy[i] {
+			t.Errorf("Entry %d: expected healthy=%v, got %+v", i, wantHealthy[i], probe)
+		}
+		if i > 0 && probe.Time.Before(history[i-1].Time) {
+			t.Errorf("Entry %d is older than entry %d", i, i-1)
+		}
+	}
+	if history[0].Status != http.StatusServiceUnavailable || history[0].Error == "" {
+		t.Errorf("Expected failed probe to record status and error, got %+v", history[0])
+	}
+	if history[2].Status != http.StatusOK || history[2].Error != "" || history[2].Latency <= 0 {
+		t.Errorf("Expected successful probe to record status and latency, got %+v", history[2])
+	}
+}
+
+// TestHealthHistoryDisabled tests that a non-positive size keeps no history
+func TestHealthHistoryDisabled(t *testing.T) {
+	server := newHealthServer(t, http.StatusOK)
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithHistorySize(0))
+
+	hc.checkBackend(b)
+	if history := hc.HealthHistory(b); history != nil {
+		t.Errorf("Expected no history, got %+v", history)
+	}
+}
+
+// TestHealthHistoryConcurrent tests that history can be read while probes record it
+func TestHealthHistoryConcurrent(t *testing.T) {
+	server := newHealthServer(t, http.StatusOK)
+	b := newTestBackend(t, server.URL)
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithHistorySize(5))
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				hc.checkBackend(b)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				if n := len(hc.HealthHistory(b)); n > 5 {
+					t.Errorf("Expected at most 5 entries, got %d", n)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(hc.HealthHistory(b)); n != 5 {
+		t.Errorf("Expected 5 entries after 40 probes, got %d", n)
+	}
+}
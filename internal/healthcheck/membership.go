@@ -0,0 +1,103 @@
+package healthcheck
+
+import (
+	"context"
+	"slices"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// AddBackend starts checking b too, e.g. after it joined the balancer's
+// pool. If the checker is running b is probed right away and then on its
+// interval like the others, so a backend still in backend.Unknown gets a
+// real state as soon as possible. Adding a checked backend does nothing.
+func (hc *HealthChecker) AddBackend(b *backend.Backend) {
+	hc.backendsMu.Lock()
+	defer hc.backendsMu.Unlock()
+	if slices.Contains(hc.backends, b) {
+		return
+	}
+	// Copy on write: snapshots handed out by checked stay valid
+	hc.backends = append(slices.Clip(hc.backends), b)
+	hc.expectFirstCheck(b)
+	if hc.running.Load() && hc.ctx.Err() == nil {
+		hc.startLoop(b)
+	}
+}
+
+// RemoveBackend stops checking b and drops what the checker keeps for it,
+// such as its backoff, probe history and connections. A probe of b already
+// in flight still completes. Removing an unknown backend does nothing.
+func (hc *HealthChecker) RemoveBackend(b *backend.Backend) {
+	hc.backendsMu.Lock()
+	i := slices.Index(hc.backends, b)
+	if i < 0 {
+		hc.backendsMu.Unlock()
+		return
+	}
+	hc.backends = slices.Concat(hc.backends[:i], hc.backends[i+1:])
+	cancel, looping := hc.loopCancels[b]
+	delete(hc.loopCancels, b)
+	hc.backendsMu.Unlock()
+
+	hc.forgetFirstCheck(b)
+	if looping {
+		// The loop cleans up once it exits, after any probe in flight
+		cancel()
+		return
+	}
+	hc.forget(b)
+}
+
+// checked returns the backends being checked. The slice must not be modified.
+func (hc *HealthChecker) checked() []*backend.Backend {
+	hc.backendsMu.Lock()
+	defer hc.backendsMu.Unlock()
+	return hc.backends
+}
+
+// startLoop starts the probe loop of b. Callers must hold backendsMu.
+func (hc *HealthChecker) startLoop(b *backend.Backend) {
+	ctx, cancel := context.WithCancel(hc.ctx)
+	if hc.loopCancels == nil {
+		hc.loopCancels = make(map[*backend.Backend]context.CancelFunc)
+	}
+	hc.loopCancels[b] = cancel
+	hc.loops.Add(1)
+	go hc.probeLoop(ctx, b)
+}
+
+// forget drops the per-backend state of b unless it was added back since.
+func (hc *HealthChecker) forget(b *backend.Backend) {
+	hc.backendsMu.Lock()
+	defer hc.backendsMu.Unlock()
+	if slices.Contains(hc.backends, b) {
+		return
+	}
+
+	hc.streakMu.Lock()
+	delete(hc.streaks, b)
+	hc.streakMu.Unlock()
+
+	hc.backoffMu.Lock()
+	delete(hc.backoff, b)
+	hc.backoffMu.Unlock()
+
+	hc.history.mu.Lock()
+	delete(hc.history.rings, b)
+	hc.history.mu.Unlock()
+
+	hc.grpcMu.Lock()
+	if conn, ok := hc.grpcConns[b]; ok {
+		conn.Close()
+		delete(hc.grpcConns, b)
+	}
+	hc.grpcMu.Unlock()
+
+	hc.tlsMu.Lock()
+	if cached, ok := hc.tlsClients[b]; ok {
+		cached.client.CloseIdleConnections()
+		delete(hc.tlsClients, b)
+	}
+	hc.tlsMu.Unlock()
+}
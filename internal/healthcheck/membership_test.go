@@ -0,0 +1,65 @@
+package healthcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestMembership tests that backends added or removed while the checker runs are probed or dropped
+func TestMembership(t *testing.T) {
+	var probes atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+	}))
+	defer server.Close()
+
+	first := newTestBackend(t, newHealthServer(t, http.StatusOK).URL)
+	hc := NewHealthChecker([]*backend.Backend{first}, 10*time.Millisecond, WithHistorySize(5), WithLogger(&recordingLogger{}))
+	hc.Start()
+	defer hc.Stop()
+
+	added := newTestBackend(t, server.URL)
+
+	t.Run("Added Backend Is Probed", func(t *testing.T) {
+		hc.AddBackend(added)
+		hc.AddBackend(added)
+		waitUntil(t, added.IsAlive, "Expected the added backend to be probed, state %v", added.HealthState())
+		if err := hc.CheckNow(added.URL.Host, false); err != nil {
+			t.Errorf("CheckNow of an added backend failed: %v", err)
+		}
+		if got := len(hc.checked()); got != 2 {
+			t.Errorf("Expected 2 checked backends after adding one twice, got %d", got)
+		}
+	})
+
+	t.Run("Removed Backend Is No Longer Probed", func(t *testing.T) {
+		hc.RemoveBackend(added)
+		waitUntil(t, func() bool { return len(hc.HealthHistory(added)) == 0 }, "Expected the removed backend's history dropped")
+
+		before := probes.Load()
+		time.Sleep(50 * time.Millisecond)
+		if got := probes.Load(); got != before {
+			t.Errorf("Expected no probes after removal, got %d more", got-before)
+		}
+		if err := hc.CheckNow(added.URL.Host, false); err == nil {
+			t.Error("Expected CheckNow of a removed backend to fail")
+		}
+	})
+
+	t.Run("Added Before Start", func(t *testing.T) {
+		idle := NewHealthChecker(nil, time.Hour, WithLogger(&recordingLogger{}))
+		b := newTestBackend(t, server.URL)
+		idle.AddBackend(b)
+		if b.HealthState() != backend.Unknown {
+			t.Errorf("Expected no probe before Start, got %v", b.HealthState())
+		}
+		idle.Start()
+		defer idle.Stop()
+		waitUntil(t, b.IsAlive, "Expected the backend probed on Start, state %v", b.HealthState())
+	})
+}
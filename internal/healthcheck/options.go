@@ -0,0 +1,276 @@
+package healthcheck
+
+import (
+	"bytes"
+	"cmp"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+	"github.com/akshaykumarthakur/load-balancer/pkg/logging"
+)
+
+// Option configures optional HealthChecker behavior.
+type Option func(*HealthChecker)
+
+// WithHTTPClient makes the checker probe backends with client instead of the
+// default pooled client, e.g. to present mTLS client certificates. A nil
+// client keeps the default. If client has no Timeout, the default probe
+// timeout is applied to a copy so slow backends still fail their check.
+func WithHTTPClient(client *http.Client) Option {
+	return func(hc *HealthChecker) {
+		if client == nil {
+			return
+		}
+		if client.Timeout == 0 {
+			withTimeout := *client
+			withTimeout.Timeout = defaultTimeout
+			client = &withTimeout
+		}
+		hc.client = client
+	}
+}
+
+// WithTransportConfig tunes the default probe client's connection pool, e.g.
+// to raise MaxConnsPerHost when probing many backends at short intervals.
+// Zero fields keep their defaults. It has no effect with WithHTTPClient.
+func WithTransportConfig(cfg TransportConfig) Option {
+	return func(hc *HealthChecker) {
+		defaults := DefaultTransportConfig()
+		if cfg.MaxIdleConns <= 0 {
+			cfg.MaxIdleConns = defaults.MaxIdleConns
+		}
+		if cfg.MaxIdleConnsPerHost <= 0 {
+			cfg.MaxIdleConnsPerHost = defaults.MaxIdleConnsPerHost
+		}
+		if cfg.MaxConnsPerHost <= 0 {
+			cfg.MaxConnsPerHost = defaults.MaxConnsPerHost
+		}
+		if cfg.IdleConnTimeout <= 0 {
+			cfg.IdleConnTimeout = defaults.IdleConnTimeout
+		}
+		if cfg.DialTimeout <= 0 {
+			cfg.DialTimeout = defaults.DialTimeout
+		}
+		hc.transport = cfg
+	}
+}
+
+// WithInsecureSkipVerify makes the default probe client accept any TLS
+// certificate, e.g. the self-signed ones of a staging environment. It turns
+// off protection against impersonated backends, so it is logged as a warning
+// and should never be used in production; supply a client trusting the right
+// CA pool with WithHTTPClient instead. It has no effect with WithHTTPClient.
+func WithInsecureSkipVerify() Option {
+	return func(hc *HealthChecker) {
+		hc.insecure = true
+	}
+}
+
+// WithHeaders attaches headers to every health probe, e.g. an Authorization
+// token required by a gateway in front of the health endpoint. A "Host" entry
+// overrides the Host the probe is sent with.
+func WithHeaders(headers http.Header) Option {
+	return func(hc *HealthChecker) {
+		hc.headers = make(http.Header, len(headers))
+		for key, values := range headers {
+			key = http.CanonicalHeaderKey(key)
+			if key == "Host" {
+				if len(values) > 0 {
+					hc.host = values[0]
+				}
+				continue
+			}
+			hc.headers[key] = append([]string(nil), values...)
+		}
+	}
+}
+
+// WithBodyValidator makes a 200 response count as healthy only if validate
+// accepts the response body. See BodyContains and BodyMatches.
+func WithBodyValidator(validate func(body []byte) bool) Option {
+	return func(hc *HealthChecker) {
+		hc.validateBody = validate
+	}
+}
+
+// WithMaxBodySize sets how much of a health response body is read, and so
+// seen by the body validator and the degraded check; the rest is discarded
+// with the connection. It defaults to DefaultMaxBodySize so a backend
+// streaming a huge body can't make every probe buffer it. Zero or less
+// reads the whole body.
+func WithMaxBodySize(n int64) Option {
+	return func(hc *HealthChecker) {
+		hc.maxBodySize = n
+	}
+}
+
+// WithFollowRedirects makes HTTP probes follow redirects and judge the
+// backend by the final response. By default they don't, so a 3xx, e.g. to a
+// login page, fails the check. It has no effect on a client given to
+// WithHTTPClient that sets its own CheckRedirect.
+func WithFollowRedirects(follow bool) Option {
+	return func(hc *HealthChecker) {
+		hc.followRedirects = follow
+	}
+}
+
+// BodyContains returns a validator that requires the body to contain substr,
+// e.g. `"status":"UP"`.
+func BodyContains(substr string) func([]byte) bool {
+	return func(body []byte) bool {
+		return bytes.Contains(body, []byte(substr))
+	}
+}
+
+// BodyMatches returns a validator that requires the body to match re.
+func BodyMatches(re *regexp.Regexp) func([]byte) bool {
+	return re.Match
+}
+
+// WithPerHostConcurrency limits how many probes run at once against backends
+// that share a hostname, so a machine serving several backends isn't hit by
+// all of their probes simultaneously. Zero or less means unlimited.
+func WithPerHostConcurrency(n int) Option {
+	return func(hc *HealthChecker) {
+		hc.perHostLimit = n
+		hc.hostSems = make(map[string]chan struct{})
+	}
+}
+
+// WithMaxConcurrentChecks limits how many probes run at once across all
+// backends, smoothing the CPU and socket spikes of probing a large pool on
+// every tick. Zero or less means unlimited, the default.
+func WithMaxConcurrentChecks(k int) Option {
+	return func(hc *HealthChecker) {
+		hc.checkSem = nil
+		if k > 0 {
+			hc.checkSem = make(chan struct{}, k)
+		}
+	}
+}
+
+// WithBackoff probes a dead backend less often: the gap between its probes
+// doubles (see WithBackoffMultiplier) with each consecutive failure, from the
+// base interval up to max, and returns to the base interval as soon as a
+// probe succeeds. It saves probe traffic against backends that are down for a
+// long time; keep max short enough, e.g. 5m, that recoveries are still
+// noticed promptly. A backend whose interval is more than half of max never
+// backs off; zero or less turns backoff off.
+func WithBackoff(max time.Duration) Option {
+	return func(hc *HealthChecker) {
+		if max <= 0 {
+			hc.maxBackoff = 0
+			return
+		}
+		hc.maxBackoff = max
+		if hc.backoff == nil {
+			hc.backoff = make(map[*backend.Backend]*probeBackoff)
+		}
+	}
+}
+
+// WithBackoffMultiplier sets how much the gap between probes of a dead
+// backend grows with each failure under WithBackoff, e.g. 1.5 for a gentler
+// ramp. It defaults to 2, which values of 1 or less restore.
+func WithBackoffMultiplier(m float64) Option {
+	return func(hc *HealthChecker) {
+		hc.backoffMultiplier = defaultBackoffMultiplier
+		if m > 1 {
+			hc.backoffMultiplier = m
+		}
+	}
+}
+
+// WithDrainGrace sets how long requests already in flight to a backend that
+// fails its checks may take to finish. The backend gets no new requests
+// either way; the checker logs when it has drained, or a warning if requests
+// are still open once the grace period has passed. It defaults to 30s; zero
+// or less turns the watch off.
+func WithDrainGrace(d time.Duration) Option {
+	return func(hc *HealthChecker) {
+		hc.drainGrace = max(d, 0)
+	}
+}
+
+// WithClock makes the checker read the time and schedule its probe ticks,
+// drain watches and timings on c instead of the real clock, so tests can drive
+// it with a clock.Fake. A nil clock keeps the real one.
+func WithClock(c clock.Clock) Option {
+	return func(hc *HealthChecker) {
+		if c != nil {
+			hc.clock = c
+		}
+	}
+}
+
+// WithLogger sends the checker's logs to l instead of the standard library logger.
+func WithLogger(l logging.Logger) Option {
+	return func(hc *HealthChecker) {
+		if l != nil {
+			hc.logger = l
+		}
+	}
+}
+
+// WithTracer runs every probe in a span started by t. Probes in sampled spans
+// are observed in the duration histogram with their trace ID as an exemplar,
+// which the balancer's MetricsHandler serves in the OpenMetrics format.
+func WithTracer(t Tracer) Option {
+	return func(hc *HealthChecker) {
+		hc.tracer = t
+	}
+}
+
+// WithHealthPath sets the path probed on backends that don't declare their
+// own with SetHealthPath. It defaults to "/health", which "" restores.
+func WithHealthPath(path string) Option {
+	return func(hc *HealthChecker) {
+		hc.healthPath = cmp.Or(path, defaultHealthPath)
+	}
+}
+
+// WithTimeout bounds each probe, overriding the timeout of the default or
+// injected HTTP client. Zero or less leaves the client's timeout alone.
+func WithTimeout(d time.Duration) Option {
+	return func(hc *HealthChecker) {
+		hc.timeout = max(d, 0)
+	}
+}
+
+// WithThresholds sets how many consecutive successful probes mark a backend
+// healthy and how many consecutive failures mark it unhealthy, so a single
+// flaky probe doesn't flap it in and out of rotation. Both default to 1.
+func WithThresholds(healthy, unhealthy int) Option {
+	return func(hc *HealthChecker) {
+		hc.healthyThreshold = max(healthy, 1)
+		hc.unhealthyThreshold = max(unhealthy, 1)
+	}
+}
+
+// WithCheckType selects how backends are probed: CheckHTTP (the default),
+// CheckTCP or CheckGRPC. TCP and gRPC checks target the host and port of the
+// backend's URL and ignore the health path, headers and body validator.
+func WithCheckType(t CheckType) Option {
+	return func(hc *HealthChecker) {
+		hc.checkType = t
+	}
+}
+
+// WithGRPCService sets the service name sent in gRPC health checks. The
+// default, "", asks for the health of the server as a whole.
+func WithGRPCService(service string) Option {
+	return func(hc *HealthChecker) {
+		hc.grpcService = service
+	}
+}
+
+// WithHistorySize sets how many recent probe results HealthHistory keeps per
+// backend. It defaults to 20; zero or less disables the history.
+func WithHistorySize(n int) Option {
+	return func(hc *HealthChecker) {
+		hc.history.size = n
+	}
+}
@@ -0,0 +1,37 @@
+package healthcheck
+
+import "github.com/akshaykumarthakur/load-balancer/internal/backend"
+
+// CheckAllNow probes every backend immediately, regardless of the interval
+// and any backoff, and returns once all their states are updated, e.g. right
+// after a deploy instead of waiting for the next tick. It is safe to call
+// while the checker runs: backends whose probe is already in progress, from
+// a tick or another CheckNow or CheckAllNow, are not probed twice; the call
+// waits for that probe instead.
+func (hc *HealthChecker) CheckAllNow() {
+	hc.probeAll(hc.checked())
+}
+
+// probeOnce probes b, or waits for the probe of b already in progress.
+func (hc *HealthChecker) probeOnce(b *backend.Backend) {
+	hc.inflightMu.Lock()
+	if done, ok := hc.inflight[b]; ok {
+		hc.inflightMu.Unlock()
+		<-done
+		return
+	}
+	if hc.inflight == nil {
+		hc.inflight = make(map[*backend.Backend]chan struct{})
+	}
+	done := make(chan struct{})
+	hc.inflight[b] = done
+	hc.inflightMu.Unlock()
+
+	defer func() {
+		hc.inflightMu.Lock()
+		delete(hc.inflight, b)
+		hc.inflightMu.Unlock()
+		close(done)
+	}()
+	hc.probe(b)
+}
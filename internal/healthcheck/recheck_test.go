@@ -0,0 +1,67 @@
+package healthcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestCheckAllNow tests that an on-demand sweep probes every backend once, even when calls overlap
+func TestCheckAllNow(t *testing.T) {
+	t.Run("Probes Every Backend", func(t *testing.T) {
+		up := newTestBackend(t, newHealthServer(t, http.StatusOK).URL)
+		down := newTestBackend(t, newHealthServer(t, http.StatusServiceUnavailable).URL)
+		down.SetAlive(true)
+		hc := NewHealthChecker([]*backend.Backend{up, down}, time.Hour, WithLogger(&recordingLogger{}))
+
+		hc.CheckAllNow()
+
+		if !up.IsAlive() || down.IsAlive() {
+			t.Errorf("Expected states updated on return, got %v and %v", up.HealthState(), down.HealthState())
+		}
+	})
+
+	t.Run("Overlapping Calls Share Probes", func(t *testing.T) {
+		var probes atomic.Int64
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			probes.Add(1)
+			<-release
+		}))
+		defer server.Close()
+
+		b := newTestBackend(t, server.URL)
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Hour, WithTimeout(5*time.Second), WithLogger(&recordingLogger{}))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hc.CheckAllNow()
+		}()
+		waitUntil(t, func() bool { return probes.Load() == 1 }, "Expected the first probe to start")
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := hc.CheckNow(b.URL.Host, false); err != nil {
+				t.Errorf("CheckNow failed: %v", err)
+			}
+		}()
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if got := probes.Load(); got != 1 {
+			t.Errorf("Expected overlapping checks to share a single probe, got %d", got)
+		}
+		if !b.IsAlive() {
+			t.Error("Expected backend alive after the shared probe")
+		}
+	})
+}
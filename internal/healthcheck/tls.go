@@ -0,0 +1,80 @@
+package healthcheck
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// tlsClient is a probe client built for a backend's own TLS settings.
+type tlsClient struct {
+	config *tls.Config // the backend's settings it was built from
+	client *http.Client
+}
+
+// clientFor returns the client to probe b with: the checker's client, or a
+// copy whose transport verifies b with the settings from its SetTLS. Copies
+// are cached per backend and rebuilt if the settings change. A client given
+// to WithHTTPClient whose Transport is not an *http.Transport is used as is.
+func (hc *HealthChecker) clientFor(b *backend.Backend) *http.Client {
+	cfg := b.TLSClientConfig()
+	if cfg == nil {
+		return hc.client
+	}
+
+	hc.tlsMu.Lock()
+	defer hc.tlsMu.Unlock()
+	if cached, ok := hc.tlsClients[b]; ok && sameTLS(cached.config, cfg) {
+		return cached.client
+	}
+
+	base := http.DefaultTransport.(*http.Transport)
+	if hc.client.Transport != nil {
+		t, ok := hc.client.Transport.(*http.Transport)
+		if !ok {
+			return hc.client
+		}
+		base = t
+	}
+	transport := base.Clone()
+	merged := cfg
+	if transport.TLSClientConfig != nil {
+		// Keep the checker's own settings, such as WithInsecureSkipVerify
+		merged = transport.TLSClientConfig.Clone()
+		merged.ServerName = cfg.ServerName
+		if cfg.RootCAs != nil {
+			merged.RootCAs = cfg.RootCAs
+		}
+	}
+	transport.TLSClientConfig = merged
+
+	client := *hc.client
+	client.Transport = transport
+	if hc.tlsClients == nil {
+		hc.tlsClients = make(map[*backend.Backend]tlsClient)
+	}
+	hc.tlsClients[b] = tlsClient{config: cfg, client: &client}
+	return &client
+}
+
+// sameTLS reports whether two backend TLS settings verify the same way.
+func sameTLS(a, b *tls.Config) bool {
+	if a.ServerName != b.ServerName {
+		return false
+	}
+	if a.RootCAs == nil || b.RootCAs == nil {
+		return a.RootCAs == b.RootCAs
+	}
+	return a.RootCAs.Equal(b.RootCAs)
+}
+
+// closeTLSClients drops the per-backend clients and their idle connections.
+func (hc *HealthChecker) closeTLSClients() {
+	hc.tlsMu.Lock()
+	defer hc.tlsMu.Unlock()
+	for b, cached := range hc.tlsClients {
+		cached.client.CloseIdleConnections()
+		delete(hc.tlsClients, b)
+	}
+}
@@ -0,0 +1,40 @@
+package healthcheck
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestBackendTLS tests that probes verify a backend with the server name and CAs given to SetTLS
+func TestBackendTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	b := newTestBackend(t, server.URL)
+	if err := b.SetTLS(backend.TLSConfig{ServerName: "example.com", RootCAs: roots}); err != nil {
+		t.Fatalf("Failed to set TLS: %v", err)
+	}
+	hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithThresholds(1, 1), WithLogger(&recordingLogger{}))
+	defer hc.Stop()
+
+	hc.checkBackend(b)
+	if !b.IsAlive() {
+		t.Fatalf("Expected backend verified with its own CAs to pass, got state %v", b.HealthState())
+	}
+
+	// Changed settings must not reuse the cached client
+	if err := b.SetTLS(backend.TLSConfig{ServerName: "api.internal", RootCAs: roots}); err != nil {
+		t.Fatalf("Failed to set TLS: %v", err)
+	}
+	hc.checkBackend(b)
+	if b.IsAlive() {
+		t.Error("Expected backend whose certificate doesn't name the server name to fail")
+	}
+}
@@ -0,0 +1,26 @@
+package healthcheck
+
+import (
+	"context"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// Tracer traces health probes, e.g. an adapter around an OpenTelemetry
+// tracer, so a slow probe in the duration histogram links to its trace.
+type Tracer interface {
+	// StartProbe starts a span for a probe of b under ctx. It returns the
+	// context the probe runs under, the span's trace ID, which is empty if
+	// the span isn't sampled, and a function that ends the span.
+	StartProbe(ctx context.Context, b *backend.Backend) (spanCtx context.Context, traceID string, end func())
+}
+
+// traceIDKey is the context key under which probeContext keeps the trace ID
+// of the probe's span.
+type traceIDKey struct{}
+
+// probeTraceID returns the trace ID of the span ctx's probe runs in, or "".
+func probeTraceID(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
@@ -0,0 +1,93 @@
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/metrics"
+)
+
+// fakeTracer hands out a fixed trace ID and counts the spans it starts and ends
+type fakeTracer struct {
+	traceID string
+	started atomic.Int64
+	ended   atomic.Int64
+}
+
+func (f *fakeTracer) StartProbe(ctx context.Context, b *backend.Backend) (context.Context, string, func()) {
+	f.started.Add(1)
+	return ctx, f.traceID, func() { f.ended.Add(1) }
+}
+
+// TestProbeTracing tests that traced probes carry their trace ID as a histogram exemplar
+func TestProbeTracing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	exemplars := func(t *testing.T, b *backend.Backend) []*dto.Exemplar {
+		t.Helper()
+		var m dto.Metric
+		histogram := metrics.HealthCheckDuration.WithLabelValues(b.URL.Host).(prometheus.Histogram)
+		if err := histogram.Write(&m); err != nil {
+			t.Fatalf("Failed to read histogram: %v", err)
+		}
+		var found []*dto.Exemplar
+		for _, bucket := range m.GetHistogram().GetBucket() {
+			if e := bucket.GetExemplar(); e != nil {
+				found = append(found, e)
+			}
+		}
+		return found
+	}
+
+	t.Run("Sampled Span", func(t *testing.T) {
+		b, err := backend.NewBackend(server.URL)
+		if err != nil {
+			t.Fatalf("Failed to create backend: %v", err)
+		}
+		tracer := &fakeTracer{traceID: "4bf92f3577b34da6a3ce929d0e0e4736"}
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithTracer(tracer))
+
+		hc.checkBackend(b)
+
+		if tracer.started.Load() != 1 || tracer.ended.Load() != 1 {
+			t.Errorf("Expected one span started and ended, got %d and %d", tracer.started.Load(), tracer.ended.Load())
+		}
+		found := exemplars(t, b)
+		if len(found) != 1 {
+			t.Fatalf("Expected one exemplar, got %d", len(found))
+		}
+		label := found[0].GetLabel()
+		if len(label) != 1 || label[0].GetName() != "trace_id" || label[0].GetValue() != tracer.traceID {
+			t.Errorf("Expected trace_id=%s, got %v", tracer.traceID, label)
+		}
+	})
+
+	t.Run("Unsampled Span", func(t *testing.T) {
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer other.Close()
+		b, err := backend.NewBackend(other.URL)
+		if err != nil {
+			t.Fatalf("Failed to create backend: %v", err)
+		}
+		tracer := &fakeTracer{}
+		hc := NewHealthChecker([]*backend.Backend{b}, time.Second, WithTracer(tracer))
+
+		hc.checkBackend(b)
+
+		if found := exemplars(t, b); len(found) != 0 {
+			t.Errorf("Expected no exemplar without a trace ID, got %v", found)
+		}
+		if tracer.ended.Load() != 1 {
+			t.Errorf("Expected the span to be ended, got %d", tracer.ended.Load())
+		}
+	})
+}
@@ -0,0 +1,61 @@
+// Package metrics holds the Prometheus collectors shared by the balancer and
+// the health checker. Per-backend series are labeled with the backend's URL
+// host so they stay stable across restarts.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry holds every collector in this package. It is separate from the
+// Prometheus default registry so embedding applications keep control of theirs.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// RequestsTotal counts requests proxied to each backend, one per attempt.
+	RequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lb_backend_requests_total",
+		Help: "Requests proxied to the backend, counting each retry attempt.",
+	}, []string{"backend"})
+
+	// RequestFailuresTotal counts proxy attempts that failed at the transport
+	// level or while streaming the response body.
+	RequestFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lb_backend_request_failures_total",
+		Help: "Proxy attempts to the backend that failed before or while streaming the response.",
+	}, []string{"backend"})
+
+	// RetriesOverBudgetTotal counts retries dropped because the retry budget
+	// was used up.
+	RetriesOverBudgetTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "lb_retries_over_budget_total",
+		Help: "Retries not attempted because the retry budget was exhausted.",
+	})
+
+	// HealthCheckDuration observes how long each active health probe took.
+	HealthCheckDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "lb_health_check_duration_seconds",
+		Help:    "Duration of active health check probes.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"backend"})
+)
+
+func init() {
+	Registry.MustRegister(
+		RequestsTotal,
+		RequestFailuresTotal,
+		RetriesOverBudgetTotal,
+		HealthCheckDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// DeleteBackend drops the per-backend series labeled with host, e.g. once
+// the backend has left the pool, so removed backends don't linger in scrapes.
+func DeleteBackend(host string) {
+	RequestsTotal.DeleteLabelValues(host)
+	RequestFailuresTotal.DeleteLabelValues(host)
+	HealthCheckDuration.DeleteLabelValues(host)
+}
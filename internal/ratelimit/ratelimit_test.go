@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTokenBucket tests burst capacity and refill
+func TestTokenBucket(t *testing.T) {
+	tb := NewTokenBucket(100, 5)
+
+	t.Run("Burst Then Deny", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			if !tb.Allow() {
+				t.Fatalf("Request %d within burst was denied", i)
+			}
+		}
+		if tb.Allow() {
+			t.Error("Expected request beyond burst to be denied")
+		}
+	})
+
+	t.Run("Refills Over Time", func(t *testing.T) {
+		time.Sleep(30 * time.Millisecond) // ~3 tokens at 100/s
+		if !tb.Allow() {
+			t.Error("Expected a refilled token to be available")
+		}
+	})
+
+	t.Run("Delay Until Next Token", func(t *testing.T) {
+		for tb.Allow() {
+		}
+		if d := tb.Delay(); d <= 0 || d > 10*time.Millisecond {
+			t.Errorf("Expected a delay of at most one token interval, got %v", d)
+		}
+	})
+}
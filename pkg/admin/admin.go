@@ -0,0 +1,278 @@
+// Package admin provides an HTTP API for inspecting and controlling a
+// LoadBalancer's backends at runtime. It has no authentication of its own,
+// so mount it on an internal-only listener:
+//
+//	GET    /backends                list backends and their state
+//	POST   /backends                add a backend: {"url": "...", "weight": 1}
+//	DELETE /backends/{host}         remove a backend
+//	POST   /backends/{host}/drain   stop sending it new requests
+//	POST   /backends/{host}/enable  put it back into rotation
+//	POST   /backends/{host}/disable take it out of rotation
+//	GET    /backends/{host}/health  its most recent health probe results
+//	POST   /backends/{host}/check   health check it now and return its state
+//	POST   /check                   health check every backend now and list them
+//
+// {host} is the backend's URL host, e.g. "10.0.0.5:8080". Added backends
+// are registered with the health checker and stay unknown until it probes
+// them; removed ones are unregistered. Without a health checker added
+// backends start out alive, as nothing would ever probe them.
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+	"github.com/akshaykumarthakur/load-balancer/pkg/balancer"
+)
+
+// BackendStatus is the JSON view of a backend.
+type BackendStatus struct {
+	URL               string `json:"url"`
+	Alive             bool   `json:"alive"`
+	State             string `json:"state"`           // health check result: healthy, degraded, dead or unknown
+	EffectiveState    string `json:"effective_state"` // State, or draining, disabled or ejected if that overrides it
+	Enabled           bool   `json:"enabled"`
+	Draining          bool   `json:"draining"`
+	Weight            int    `json:"weight"`
+	Priority          int    `json:"priority"`
+	ActiveConnections int64  `json:"active_connections"`
+	Requests          uint64 `json:"requests"`
+
+	// Why and when the latest health check failed, if one has
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// AddRequest is the body of POST /backends.
+type AddRequest struct {
+	URL      string `json:"url"`
+	Weight   int    `json:"weight"`
+	Priority int    `json:"priority"`
+}
+
+// Handler serves the admin API for a LoadBalancer.
+type Handler struct {
+	lb      *balancer.LoadBalancer
+	checker *healthcheck.HealthChecker
+	mux     *http.ServeMux
+}
+
+// Option configures optional Handler behavior.
+type Option func(*Handler)
+
+// WithHealthChecker makes the handler use hc: the health endpoint reports its
+// probes, and backends added or removed through the API join or leave its
+// checks. By default it uses the balancer's own checker, if it has one.
+func WithHealthChecker(hc *healthcheck.HealthChecker) Option {
+	return func(h *Handler) {
+		h.checker = hc
+	}
+}
+
+// NewHandler creates an admin API handler operating on lb.
+func NewHandler(lb *balancer.LoadBalancer, opts ...Option) *Handler {
+	h := &Handler{lb: lb, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.mux.HandleFunc("GET /backends", h.list)
+	h.mux.HandleFunc("POST /backends", h.add)
+	h.mux.HandleFunc("DELETE /backends/{host}", h.remove)
+	h.mux.HandleFunc("POST /backends/{host}/drain", h.toggle(func(b *backend.Backend) { b.Drain() }))
+	h.mux.HandleFunc("POST /backends/{host}/enable", h.toggle(func(b *backend.Backend) {
+		b.Undrain()
+		b.SetEnabled(true)
+	}))
+	h.mux.HandleFunc("POST /backends/{host}/disable", h.toggle(func(b *backend.Backend) { b.SetEnabled(false) }))
+	h.mux.HandleFunc("GET /backends/{host}/health", h.health)
+	h.mux.HandleFunc("POST /backends/{host}/check", h.checkOne)
+	h.mux.HandleFunc("POST /check", h.checkAll)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	backends := h.lb.Backends()
+	statuses := make([]BackendStatus, 0, len(backends))
+	for _, b := range backends {
+		statuses = append(statuses, status(b))
+	}
+	writeJSON(w, http.StatusOK, statuses)
+}
+
+func (h *Handler) add(w http.ResponseWriter, r *http.Request) {
+	var req AddRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	b, err := backend.NewBackend(req.URL)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	b.SetWeight(req.Weight)
+	b.SetPriority(req.Priority)
+	checker := h.checkerOrNil()
+	if checker == nil {
+		b.SetAlive(true)
+	}
+
+	if err := h.lb.AddBackend(b); err != nil {
+		if errors.Is(err, balancer.ErrBackendExists) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// The balancer registers it with its own checker, which may not be ours
+	if checker != nil {
+		checker.AddBackend(b)
+	}
+	writeJSON(w, http.StatusCreated, status(b))
+}
+
+func (h *Handler) remove(w http.ResponseWriter, r *http.Request) {
+	b, err := h.lb.RemoveBackend(r.PathValue("host"))
+	if err != nil {
+		if errors.Is(err, balancer.ErrBackendNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if checker := h.checkerOrNil(); checker != nil {
+		checker.RemoveBackend(b)
+	}
+	writeJSON(w, http.StatusOK, status(b))
+}
+
+// toggle returns a handler that applies change to the backend named in the
+// path and responds with its new state.
+func (h *Handler) toggle(change func(*backend.Backend)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b, ok := h.lookup(w, r)
+		if !ok {
+			return
+		}
+		change(b)
+		writeJSON(w, http.StatusOK, status(b))
+	}
+}
+
+// health responds with the backend's recent probe results, oldest first.
+func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
+	b, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	checker, ok := h.healthChecker(w)
+	if !ok {
+		return
+	}
+	history := checker.HealthHistory(b)
+	if history == nil {
+		history = []healthcheck.ProbeResult{}
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// checkOne probes the backend named in the path right away and responds
+// with its updated state.
+func (h *Handler) checkOne(w http.ResponseWriter, r *http.Request) {
+	b, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	checker, ok := h.healthChecker(w)
+	if !ok {
+		return
+	}
+	if err := checker.CheckNow(b.URL.Host, false); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, status(b))
+}
+
+// checkAll probes every backend right away and responds like list.
+func (h *Handler) checkAll(w http.ResponseWriter, r *http.Request) {
+	checker, ok := h.healthChecker(w)
+	if !ok {
+		return
+	}
+	checker.CheckAllNow()
+	h.list(w, r)
+}
+
+// healthChecker returns the checker to use, responding with 404 if health
+// checks are not configured.
+func (h *Handler) healthChecker(w http.ResponseWriter) (*healthcheck.HealthChecker, bool) {
+	checker := h.checkerOrNil()
+	if checker == nil {
+		writeError(w, http.StatusNotFound, "health checks are not configured")
+		return nil, false
+	}
+	return checker, true
+}
+
+// checkerOrNil returns the checker to use, or nil if health checks are not
+// configured.
+func (h *Handler) checkerOrNil() *healthcheck.HealthChecker {
+	if h.checker != nil {
+		return h.checker
+	}
+	return h.lb.HealthChecker()
+}
+
+// lookup finds the backend named in the path, responding with 404 if there
+// is none.
+func (h *Handler) lookup(w http.ResponseWriter, r *http.Request) (*backend.Backend, bool) {
+	host := r.PathValue("host")
+	for _, b := range h.lb.Backends() {
+		if b.URL.Host == host {
+			return b, true
+		}
+	}
+	writeError(w, http.StatusNotFound, balancer.ErrBackendNotFound.Error()+": "+host)
+	return nil, false
+}
+
+func status(b *backend.Backend) BackendStatus {
+	s := BackendStatus{
+		URL:               b.URL.String(),
+		Alive:             b.IsAlive(),
+		State:             b.HealthState().String(),
+		EffectiveState:    b.EffectiveState().String(),
+		Enabled:           b.IsEnabled(),
+		Draining:          b.IsDraining(),
+		Weight:            b.Weight(),
+		Priority:          b.Priority(),
+		ActiveConnections: b.ActiveConnections(),
+		Requests:          b.TotalRequests(),
+	}
+	if err, at := b.LastError(); err != nil {
+		s.LastError = err.Error()
+		s.LastErrorAt = &at
+	}
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,321 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+	"github.com/akshaykumarthakur/load-balancer/pkg/balancer"
+)
+
+// newTestBalancer creates a balancer over alive backends at the given URLs
+func newTestBalancer(t *testing.T, urls ...string) *balancer.LoadBalancer {
+	t.Helper()
+	var backends []*backend.Backend
+	for _, u := range urls {
+		b, err := backend.NewBackend(u)
+		if err != nil {
+			t.Fatalf("Failed to create backend %s: %v", u, err)
+		}
+		b.SetAlive(true)
+		backends = append(backends, b)
+	}
+	lb, err := balancer.New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	return lb
+}
+
+// do sends a request to h and returns the recorded response
+func do(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec
+}
+
+// TestAdminAPI tests listing, adding, removing and toggling backends
+func TestAdminAPI(t *testing.T) {
+	lb := newTestBalancer(t, "http://localhost:3000", "http://localhost:3001")
+	h := NewHandler(lb)
+
+	t.Run("List Backends", func(t *testing.T) {
+		rec := do(h, http.MethodGet, "/backends", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		var statuses []BackendStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil {
+			t.Fatalf("Invalid JSON response: %v", err)
+		}
+		if len(statuses) != 2 || statuses[0].URL != "http://localhost:3000" || !statuses[0].Alive || !statuses[0].Enabled {
+			t.Errorf("Unexpected backend list: %+v", statuses)
+		}
+	})
+
+	t.Run("Add Backend", func(t *testing.T) {
+		rec := do(h, http.MethodPost, "/backends", `{"url": "http://localhost:3002", "weight": 3}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+		}
+		if n := len(lb.Backends()); n != 3 {
+			t.Fatalf("Expected 3 backends after add, got %d", n)
+		}
+		if w := lb.Backends()[2].Weight(); w != 3 {
+			t.Errorf("Expected weight 3, got %d", w)
+		}
+	})
+
+	t.Run("Add Duplicate Conflicts", func(t *testing.T) {
+		rec := do(h, http.MethodPost, "/backends", `{"url": "http://localhost:3002"}`)
+		if rec.Code != http.StatusConflict {
+			t.Errorf("Expected 409, got %d", rec.Code)
+		}
+	})
+
+	t.Run("Add Invalid URL", func(t *testing.T) {
+		rec := do(h, http.MethodPost, "/backends", `{"url": "localhost"}`)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", rec.Code)
+		}
+	})
+
+	t.Run("Drain, Disable And Enable", func(t *testing.T) {
+		b := lb.Backends()[0]
+
+		rec := do(h, http.MethodPost, "/backends/localhost:3000/drain", "")
+		if rec.Code != http.StatusOK || !b.IsDraining() {
+			t.Errorf("Expected drain to succeed, got %d, draining=%v", rec.Code, b.IsDraining())
+		}
+		var status BackendStatus
+		if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+			t.Fatalf("Failed to decode status: %v", err)
+		}
+		if status.State != "healthy" || status.EffectiveState != "draining" {
+			t.Errorf("Expected a healthy backend that is draining, got state %q, effective state %q", status.State, status.EffectiveState)
+		}
+		if rec := do(h, http.MethodPost, "/backends/localhost:3000/disable", ""); rec.Code != http.StatusOK || b.IsEnabled() {
+			t.Errorf("Expected disable to succeed, got %d, enabled=%v", rec.Code, b.IsEnabled())
+		}
+		rec = do(h, http.MethodPost, "/backends/localhost:3000/enable", "")
+		if rec.Code != http.StatusOK || !b.IsEnabled() || b.IsDraining() {
+			t.Errorf("Expected enable to put backend back in rotation, got %d, enabled=%v draining=%v",
+				rec.Code, b.IsEnabled(), b.IsDraining())
+		}
+	})
+
+	t.Run("Remove Backend", func(t *testing.T) {
+		if rec := do(h, http.MethodDelete, "/backends/localhost:3002", ""); rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		if n := len(lb.Backends()); n != 2 {
+			t.Errorf("Expected 2 backends after remove, got %d", n)
+		}
+	})
+
+	t.Run("Unknown Host", func(t *testing.T) {
+		for _, path := range []string{"/backends/localhost:9999/drain", "/backends/localhost:9999/enable"} {
+			if rec := do(h, http.MethodPost, path, ""); rec.Code != http.StatusNotFound {
+				t.Errorf("POST %s: expected 404, got %d", path, rec.Code)
+			}
+		}
+		if rec := do(h, http.MethodDelete, "/backends/localhost:9999", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("DELETE: expected 404, got %d", rec.Code)
+		}
+	})
+}
+
+// TestAddedBackendHealthChecked tests that backends added through the API are
+// left to the balancer's health checker instead of joining alive
+func TestAddedBackendHealthChecked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	initial, err := backend.NewBackend("http://localhost:3000")
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+	hc := healthcheck.NewHealthChecker([]*backend.Backend{initial}, time.Hour)
+	lb, err := balancer.New([]*backend.Backend{initial}, balancer.WithHealthChecker(hc))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	h := NewHandler(lb)
+
+	rec := do(h, http.MethodPost, "/backends", `{"url": "`+server.URL+`"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var added BackendStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &added); err != nil {
+		t.Fatalf("Invalid JSON response: %v", err)
+	}
+	if added.State != "unknown" {
+		t.Errorf("Expected the added backend unknown until probed, got %+v", added)
+	}
+
+	if err := hc.CheckNow(host, false); err != nil {
+		t.Fatalf("Expected the health checker to know the added backend: %v", err)
+	}
+	if !lb.Backends()[1].IsAlive() {
+		t.Error("Expected the probe to bring the added backend up")
+	}
+
+	if rec := do(h, http.MethodDelete, "/backends/"+host, ""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 removing the added backend, got %d", rec.Code)
+	}
+	if err := hc.CheckNow(host, false); err == nil {
+		t.Error("Expected the removed backend unregistered from the health checker")
+	}
+}
+
+// TestHealthHistoryEndpoint tests that a backend's recent probes are served as JSON
+func TestHealthHistoryEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	lb := newTestBalancer(t, server.URL)
+	b := lb.Backends()[0]
+
+	t.Run("Without Health Checker", func(t *testing.T) {
+		if rec := do(NewHandler(lb), http.MethodGet, "/backends/"+b.URL.Host+"/health", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404, got %d", rec.Code)
+		}
+	})
+
+	t.Run("Recent Probes", func(t *testing.T) {
+		hc := healthcheck.NewHealthChecker(lb.Backends(), time.Hour)
+		hc.CheckAllNow()
+		h := NewHandler(lb, WithHealthChecker(hc))
+
+		rec := do(h, http.MethodGet, "/backends/"+b.URL.Host+"/health", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		var history []healthcheck.ProbeResult
+		if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+			t.Fatalf("Invalid JSON response: %v", err)
+		}
+		if len(history) != 1 || !history[0].Healthy || history[0].Status != http.StatusOK {
+			t.Errorf("Unexpected history: %+v", history)
+		}
+	})
+
+	t.Run("Unknown Host", func(t *testing.T) {
+		if rec := do(NewHandler(lb), http.MethodGet, "/backends/localhost:9999/health", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404, got %d", rec.Code)
+		}
+	})
+}
+
+// TestCheckEndpoints tests that backends can be health checked on demand
+func TestCheckEndpoints(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	lb := newTestBalancer(t, up.URL, down.URL)
+	hc := healthcheck.NewHealthChecker(lb.Backends(), time.Hour)
+	h := NewHandler(lb, WithHealthChecker(hc))
+
+	t.Run("Single Backend", func(t *testing.T) {
+		rec := do(h, http.MethodPost, "/backends/"+lb.Backends()[1].URL.Host+"/check", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		var got BackendStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("Invalid JSON response: %v", err)
+		}
+		if got.Alive || got.State != "dead" {
+			t.Errorf("Expected the failing backend reported dead, got %+v", got)
+		}
+	})
+
+	t.Run("Every Backend", func(t *testing.T) {
+		lb.Backends()[1].SetAlive(true)
+		rec := do(h, http.MethodPost, "/check", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		var statuses []BackendStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil {
+			t.Fatalf("Invalid JSON response: %v", err)
+		}
+		if len(statuses) != 2 || !statuses[0].Alive || statuses[1].Alive {
+			t.Errorf("Expected only the first backend alive after the check, got %+v", statuses)
+		}
+	})
+
+	t.Run("Added Backend", func(t *testing.T) {
+		extra := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer extra.Close()
+		host := strings.TrimPrefix(extra.URL, "http://")
+
+		rec := do(h, http.MethodPost, "/backends", `{"url": "`+extra.URL+`"}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+		}
+		var added BackendStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &added); err != nil {
+			t.Fatalf("Invalid JSON response: %v", err)
+		}
+		if added.State != "unknown" {
+			t.Errorf("Expected the added backend unknown until probed, got %+v", added)
+		}
+
+		rec = do(h, http.MethodPost, "/backends/"+host+"/check", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200 checking the added backend, got %d: %s", rec.Code, rec.Body)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &added); err != nil {
+			t.Fatalf("Invalid JSON response: %v", err)
+		}
+		if !added.Alive {
+			t.Errorf("Expected the added backend alive after the check, got %+v", added)
+		}
+
+		if rec := do(h, http.MethodDelete, "/backends/"+host, ""); rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200 removing the added backend, got %d", rec.Code)
+		}
+		if err := hc.CheckNow(host, false); err == nil {
+			t.Error("Expected the removed backend unregistered from the health checker")
+		}
+	})
+
+	t.Run("Without Health Checker", func(t *testing.T) {
+		if rec := do(NewHandler(lb), http.MethodPost, "/check", ""); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404, got %d", rec.Code)
+		}
+	})
+}
+
+// TestLastErrorStatus tests that a backend's last health check failure is listed with its time
+func TestLastErrorStatus(t *testing.T) {
+	lb := newTestBalancer(t, "http://localhost:8081", "http://localhost:8082")
+	at := time.Date(2024, 5, 1, 14, 3, 22, 0, time.UTC)
+	lb.Backends()[0].SetLastError(errors.New("connection refused: dial tcp"), at)
+
+	rec := do(NewHandler(lb), http.MethodGet, "/backends", "")
+	var statuses []BackendStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("Invalid JSON response: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("Expected 2 backends, got %d", len(statuses))
+	}
+	if got := statuses[0]; got.LastError != "connection refused: dial tcp" || got.LastErrorAt == nil || !got.LastErrorAt.Equal(at) {
+		t.Errorf("Expected the failure and its time, got %q at %v", got.LastError, got.LastErrorAt)
+	}
+	if got := statuses[1]; got.LastError != "" || got.LastErrorAt != nil {
+		t.Errorf("Expected no failure on a backend that never failed, got %q at %v", got.LastError, got.LastErrorAt)
+	}
+}
@@ -0,0 +1,82 @@
+package balancer
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// accessLogKey is the context key under which serve stores the access log
+// entry of a sampled request, so the proxy paths can note the backend used.
+type accessLogKey struct{}
+
+// accessEntry collects what the access log reports beyond the response.
+type accessEntry struct {
+	backend *backend.Backend
+}
+
+// sampleAccess reports whether the current request should be logged.
+func (lb *LoadBalancer) sampleAccess() bool {
+	return lb.accessLogRate >= 1 || lb.randFloat64() < lb.accessLogRate
+}
+
+// beginAccessLog wraps w and r to record a request for the access log, and
+// returns the func that writes its line once the request is done.
+func (lb *LoadBalancer) beginAccessLog(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	start := time.Now()
+	entry := &accessEntry{}
+	aw := &accessLogWriter{ResponseWriter: w}
+	r = r.WithContext(context.WithValue(r.Context(), accessLogKey{}, entry))
+
+	return aw, r, func() {
+		host := "-"
+		if entry.backend != nil {
+			host = entry.backend.URL.Host
+		}
+		status := aw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		lb.logger.Info("access",
+			"start", start.Format(time.RFC3339Nano), "client", ClientIP(r, false), "method", r.Method,
+			"path", r.URL.Path, "backend", host, "status", status, "bytes", aw.bytes, "duration", time.Since(start))
+	}
+}
+
+// noteBackend records b as the backend serving r, if r is being logged. The
+// last call wins, so retries report the backend that produced the response.
+func noteBackend(r *http.Request, b *backend.Backend) {
+	if entry, ok := r.Context().Value(accessLogKey{}).(*accessEntry); ok {
+		entry.backend = b
+	}
+}
+
+// accessLogWriter records the final status and body size of a response.
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (aw *accessLogWriter) WriteHeader(code int) {
+	if aw.status == 0 && code >= http.StatusOK {
+		aw.status = code
+	}
+	aw.ResponseWriter.WriteHeader(code)
+}
+
+func (aw *accessLogWriter) Write(p []byte) (int, error) {
+	if aw.status == 0 {
+		aw.status = http.StatusOK
+	}
+	n, err := aw.ResponseWriter.Write(p)
+	aw.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer for Flush.
+func (aw *accessLogWriter) Unwrap() http.ResponseWriter {
+	return aw.ResponseWriter
+}
@@ -0,0 +1,123 @@
+package balancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// accessRecorder keeps the fields of every access log line
+type accessRecorder struct {
+	mu    sync.Mutex
+	lines []map[string]any
+}
+
+func (l *accessRecorder) Debug(msg string, kv ...any) {}
+func (l *accessRecorder) Warn(msg string, kv ...any)  {}
+func (l *accessRecorder) Error(msg string, kv ...any) {}
+
+func (l *accessRecorder) Info(msg string, kv ...any) {
+	if msg != "access" {
+		return
+	}
+	fields := make(map[string]any)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fields[kv[i].(string)] = kv[i+1]
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fields)
+}
+
+func (l *accessRecorder) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.lines)
+}
+
+// TestAccessLog tests that proxied requests are logged with the backend that served them
+func TestAccessLog(t *testing.T) {
+	live := newNamedServer(t, "live")
+	dead := newTestBackend(t, newDeadServerURL())
+	alive := newTestBackend(t, live.URL)
+	dead.SetAlive(true)
+	alive.SetAlive(true)
+
+	t.Run("Retried Request", func(t *testing.T) {
+		logger := &accessRecorder{}
+		lb, err := New([]*backend.Backend{dead, alive}, WithAccessLog(1), WithLogger(logger))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.RemoteAddr = "192.0.2.7:51234"
+		lb.ServeHTTP(httptest.NewRecorder(), req)
+
+		if len(logger.lines) != 1 {
+			t.Fatalf("Expected 1 access log line, got %d", len(logger.lines))
+		}
+		line := logger.lines[0]
+		want := map[string]any{
+			"client":  "192.0.2.7",
+			"method":  http.MethodGet,
+			"path":    "/items",
+			"backend": alive.URL.Host,
+			"status":  http.StatusOK,
+			"bytes":   int64(len("live")),
+		}
+		for key, value := range want {
+			if line[key] != value {
+				t.Errorf("Expected %s=%v, got %v", key, value, line[key])
+			}
+		}
+		if _, ok := line["duration"]; !ok {
+			t.Error("Expected a duration field")
+		}
+	})
+
+	t.Run("No Backend Available", func(t *testing.T) {
+		logger := &accessRecorder{}
+		lb, err := New([]*backend.Backend{newTestBackend(t, live.URL)}, WithAccessLog(1), WithLogger(logger))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if len(logger.lines) != 1 {
+			t.Fatalf("Expected 1 access log line, got %d", len(logger.lines))
+		}
+		if line := logger.lines[0]; line["backend"] != "-" || line["status"] != http.StatusServiceUnavailable {
+			t.Errorf("Expected no backend and 503, got %v", line)
+		}
+	})
+
+	t.Run("Sampling", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			opts     []Option
+			min, max int
+		}{
+			{"Disabled By Default", nil, 0, 0},
+			{"Half Sampled", []Option{WithAccessLog(0.5)}, 400, 600},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				logger := &accessRecorder{}
+				lb, err := New([]*backend.Backend{alive}, append(tt.opts, WithLogger(logger))...)
+				if err != nil {
+					t.Fatalf("Failed to create load balancer: %v", err)
+				}
+				for i := 0; i < 1000; i++ {
+					lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+				}
+				if n := logger.count(); n < tt.min || n > tt.max {
+					t.Errorf("Expected between %d and %d lines, got %d", tt.min, tt.max, n)
+				}
+			})
+		}
+	})
+}
@@ -1,54 +1,438 @@
 package balancer
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+	"github.com/akshaykumarthakur/load-balancer/internal/ratelimit"
+	"github.com/akshaykumarthakur/load-balancer/pkg/logging"
 )
 
+// defaultMaxRetries is the number of extra attempts ServeHTTP makes for
+// retryable requests when no WithMaxRetries option is given.
+const defaultMaxRetries = 2
+
+// DefaultMaxRetryBody is how much of a request body ServeHTTP buffers for
+// replaying it on another backend unless WithMaxRetryBody says otherwise.
+const DefaultMaxRetryBody = 1 << 20
+
 type LoadBalancer struct {
-	backends []*backend.Backend
-	current  atomic.Uint64
+	// backends holds an immutable snapshot of the pool; AddBackend and
+	// RemoveBackend swap in a new copy so selection never takes a lock
+	backends       atomic.Pointer[[]*backend.Backend]
+	poolMu         sync.Mutex // serializes pool mutations
+	healthy        atomic.Pointer[healthySnapshot]
+	strategy       Strategy
+	keyRing        *ConsistentHash // for SelectBackendForKey when strategy isn't a KeyStrategy
+	available      func(*backend.Backend) bool
+	maxRetries     int
+	maxRetryBody   int64
+	maxRequestBody int64
+	slowStart      time.Duration
+	classify       func(*http.Request) string
+	rateLimit      *ratelimit.TokenBucket
+	queue          *requestQueue
+	hedging        *HedgePolicy
+
+	randomStart    bool
+	accessLogRate  float64
+	requestTimeout time.Duration
+	offlineWait    time.Duration
+
+	// Source for slow-start admission, access log sampling and the random
+	// start; nil means the global source
+	rngMu sync.Mutex
+	rng   *rand.Rand
+	seed  *int64 // from WithSeed, applied once every option has run
+
+	clock clock.Clock // from WithClock; nil means the real clock
+
+	noForwardedHeaders bool
+	responseHeaders    *HeaderRules
+	insecureTransport  http.RoundTripper
+	h2c                bool
+
+	latencySmoothing  float64
+	scoreCoefficients *backend.ScoreCoefficients
+
+	sticky        *stickySessions
+	stickyCookie  string
+	sessionStore  SessionStore
+	sessionTTL    time.Duration
+	errorRenderer ErrorRenderer
+	logger        logging.Logger
+
+	minHealthy         int
+	minHealthyFraction float64
+
+	healthPolicy     HealthPolicy
+	passiveHealthTTL time.Duration
+	selectUnknown    bool
+	unknownWarned    sync.Map // *backend.Backend -> struct{}, for WithSelectUnknown
+	outlierDetection *OutlierDetection
+
+	priorities  *PriorityPolicy
+	servingTier atomic.Int64 // priority tier of the latest selection plus one, or 0 before the first
+
+	retryBudget *retryBudget
+
+	resolver Resolver // for ResolveHost; nil means net.DefaultResolver
+
+	checker *healthcheck.HealthChecker // follows pool changes, see WithHealthChecker
+
+	partialFailures atomic.Uint64
+	stats           selectionStats
+
+	// Set when built from a config file, for Reload
+	reloadMu sync.Mutex
+	config   *Config
+	drains   map[*backend.Backend]context.CancelFunc
 }
 
-func New(backends []*backend.Backend) (*LoadBalancer, error) {
+func New(backends []*backend.Backend, opts ...Option) (*LoadBalancer, error) {
 	if len(backends) == 0 {
-		return nil, fmt.Errorf("at least one backend is required")
+		return nil, ErrNoBackends
+	}
+
+	lb := &LoadBalancer{
+		strategy:      NewRoundRobin(),
+		keyRing:       NewConsistentHash(nil, DefaultVirtualNodes),
+		maxRetries:    defaultMaxRetries,
+		maxRetryBody:  DefaultMaxRetryBody,
+		errorRenderer: DefaultErrorRenderer,
+		logger:        logging.Default(),
+
+		passiveHealthTTL: defaultPassiveHealthTTL,
+		sessionTTL:       defaultSessionTTL,
+	}
+	lb.available = lb.isAvailable
+	for _, opt := range opts {
+		opt(lb)
+	}
+	if lb.seed != nil {
+		lb.applySeed(*lb.seed)
+	}
+	if lb.insecureTransport != nil {
+		lb.logger.Warn("proxying skips TLS certificate verification; do not use this in production")
+	}
+	if lb.stickyCookie != "" {
+		lb.sticky = newStickySessions(lb.stickyCookie, lb.sessionStore, lb.sessionTTL, lb.clock)
+	}
+
+	pool := make([]*backend.Backend, 0, len(backends))
+	for _, b := range backends {
+		lb.attach(b)
+		pool = append(pool, b)
+	}
+	lb.backends.Store(&pool)
+
+	if rr, ok := lb.strategy.(*RoundRobin); ok && lb.randomStart {
+		rr.current.Store(lb.randUint64N(uint64(len(pool))))
 	}
 
-	return &LoadBalancer{
-		backends: backends,
-		current:  atomic.Uint64{},
-	}, nil
+	return lb, nil
 }
 
+// SelectBackend returns the next available backend according to the
+// balancer's strategy (round-robin by default).
 func (lb *LoadBalancer) SelectBackend() (*backend.Backend, error) {
-	attempts := 0
-	totalBackends := len(lb.backends)
+	selected, _, err := lb.SelectBackendDetailed()
+	return selected, err
+}
+
+// SelectBackendContext is like SelectBackend but abandons the selection with
+// the context's error once ctx is cancelled or past its deadline. With
+// WithOfflineWait it waits for a backend to come back while ctx allows.
+func (lb *LoadBalancer) SelectBackendContext(ctx context.Context) (*backend.Backend, error) {
+	return lb.selectBackend(ctx, nil, nil)
+}
+
+// SelectBackendForRequest selects a backend for r, letting request-aware
+// strategies route on the request's headers or client address. It honors
+// the request's context like SelectBackendContext.
+func (lb *LoadBalancer) SelectBackendForRequest(r *http.Request) (*backend.Backend, error) {
+	return lb.selectBackend(r.Context(), r, nil)
+}
+
+// SelectBackendForKey selects the backend that owns key, e.g. a cache key or
+// user ID, so the same key keeps going to the same backend. Keys go through
+// the strategy if it is a KeyStrategy, such as ConsistentHash, and otherwise
+// through a consistent-hash ring with DefaultVirtualNodes per backend, so
+// when a backend goes down or leaves the pool only its own keys move. An
+// empty key selects like SelectBackend.
+func (lb *LoadBalancer) SelectBackendForKey(key string) (*backend.Backend, error) {
+	return lb.selectBackend(withSelectionKey(context.Background(), key), nil, nil)
+}
+
+// AcquireBackend is like SelectBackendContext for callers that route the
+// request themselves: it also counts the request as in flight on the
+// selected backend, which least-connections routing and connection caps go
+// by, until done is called. done may be called more than once.
+func (lb *LoadBalancer) AcquireBackend(ctx context.Context) (b *backend.Backend, done func(), err error) {
+	b, err = lb.reserveBackend(ctx, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var once sync.Once
+	return b, func() { once.Do(b.DecrementConnections) }, nil
+}
+
+// selectionKeyCtx is the context key under which SelectBackendForKey hands
+// its key to the selection.
+type selectionKeyCtx struct{}
+
+func withSelectionKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, selectionKeyCtx{}, key)
+}
+
+// selectionKey returns the key SelectBackendForKey selects for, or "".
+func selectionKey(ctx context.Context) string {
+	key, _ := ctx.Value(selectionKeyCtx{}).(string)
+	return key
+}
+
+// selectBackend runs the configured strategy; r may be nil. A non-nil subset
+// restricts selection to the backends it accepts, failing with
+// ErrNoMatchingBackends if it accepts none of the pool. With WithQueue, a
+// selection that finds every backend saturated waits for capacity, and with
+// WithOfflineWait one that finds every backend offline waits for a recovery.
+func (lb *LoadBalancer) selectBackend(ctx context.Context, r *http.Request, subset func(*backend.Backend) bool) (*backend.Backend, error) {
+	return lb.selectWith(ctx, r, subset, false)
+}
+
+// reserveBackend is selectBackend for requests about to be sent: the
+// selected backend's connection count is incremented in the same atomic
+// step that checks its cap, so concurrent selections can't overfill it. The
+// caller must release the slot with DecrementConnections.
+func (lb *LoadBalancer) reserveBackend(ctx context.Context, r *http.Request, subset func(*backend.Backend) bool) (*backend.Backend, error) {
+	return lb.selectWith(ctx, r, subset, true)
+}
+
+// selectWith implements selectBackend and, with reserve, reserveBackend.
+func (lb *LoadBalancer) selectWith(ctx context.Context, r *http.Request, subset func(*backend.Backend) bool, reserve bool) (selected *backend.Backend, err error) {
+	defer func() { lb.stats.count(selected, err) }()
+	selected, err = lb.trySelect(ctx, r, subset, reserve)
+	if lb.offlineWait > 0 && errors.Is(err, ErrAllBackendsOffline) {
+		return lb.waitForBackend(ctx, func() (*backend.Backend, error) {
+			return lb.trySelect(ctx, r, subset, reserve)
+		})
+	}
+	if lb.queue == nil || !errors.Is(err, ErrAllBackendsSaturated) {
+		return selected, err
+	}
+	return lb.queue.wait(ctx, func() (*backend.Backend, error) {
+		return lb.trySelect(ctx, r, subset, reserve)
+	})
+}
+
+// trySelect makes one selection attempt for selectWith.
+func (lb *LoadBalancer) trySelect(ctx context.Context, r *http.Request, subset func(*backend.Backend) bool, reserve bool) (*backend.Backend, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if subset != nil && !slices.ContainsFunc(lb.Backends(), subset) {
+		return nil, ErrNoMatchingBackends
+	}
+	if !lb.enoughHealthy() {
+		return nil, ErrInsufficientHealthyBackends
+	}
+
+	key := selectionKey(ctx)
+	class := lb.requestClass(r)
+	isSelectable := func(b *backend.Backend) bool { return lb.isSelectable(b, class) }
+	acceptsNew := func(b *backend.Backend) bool { return lb.acceptsNew(b, class) }
+	if subset != nil {
+		isSelectable = within(subset, isSelectable)
+		acceptsNew = within(subset, acceptsNew)
+	}
+	// Fallback tiers only see traffic once every preferred backend is out
+	if tier, ok := lb.activeTier(subset); ok {
+		if subset == nil {
+			lb.noteTier(tier)
+		}
+		isSelectable = inTier(isSelectable, tier)
+		acceptsNew = inTier(acceptsNew, tier)
+	}
+	// Degraded backends only see traffic once no healthy one can take it
+	if slices.ContainsFunc(lb.Backends(), undegraded(acceptsNew)) {
+		isSelectable = undegraded(isSelectable)
+		acceptsNew = undegraded(acceptsNew)
+	}
+	// Low-priority requests leave the top of each backend's capacity alone
+	low := lb.lowPriority(r)
+	ignoringReserve := acceptsNew
+	if low {
+		isSelectable = lb.outsideHighPriorityReserve(isSelectable)
+		acceptsNew = lb.outsideHighPriorityReserve(acceptsNew)
+	}
 
-	for attempts < totalBackends {
-		idx := lb.current.Add(1) - 1
-		idx = idx % uint64(totalBackends)
+	selectable := isSelectable
+	var overCapacity, filled []*backend.Backend
+	for {
+		selected, err := lb.pick(r, key, selectable)
+		// Warming backends still beat failing the request outright
+		if errors.Is(err, ErrAllBackendsOffline) && lb.slowStart > 0 {
+			selected, err = lb.pick(r, key, lb.withoutBackends(acceptsNew, slices.Concat(overCapacity, filled)))
+		}
+		if err != nil {
+			if errors.Is(err, ErrAllBackendsOffline) {
+				if len(overCapacity) > 0 {
+					return nil, ErrAllBackendsRateLimited
+				}
+				if len(filled) > 0 || lb.anySaturated(class) {
+					return nil, ErrAllBackendsSaturated
+				}
+				if low && slices.ContainsFunc(lb.Backends(), ignoringReserve) {
+					return nil, lb.lowPriorityRefused()
+				}
+				if lb.noneChecked() {
+					return nil, ErrNoBackendsChecked
+				}
+			}
+			return nil, err
+		}
+
+		if !selected.AllowRequest() {
+			// The backend is at its declared capacity; shed it and pick again
+			overCapacity = append(overCapacity, selected)
+		} else if reserve && !selected.TryIncrementConnectionsFor(class) {
+			// Concurrent requests took its last slots since it was picked
+			filled = append(filled, selected)
+		} else {
+			if lb.selectUnknown {
+				lb.warnUnknown(selected)
+			}
+			return selected, nil
+		}
+		selectable = lb.withoutBackends(isSelectable, slices.Concat(overCapacity, filled))
+	}
+}
 
-		selectedBackend := lb.backends[idx]
-		if selectedBackend.IsAlive() {
-			return selectedBackend, nil
+// requestClass returns r's class for capacity reservations, or "" if r is
+// nil or no classifier is configured.
+func (lb *LoadBalancer) requestClass(r *http.Request) string {
+	if r == nil || lb.classify == nil {
+		return ""
+	}
+	return lb.classify(r)
+}
+
+// withoutBackends narrows available to exclude the given backends.
+func (lb *LoadBalancer) withoutBackends(available func(*backend.Backend) bool, excluded []*backend.Backend) func(*backend.Backend) bool {
+	if len(excluded) == 0 {
+		return available
+	}
+	return func(b *backend.Backend) bool {
+		for _, e := range excluded {
+			if b == e {
+				return false
+			}
 		}
+		return available(b)
+	}
+}
 
-		attempts++
+// within narrows available to the backends accepted by subset.
+func within(subset, available func(*backend.Backend) bool) func(*backend.Backend) bool {
+	return func(b *backend.Backend) bool {
+		return subset(b) && available(b)
 	}
+}
+
+// undegraded narrows available to backends that are not degraded.
+func undegraded(available func(*backend.Backend) bool) func(*backend.Backend) bool {
+	return func(b *backend.Backend) bool {
+		return !b.IsDegraded() && available(b)
+	}
+}
+
+// pick runs the strategy over the pool with the given availability filter.
+// A non-empty key takes precedence over r.
+func (lb *LoadBalancer) pick(r *http.Request, key string, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	backends := lb.Backends()
+	if key != "" {
+		if ks, ok := lb.strategy.(KeyStrategy); ok {
+			return ks.PickForKey(key, backends, available)
+		}
+		return lb.keyRing.PickForKey(key, backends, available)
+	}
+	if rs, ok := lb.strategy.(RequestStrategy); ok && r != nil {
+		return rs.PickForRequest(r, backends, available)
+	}
+	return lb.strategy.Pick(backends, available)
+}
+
+// isAvailable reports whether b may currently receive traffic: it must be
+// enabled, healthy and not ejected as an outlier.
+func (lb *LoadBalancer) isAvailable(b *backend.Backend) bool {
+	return b.IsEnabled() && !b.IsEjected() && lb.isHealthy(b)
+}
+
+// acceptsNew reports whether b may be sent new requests of the given class:
+// it must be available, not draining and below the share of its connection
+// cap that class may use.
+func (lb *LoadBalancer) acceptsNew(b *backend.Backend, class string) bool {
+	return lb.isAvailable(b) && !b.IsDraining() && !b.AtCapacityFor(class)
+}
 
-	return nil, fmt.Errorf("all backends are offline")
+// isSelectable reports whether b should take this particular request: it
+// must accept new requests and, while warming up, win its slow-start share.
+func (lb *LoadBalancer) isSelectable(b *backend.Backend, class string) bool {
+	return lb.acceptsNew(b, class) && lb.admitWarming(b)
 }
 
-// GetHealthyBackends returns only the backends that are currently alive.
-func (lb *LoadBalancer) GetHealthyBackends() []*backend.Backend {
-	var healthy []*backend.Backend
-	for _, b := range lb.backends {
-		if b.IsAlive() {
-			healthy = append(healthy, b)
+// anySaturated reports whether some available backend was only skipped
+// because the class is at its share of the backend's connection cap.
+func (lb *LoadBalancer) anySaturated(class string) bool {
+	for _, b := range lb.Backends() {
+		if lb.isAvailable(b) && !b.IsDraining() && b.AtCapacityFor(class) {
+			return true
 		}
 	}
-	return healthy
+	return false
+}
+
+// noneChecked reports whether every backend in the pool is still waiting
+// for its first health check.
+func (lb *LoadBalancer) noneChecked() bool {
+	return !slices.ContainsFunc(lb.Backends(), func(b *backend.Backend) bool {
+		return b.HealthState() != backend.Unknown
+	})
+}
+
+// enoughHealthy reports whether the pool meets the WithMinHealthy and
+// WithMinHealthyFraction thresholds. With no backend healthy at all it
+// reports true, leaving selection to fail with ErrAllBackendsOffline.
+func (lb *LoadBalancer) enoughHealthy() bool {
+	if lb.minHealthy <= 1 && lb.minHealthyFraction <= 0 {
+		return true
+	}
+	required := max(lb.minHealthy, int(math.Ceil(lb.minHealthyFraction*float64(len(lb.Backends())))))
+	healthy := len(lb.GetHealthyBackends())
+	return healthy == 0 || healthy >= required
+}
+
+// HealthRatio returns the fraction of the pool that is currently available,
+// from 0 when every backend is down to 1 when all are up.
+func (lb *LoadBalancer) HealthRatio() float64 {
+	backends := lb.Backends()
+	if len(backends) == 0 {
+		return 0
+	}
+	return float64(len(lb.GetHealthyBackends())) / float64(len(backends))
+}
+
+// Ready reports whether at least one backend is available to serve traffic.
+func (lb *LoadBalancer) Ready() bool {
+	return len(lb.GetHealthyBackends()) > 0
 }
@@ -0,0 +1,409 @@
+package balancer
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+)
+
+// defaultCheckInterval is used when the config file sets no health check interval.
+const defaultCheckInterval = 5 * time.Second
+
+// Config describes a load balancer and its health checks. It is usually read
+// from a YAML or JSON file with LoadConfig:
+//
+//	strategy: weighted-round-robin
+//	backends:
+//	  - url: http://10.0.0.5:8080
+//	    weight: 3
+//	  - url: http://10.0.0.6:8080
+//	    health_path: /healthz
+//	health_check:
+//	  interval: 5s
+//	  timeout: 2s
+//	  unhealthy_threshold: 3
+type Config struct {
+	// Strategy is one of the names accepted by StrategyByName; empty means round-robin.
+	Strategy    string            `json:"strategy" yaml:"strategy"`
+	MaxRetries  *int              `json:"max_retries" yaml:"max_retries"`
+	Backends    []BackendConfig   `json:"backends" yaml:"backends"`
+	HealthCheck HealthCheckConfig `json:"health_check" yaml:"health_check"`
+
+	// TrustForwarded makes the ip-hash and consistent-hash strategies key on
+	// the first X-Forwarded-For address instead of the connection's, for a
+	// balancer behind another proxy. Clients can forge the header, so only
+	// set it if that proxy overwrites it; see ClientIP.
+	TrustForwarded bool `json:"trust_forwarded" yaml:"trust_forwarded"`
+}
+
+// BackendConfig describes one backend.
+type BackendConfig struct {
+	URL            string `json:"url" yaml:"url"`
+	Weight         int    `json:"weight" yaml:"weight"`
+	Priority       int    `json:"priority" yaml:"priority"`
+	HealthPath     string `json:"health_path" yaml:"health_path"`
+	MaxConnections int    `json:"max_connections" yaml:"max_connections"`
+
+	// StripPrefix is removed from request paths and PathPrefix put in front
+	// before they are forwarded; see backend.Backend.SetPathRewrite.
+	StripPrefix string `json:"strip_prefix" yaml:"strip_prefix"`
+	PathPrefix  string `json:"path_prefix" yaml:"path_prefix"`
+
+	// RequestHeaders are set on every request proxied to the backend, unless
+	// the client sent them itself and OverrideRequestHeaders is false; see
+	// backend.Backend.SetRequestHeaders.
+	RequestHeaders         map[string]string `json:"request_headers" yaml:"request_headers"`
+	OverrideRequestHeaders bool              `json:"override_request_headers" yaml:"override_request_headers"`
+
+	// H2C proxies to the backend over HTTP/2 cleartext; the URL must be http.
+	H2C bool `json:"h2c" yaml:"h2c"`
+
+	// TLSServerName and TLSCAFile verify an https backend's certificate
+	// against this name and the CAs in this PEM file instead of the URL's
+	// host and the system roots; see backend.Backend.SetTLS.
+	TLSServerName string `json:"tls_server_name" yaml:"tls_server_name"`
+	TLSCAFile     string `json:"tls_ca_file" yaml:"tls_ca_file"`
+
+	// HealthCheckInterval overrides health_check.interval for this backend.
+	HealthCheckInterval Duration `json:"health_check_interval" yaml:"health_check_interval"`
+}
+
+// HealthCheckConfig describes the active health checks.
+type HealthCheckConfig struct {
+	// Type is http (the default), tcp or grpc.
+	Type               string    `json:"type" yaml:"type"`
+	GRPCService        string    `json:"grpc_service" yaml:"grpc_service"`
+	Path               string    `json:"path" yaml:"path"`
+	Interval           *Duration `json:"interval" yaml:"interval"`
+	Timeout            Duration  `json:"timeout" yaml:"timeout"`
+	HealthyThreshold   int       `json:"healthy_threshold" yaml:"healthy_threshold"`
+	UnhealthyThreshold int       `json:"unhealthy_threshold" yaml:"unhealthy_threshold"`
+	// MaxBackoff enables probe backoff for dead backends, up to this gap.
+	MaxBackoff        Duration `json:"max_backoff" yaml:"max_backoff"`
+	BackoffMultiplier float64  `json:"backoff_multiplier" yaml:"backoff_multiplier"`
+}
+
+// Duration is a time.Duration written in config files as a string such as "5s".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"5s\": %w", err)
+	}
+	return d.parse(s)
+}
+
+func (d *Duration) UnmarshalYAML(value *yaml.Node) error {
+	return d.parse(value.Value)
+}
+
+func (d *Duration) parse(s string) error {
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// StrategyByName returns a new instance of the named strategy: round-robin,
+// weighted-round-robin, random, weighted-random, power-of-two-choices,
+// least-connections, least-response-time, most-headroom, score-weighted,
+// ip-hash or consistent-hash. The hash
+// strategies key on the client address.
+func StrategyByName(name string) (Strategy, error) {
+	return newStrategy(name, false)
+}
+
+// newStrategy is StrategyByName with the hash strategies keyed on
+// X-Forwarded-For if trustForwarded is set.
+func newStrategy(name string, trustForwarded bool) (Strategy, error) {
+	switch name {
+	case "", "round-robin":
+		return NewRoundRobin(), nil
+	case "weighted-round-robin":
+		return NewWeightedRoundRobin(), nil
+	case "random":
+		return NewRandom(nil), nil
+	case "weighted-random":
+		return NewWeightedRandom(nil), nil
+	case "power-of-two-choices":
+		return NewPowerOfTwoChoices(nil), nil
+	case "least-connections":
+		return NewLeastConnections(), nil
+	case "least-response-time":
+		return NewLeastResponseTime(), nil
+	case "most-headroom":
+		return NewMostHeadroom(), nil
+	case "score-weighted":
+		return NewScoreWeighted(), nil
+	case "ip-hash":
+		return NewIPHash(trustForwarded), nil
+	case "consistent-hash":
+		return NewConsistentHash(ClientIPKey(trustForwarded), DefaultVirtualNodes), nil
+	default:
+		return nil, fmt.Errorf("unknown strategy %q", name)
+	}
+}
+
+// StrategyNames returns the names StrategyByName accepts, in the order it
+// lists them.
+func StrategyNames() []string {
+	return []string{
+		"round-robin", "weighted-round-robin", "random", "weighted-random", "power-of-two-choices",
+		"least-connections", "least-response-time", "most-headroom", "score-weighted", "ip-hash",
+		"consistent-hash",
+	}
+}
+
+// LoadConfig reads and validates a config file. Files ending in .json are
+// parsed as JSON, anything else as YAML. Unknown fields are rejected so typos
+// don't silently fall back to defaults.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	var cfg Config
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cfg)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate reports every problem with the config at once.
+func (c *Config) Validate() error {
+	var errs []error
+	if _, err := StrategyByName(c.Strategy); err != nil {
+		errs = append(errs, err)
+	}
+	if c.MaxRetries != nil && *c.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("max_retries must not be negative, got %d", *c.MaxRetries))
+	}
+
+	if len(c.Backends) == 0 {
+		errs = append(errs, ErrNoBackends)
+	}
+	seen := make(map[string]int)
+	for i, bc := range c.Backends {
+		b, err := backend.NewBackend(bc.URL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("backends[%d]: %w", i, err))
+			continue
+		}
+		if first, ok := seen[b.URL.String()]; ok {
+			errs = append(errs, fmt.Errorf("backends[%d]: duplicate URL %s (also backends[%d])", i, bc.URL, first))
+		}
+		seen[b.URL.String()] = i
+		if bc.Weight < 0 {
+			errs = append(errs, fmt.Errorf("backends[%d]: weight must not be negative, got %d", i, bc.Weight))
+		}
+		if bc.Priority < 0 {
+			errs = append(errs, fmt.Errorf("backends[%d]: priority must not be negative, got %d", i, bc.Priority))
+		}
+		if bc.MaxConnections < 0 {
+			errs = append(errs, fmt.Errorf("backends[%d]: max_connections must not be negative, got %d", i, bc.MaxConnections))
+		}
+		if bc.HealthPath != "" && !strings.HasPrefix(bc.HealthPath, "/") {
+			errs = append(errs, fmt.Errorf("backends[%d]: health_path %q must start with /", i, bc.HealthPath))
+		}
+		for _, prefix := range []struct{ name, value string }{{"strip_prefix", bc.StripPrefix}, {"path_prefix", bc.PathPrefix}} {
+			if prefix.value != "" && !strings.HasPrefix(prefix.value, "/") {
+				errs = append(errs, fmt.Errorf("backends[%d]: %s %q must start with /", i, prefix.name, prefix.value))
+			}
+		}
+		for name := range bc.RequestHeaders {
+			if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+				errs = append(errs, fmt.Errorf("backends[%d]: request_headers has invalid header name %q", i, name))
+			}
+		}
+		if bc.H2C && b.URL.Scheme != "http" {
+			errs = append(errs, fmt.Errorf("backends[%d]: h2c needs an http URL, got %s", i, bc.URL))
+		}
+		if (bc.TLSServerName != "" || bc.TLSCAFile != "") && b.URL.Scheme != "https" {
+			errs = append(errs, fmt.Errorf("backends[%d]: tls_server_name and tls_ca_file need an https URL, got %s", i, bc.URL))
+		} else if _, err := bc.tlsConfig(); err != nil {
+			errs = append(errs, fmt.Errorf("backends[%d]: %w", i, err))
+		}
+		if bc.HealthCheckInterval < 0 {
+			errs = append(errs, fmt.Errorf("backends[%d]: health_check_interval must not be negative, got %v", i, time.Duration(bc.HealthCheckInterval)))
+		}
+	}
+
+	hc := c.HealthCheck
+	if hc.Interval != nil && *hc.Interval <= 0 {
+		errs = append(errs, fmt.Errorf("health_check.interval must be positive, got %v", time.Duration(*hc.Interval)))
+	}
+	if hc.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("health_check.timeout must not be negative, got %v", time.Duration(hc.Timeout)))
+	}
+	if _, err := hc.checkType(); err != nil {
+		errs = append(errs, err)
+	}
+	if hc.Path != "" && !strings.HasPrefix(hc.Path, "/") {
+		errs = append(errs, fmt.Errorf("health_check.path %q must start with /", hc.Path))
+	}
+	if hc.HealthyThreshold < 0 || hc.UnhealthyThreshold < 0 {
+		errs = append(errs, fmt.Errorf("health_check thresholds must not be negative"))
+	}
+	if hc.MaxBackoff < 0 {
+		errs = append(errs, fmt.Errorf("health_check.max_backoff must not be negative, got %v", time.Duration(hc.MaxBackoff)))
+	}
+	if hc.BackoffMultiplier != 0 && hc.BackoffMultiplier <= 1 {
+		errs = append(errs, fmt.Errorf("health_check.backoff_multiplier must be greater than 1, got %v", hc.BackoffMultiplier))
+	}
+
+	return errors.Join(errs...)
+}
+
+// FromConfigFile builds a load balancer and its (not yet started) health
+// checker from the config file at path. opts are applied after the settings
+// from the file.
+func FromConfigFile(path string, opts ...Option) (*LoadBalancer, *healthcheck.HealthChecker, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return FromConfig(cfg, opts...)
+}
+
+// FromConfig builds a load balancer and its (not yet started) health checker
+// from cfg. opts are applied after the settings from cfg.
+func FromConfig(cfg *Config, opts ...Option) (*LoadBalancer, *healthcheck.HealthChecker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	backends := make([]*backend.Backend, 0, len(cfg.Backends))
+	for _, bc := range cfg.Backends {
+		b, err := newConfiguredBackend(bc)
+		if err != nil {
+			return nil, nil, err
+		}
+		backends = append(backends, b)
+	}
+
+	strategy, _ := newStrategy(cfg.Strategy, cfg.TrustForwarded)
+	lbOpts := []Option{WithStrategy(strategy)}
+	if cfg.MaxRetries != nil {
+		lbOpts = append(lbOpts, WithMaxRetries(*cfg.MaxRetries))
+	}
+	lb, err := New(backends, append(lbOpts, opts...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lb.config = cfg
+	lb.checker = lb.newChecker(cfg.HealthCheck, backends)
+	return lb, lb.checker, nil
+}
+
+// newChecker creates a health checker for backends with the given settings.
+func (lb *LoadBalancer) newChecker(hc HealthCheckConfig, backends []*backend.Backend) *healthcheck.HealthChecker {
+	interval, opts := hc.checkerOptions()
+	opts = append(opts, healthcheck.WithLogger(lb.logger))
+	return healthcheck.NewHealthChecker(backends, interval, opts...)
+}
+
+// newConfiguredBackend creates a backend with the settings from bc.
+func newConfiguredBackend(bc BackendConfig) (*backend.Backend, error) {
+	b, err := backend.NewBackend(bc.URL)
+	if err != nil {
+		return nil, err
+	}
+	b.SetWeight(bc.Weight)
+	b.SetPriority(bc.Priority)
+	b.SetHealthPath(bc.HealthPath)
+	b.SetHealthCheckInterval(time.Duration(bc.HealthCheckInterval))
+	b.SetMaxConnections(bc.MaxConnections)
+	b.SetPathRewrite(bc.StripPrefix, bc.PathPrefix)
+	b.SetRequestHeaders(bc.RequestHeaders, bc.OverrideRequestHeaders)
+	if bc.H2C {
+		b.EnableH2C()
+	}
+	if bc.TLSServerName != "" || bc.TLSCAFile != "" {
+		cfg, err := bc.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		if err := b.SetTLS(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// tlsConfig loads the backend's TLS settings, reading TLSCAFile if set.
+func (bc BackendConfig) tlsConfig() (backend.TLSConfig, error) {
+	cfg := backend.TLSConfig{ServerName: bc.TLSServerName}
+	if bc.TLSCAFile == "" {
+		return cfg, nil
+	}
+	pem, err := os.ReadFile(bc.TLSCAFile)
+	if err != nil {
+		return cfg, fmt.Errorf("reading tls_ca_file: %w", err)
+	}
+	cfg.RootCAs = x509.NewCertPool()
+	if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+		return cfg, fmt.Errorf("tls_ca_file %s contains no PEM certificates", bc.TLSCAFile)
+	}
+	return cfg, nil
+}
+
+// checkerOptions translates the health check settings into checker options.
+func (hc HealthCheckConfig) checkerOptions() (time.Duration, []healthcheck.Option) {
+	interval := defaultCheckInterval
+	if hc.Interval != nil {
+		interval = time.Duration(*hc.Interval)
+	}
+	checkType, _ := hc.checkType() // rejected by Validate
+	return interval, []healthcheck.Option{
+		healthcheck.WithCheckType(checkType),
+		healthcheck.WithGRPCService(hc.GRPCService),
+		healthcheck.WithHealthPath(hc.Path),
+		healthcheck.WithTimeout(time.Duration(hc.Timeout)),
+		healthcheck.WithThresholds(hc.HealthyThreshold, hc.UnhealthyThreshold),
+		healthcheck.WithBackoff(time.Duration(hc.MaxBackoff)),
+		healthcheck.WithBackoffMultiplier(hc.BackoffMultiplier),
+	}
+}
+
+// checkType parses the configured check type.
+func (hc HealthCheckConfig) checkType() (healthcheck.CheckType, error) {
+	switch hc.Type {
+	case "", "http":
+		return healthcheck.CheckHTTP, nil
+	case "tcp":
+		return healthcheck.CheckTCP, nil
+	case "grpc":
+		return healthcheck.CheckGRPC, nil
+	default:
+		return healthcheck.CheckHTTP, fmt.Errorf("health_check.type %q must be http, tcp or grpc", hc.Type)
+	}
+}
@@ -0,0 +1,182 @@
+package balancer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes content to a file with the given name in a temp dir
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+// TestFromConfigFile tests building a balancer from YAML and JSON files
+func TestFromConfigFile(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		path := writeConfig(t, "lb.yaml", `
+strategy: weighted-round-robin
+max_retries: 1
+backends:
+  - url: http://localhost:3000
+    weight: 3
+  - url: http://localhost:3001
+    health_path: /healthz
+    max_connections: 200
+health_check:
+  interval: 1s
+  timeout: 500ms
+  unhealthy_threshold: 3
+`)
+		lb, hc, err := FromConfigFile(path)
+		if err != nil {
+			t.Fatalf("FromConfigFile failed: %v", err)
+		}
+		if hc == nil {
+			t.Fatal("Expected a health checker")
+		}
+
+		backends := lb.Backends()
+		if len(backends) != 2 {
+			t.Fatalf("Expected 2 backends, got %d", len(backends))
+		}
+		if backends[0].Weight() != 3 {
+			t.Errorf("Expected weight 3, got %d", backends[0].Weight())
+		}
+		if backends[1].HealthPath() != "/healthz" || backends[1].MaxConnections() != 200 {
+			t.Errorf("Expected health path /healthz and 200 max connections, got %q and %d",
+				backends[1].HealthPath(), backends[1].MaxConnections())
+		}
+		if _, ok := lb.strategy.(*WeightedRoundRobin); !ok {
+			t.Errorf("Expected WeightedRoundRobin, got %T", lb.strategy)
+		}
+		if lb.maxRetries != 1 {
+			t.Errorf("Expected 1 retry, got %d", lb.maxRetries)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		path := writeConfig(t, "lb.json", `{
+  "backends": [{"url": "http://localhost:3000"}],
+  "health_check": {"interval": "2s"}
+}`)
+		lb, _, err := FromConfigFile(path)
+		if err != nil {
+			t.Fatalf("FromConfigFile failed: %v", err)
+		}
+		if _, ok := lb.strategy.(*RoundRobin); !ok {
+			t.Errorf("Expected default RoundRobin, got %T", lb.strategy)
+		}
+	})
+
+	t.Run("Trust Forwarded", func(t *testing.T) {
+		path := writeConfig(t, "lb.yaml", `
+strategy: ip-hash
+trust_forwarded: true
+backends:
+  - url: http://localhost:3000
+`)
+		lb, _, err := FromConfigFile(path)
+		if err != nil {
+			t.Fatalf("FromConfigFile failed: %v", err)
+		}
+		h, ok := lb.strategy.(*IPHash)
+		if !ok {
+			t.Fatalf("Expected IPHash, got %T", lb.strategy)
+		}
+		if !h.trustForwarded {
+			t.Error("Expected the strategy to trust X-Forwarded-For")
+		}
+	})
+}
+
+// TestConfigValidation tests that bad config files produce descriptive errors
+func TestConfigValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{"Unknown Strategy", "lb.yaml", `
+strategy: fastest
+backends:
+  - url: http://localhost:3000
+`, `unknown strategy "fastest"`},
+		{"Duplicate URLs", "lb.yaml", `
+backends:
+  - url: http://localhost:3000
+  - url: http://localhost:3000
+`, "backends[1]: duplicate URL http://localhost:3000"},
+		{"Non-Positive Interval", "lb.yaml", `
+backends:
+  - url: http://localhost:3000
+health_check:
+  interval: 0s
+`, "health_check.interval must be positive"},
+		{"No Backends", "lb.yaml", `strategy: round-robin`, "at least one backend is required"},
+		{"Invalid URL", "lb.json", `{"backends": [{"url": "localhost:3000"}]}`, "backends[0]"},
+		{"Unknown Field", "lb.yaml", `
+backend:
+  - url: http://localhost:3000
+`, "field backend not found"},
+		{"Bad Duration", "lb.json", `{"backends": [{"url": "http://localhost:3000"}], "health_check": {"interval": "soon"}}`, "invalid duration"},
+		{"Unknown Check Type", "lb.yaml", `
+backends:
+  - url: http://localhost:3000
+health_check:
+  type: icmp
+`, `health_check.type "icmp" must be http, tcp or grpc`},
+		{"Backoff Multiplier Too Small", "lb.yaml", `
+backends:
+  - url: http://localhost:3000
+health_check:
+  max_backoff: 5m
+  backoff_multiplier: 1
+`, "health_check.backoff_multiplier must be greater than 1"},
+		{"Relative Strip Prefix", "lb.yaml", `
+backends:
+  - url: http://localhost:3000
+    strip_prefix: api/v1
+`, `backends[0]: strip_prefix "api/v1" must start with /`},
+		{"H2C Over HTTPS", "lb.yaml", `
+backends:
+  - url: https://localhost:3000
+    h2c: true
+`, `backends[0]: h2c needs an http URL, got https://localhost:3000`},
+		{"Invalid Request Header Name", "lb.yaml", `
+backends:
+  - url: http://localhost:3000
+    request_headers:
+      "X Api Key": secret
+`, `backends[0]: request_headers has invalid header name "X Api Key"`},
+		{"TLS Server Name Over HTTP", "lb.yaml", `
+backends:
+  - url: http://localhost:3000
+    tls_server_name: api.internal
+`, `backends[0]: tls_server_name and tls_ca_file need an https URL, got http://localhost:3000`},
+		{"Missing CA File", "lb.yaml", `
+backends:
+  - url: https://localhost:3000
+    tls_ca_file: /nonexistent/ca.pem
+`, `backends[0]: reading tls_ca_file`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := FromConfigFile(writeConfig(t, tt.file, tt.content))
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected error containing %q, got: %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package balancer
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// DefaultVirtualNodes is the number of ring positions per backend used when
+// NewConsistentHash is given a non-positive count.
+const DefaultVirtualNodes = 100
+
+// ConsistentHash maps keys onto a hash ring with several virtual nodes per
+// backend, so adding or removing a backend only remaps the keys it owns.
+// A key whose owner is unavailable moves to the next available backend
+// clockwise on the ring. Requests without a key fall back to round-robin.
+type ConsistentHash struct {
+	key          KeyFunc
+	virtualNodes int
+	fallback     *RoundRobin
+
+	ring    atomic.Pointer[hashRing]
+	buildMu sync.Mutex
+}
+
+// hashRing is an immutable ring built from one snapshot of the pool.
+type hashRing struct {
+	pool   []*backend.Backend
+	hashes []uint64 // sorted
+	owners map[uint64]*backend.Backend
+}
+
+// NewConsistentHash creates a consistent-hash strategy keyed by key with
+// virtualNodes ring positions per backend.
+func NewConsistentHash(key KeyFunc, virtualNodes int) *ConsistentHash {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+	return &ConsistentHash{
+		key:          key,
+		virtualNodes: virtualNodes,
+		fallback:     NewRoundRobin(),
+	}
+}
+
+// Pick has no request to take a key from, so it falls back to round-robin.
+func (ch *ConsistentHash) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	return ch.fallback.Pick(backends, available)
+}
+
+// PickForRequest returns the owner of the request's key on the ring.
+func (ch *ConsistentHash) PickForRequest(r *http.Request, backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	return ch.PickForKey(ch.key(r), backends, available)
+}
+
+// PickForKey walks the ring clockwise from the key's hash to the first
+// available backend. An empty key falls back to round-robin.
+func (ch *ConsistentHash) PickForKey(key string, backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	if key == "" {
+		return ch.fallback.Pick(backends, available)
+	}
+	ring := ch.ringFor(backends)
+	if len(ring.hashes) == 0 {
+		return nil, ErrAllBackendsOffline
+	}
+
+	hash := hashKey(key)
+	start := sort.Search(len(ring.hashes), func(i int) bool { return ring.hashes[i] >= hash })
+	for i := 0; i < len(ring.hashes); i++ {
+		owner := ring.owners[ring.hashes[(start+i)%len(ring.hashes)]]
+		if available(owner) {
+			return owner, nil
+		}
+	}
+	return nil, ErrAllBackendsOffline
+}
+
+// ringFor returns the ring for backends, rebuilding it if the pool changed.
+// Selections keep reading the old ring while a new one is being built.
+func (ch *ConsistentHash) ringFor(backends []*backend.Backend) *hashRing {
+	if ring := ch.ring.Load(); ring != nil && samePool(ring.pool, backends) {
+		return ring
+	}
+
+	ch.buildMu.Lock()
+	defer ch.buildMu.Unlock()
+
+	// Another goroutine may have rebuilt it while we waited
+	if ring := ch.ring.Load(); ring != nil && samePool(ring.pool, backends) {
+		return ring
+	}
+
+	ring := &hashRing{
+		pool:   backends,
+		hashes: make([]uint64, 0, len(backends)*ch.virtualNodes),
+		owners: make(map[uint64]*backend.Backend, len(backends)*ch.virtualNodes),
+	}
+	for _, b := range backends {
+		for v := 0; v < ch.virtualNodes; v++ {
+			hash := hashKey(b.URL.String() + "#" + strconv.Itoa(v))
+			if _, taken := ring.owners[hash]; taken {
+				continue
+			}
+			ring.owners[hash] = b
+			ring.hashes = append(ring.hashes, hash)
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool { return ring.hashes[i] < ring.hashes[j] })
+
+	ch.ring.Store(ring)
+	return ring
+}
+
+// samePool reports whether a and b are the same pool snapshot.
+func samePool(a, b []*backend.Backend) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return len(a) == 0 || &a[0] == &b[0]
+}
@@ -0,0 +1,206 @@
+package balancer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestConsistentHash tests that pool changes only remap the affected share of keys
+func TestConsistentHash(t *testing.T) {
+	const keyHeader = "X-Cache-Key"
+	const numKeys = 2000
+
+	backends := make([]*backend.Backend, 5)
+	for i := range backends {
+		backends[i] = newTestBackend(t, fmt.Sprintf("http://cache-%d:3000", i))
+		backends[i].SetAlive(true)
+	}
+
+	lb, err := New(backends, WithStrategy(NewConsistentHash(HeaderKey(keyHeader), 100)))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	assignments := func() map[string]*backend.Backend {
+		result := make(map[string]*backend.Backend, numKeys)
+		for i := 0; i < numKeys; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(keyHeader, key)
+			selected, err := lb.SelectBackendForRequest(req)
+			if err != nil {
+				t.Fatalf("Selection failed: %v", err)
+			}
+			result[key] = selected
+		}
+		return result
+	}
+
+	before := assignments()
+
+	t.Run("Stable Assignment", func(t *testing.T) {
+		again := assignments()
+		for key, b := range before {
+			if again[key] != b {
+				t.Fatalf("%s moved without any pool change", key)
+			}
+		}
+	})
+
+	t.Run("Adding A Backend Only Moves Keys To It", func(t *testing.T) {
+		added := newTestBackend(t, "http://cache-5:3000")
+		added.SetAlive(true)
+		if err := lb.AddBackend(added); err != nil {
+			t.Fatalf("AddBackend failed: %v", err)
+		}
+
+		moved := 0
+		for key, b := range assignments() {
+			if b == before[key] {
+				continue
+			}
+			moved++
+			if b != added {
+				t.Errorf("%s moved between existing backends", key)
+			}
+		}
+		if moved > numKeys/4 {
+			t.Errorf("Expected roughly 1/6 of keys to move, %d of %d did", moved, numKeys)
+		}
+
+		if _, err := lb.RemoveBackend(added.URL.Host); err != nil {
+			t.Fatalf("RemoveBackend failed: %v", err)
+		}
+	})
+
+	t.Run("Removing A Backend Only Moves Its Keys", func(t *testing.T) {
+		removed, err := lb.RemoveBackend(backends[2].URL.Host)
+		if err != nil {
+			t.Fatalf("RemoveBackend failed: %v", err)
+		}
+
+		for key, b := range assignments() {
+			if before[key] != removed && b != before[key] {
+				t.Errorf("%s moved although its backend is still in the pool", key)
+			}
+		}
+	})
+
+	t.Run("Concurrent Selection During Pool Changes", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				for i := 0; i < 500; i++ {
+					req := httptest.NewRequest(http.MethodGet, "/", nil)
+					req.Header.Set(keyHeader, fmt.Sprintf("key-%d-%d", g, i))
+					if _, err := lb.SelectBackendForRequest(req); err != nil {
+						t.Errorf("Selection failed: %v", err)
+						return
+					}
+				}
+			}(g)
+		}
+
+		for i := 0; i < 20; i++ {
+			extra := newTestBackend(t, fmt.Sprintf("http://extra-%d:3000", i))
+			extra.SetAlive(true)
+			if err := lb.AddBackend(extra); err != nil {
+				t.Fatalf("AddBackend failed: %v", err)
+			}
+			if _, err := lb.RemoveBackend(extra.URL.Host); err != nil {
+				t.Fatalf("RemoveBackend failed: %v", err)
+			}
+		}
+		wg.Wait()
+	})
+}
+
+// TestSelectBackendForKey tests that a dead backend only loses its own keys
+func TestSelectBackendForKey(t *testing.T) {
+	const numKeys = 10000
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+	}{
+		{"Consistent Hash Strategy", NewConsistentHash(HeaderKey("X-Cache-Key"), 0)},
+		{"Other Strategy", NewRoundRobin()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backends := make([]*backend.Backend, 5)
+			for i := range backends {
+				backends[i] = newTestBackend(t, fmt.Sprintf("http://cache-%d:3000", i))
+				backends[i].SetAlive(true)
+			}
+			lb, err := New(backends, WithStrategy(tt.strategy))
+			if err != nil {
+				t.Fatalf("Failed to create load balancer: %v", err)
+			}
+
+			assignments := func() []*backend.Backend {
+				result := make([]*backend.Backend, numKeys)
+				for i := range result {
+					selected, err := lb.SelectBackendForKey(fmt.Sprintf("user-%d", i))
+					if err != nil {
+						t.Fatalf("SelectBackendForKey failed: %v", err)
+					}
+					result[i] = selected
+				}
+				return result
+			}
+
+			before := assignments()
+			backends[3].SetAlive(false)
+			after := assignments()
+
+			moved := 0
+			for i := range before {
+				if after[i] == before[i] {
+					continue
+				}
+				moved++
+				if before[i] != backends[3] {
+					t.Errorf("user-%d moved although its backend is alive", i)
+				}
+			}
+			if moved == 0 || moved >= numKeys/4 {
+				t.Errorf("Expected between 0 and 25%% of keys to move, got %d of %d", moved, numKeys)
+			}
+
+			backends[3].SetAlive(true)
+			for i, b := range assignments() {
+				if b != before[i] {
+					t.Fatalf("user-%d did not return to its backend after it recovered", i)
+				}
+			}
+		})
+	}
+}
+
+// TestPoolMembership tests AddBackend and RemoveBackend validation
+func TestPoolMembership(t *testing.T) {
+	b := newTestBackend(t, "http://localhost:3000")
+	lb, err := New([]*backend.Backend{b})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	if err := lb.AddBackend(newTestBackend(t, "http://localhost:3000")); err == nil {
+		t.Error("Expected error adding a duplicate backend")
+	}
+	if _, err := lb.RemoveBackend("localhost:9999"); err == nil {
+		t.Error("Expected error removing an unknown backend")
+	}
+	if len(lb.Backends()) != 1 {
+		t.Errorf("Expected pool of 1, got %d", len(lb.Backends()))
+	}
+}
@@ -0,0 +1,52 @@
+package balancer
+
+import (
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestDegradedFallback tests that degraded backends only take traffic when no healthy backend can
+func TestDegradedFallback(t *testing.T) {
+	healthy := newTestBackend(t, "http://localhost:3000")
+	degraded := newTestBackend(t, "http://localhost:3001")
+	healthy.SetAlive(true)
+	degraded.SetHealthState(backend.Degraded)
+
+	lb, err := New([]*backend.Backend{healthy, degraded})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("Healthy Preferred", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			if selected, err := lb.SelectBackend(); err != nil || selected != healthy {
+				t.Fatalf("Expected healthy backend, got %v (%v)", selected, err)
+			}
+		}
+	})
+
+	t.Run("Degraded Counts As Healthy", func(t *testing.T) {
+		if n := len(lb.GetHealthyBackends()); n != 2 {
+			t.Errorf("Expected 2 healthy backends, got %d", n)
+		}
+	})
+
+	t.Run("Fall Back To Degraded", func(t *testing.T) {
+		healthy.SetAlive(false)
+		defer healthy.SetAlive(true)
+		if selected, err := lb.SelectBackend(); err != nil || selected != degraded {
+			t.Errorf("Expected degraded backend, got %v (%v)", selected, err)
+		}
+	})
+
+	t.Run("Fall Back When Healthy Is Saturated", func(t *testing.T) {
+		healthy.SetMaxConnections(1)
+		healthy.IncrementConnections()
+		defer healthy.SetMaxConnections(0)
+		defer healthy.DecrementConnections()
+		if selected, err := lb.SelectBackend(); err != nil || selected != degraded {
+			t.Errorf("Expected degraded backend, got %v (%v)", selected, err)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package balancer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// Endpoint is a backend instance reported by service discovery.
+type Endpoint struct {
+	URL    string
+	Weight int // relative share for weighted strategies; 0 means 1
+}
+
+// Discoverer is implemented by service-discovery integrations (DNS, Consul,
+// a watched file, ...). Watch sends the complete current endpoint set each
+// time it changes and closes the channel when ctx is done or discovery ends.
+type Discoverer interface {
+	Watch(ctx context.Context) (<-chan []Endpoint, error)
+}
+
+// Discover keeps the pool in sync with d until ctx is done or d closes its
+// channel. New endpoints are handed to the balancer's health checker and
+// stay backend.Unknown until its first probe of them, and endpoints that
+// disappear stop being probed; existing backends keep their health and
+// counters. Without a checker nothing would ever probe new endpoints, so
+// they join marked alive, trusting discovery to only report instances that
+// are ready. An update that is empty or invalid is logged and ignored so a
+// discovery glitch never empties the pool.
+func (lb *LoadBalancer) Discover(ctx context.Context, d Discoverer) error {
+	updates, err := d.Watch(ctx)
+	if err != nil {
+		return fmt.Errorf("starting discovery: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case endpoints, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := lb.applyEndpoints(endpoints); err != nil {
+				lb.logger.Warn("ignoring discovery update", "endpoints", len(endpoints), "error", err)
+			}
+		}
+	}
+}
+
+// applyEndpoints turns a discovered endpoint set into the new pool.
+func (lb *LoadBalancer) applyEndpoints(endpoints []Endpoint) error {
+	lb.poolMu.Lock()
+	defer lb.poolMu.Unlock()
+
+	existing := make(map[string]*backend.Backend)
+	for _, b := range lb.Backends() {
+		existing[b.URL.String()] = b
+	}
+
+	backends := make([]*backend.Backend, 0, len(endpoints))
+	for _, ep := range endpoints {
+		b, err := backend.NewBackend(ep.URL)
+		if err != nil {
+			return err
+		}
+		if current, ok := existing[b.URL.String()]; ok {
+			b = current
+		} else if lb.checker == nil {
+			b.SetAlive(true)
+		}
+		b.SetWeight(ep.Weight)
+		backends = append(backends, b)
+	}
+
+	if err := lb.setBackendsLocked(backends); err != nil {
+		return err
+	}
+	lb.logger.Info("discovery updated the pool", "backends", len(backends))
+	return nil
+}
@@ -0,0 +1,158 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+)
+
+// fakeDiscoverer hands out a channel the test pushes endpoint sets into
+type fakeDiscoverer struct {
+	updates chan []Endpoint
+}
+
+func (f *fakeDiscoverer) Watch(ctx context.Context) (<-chan []Endpoint, error) {
+	return f.updates, nil
+}
+
+// poolHosts returns the sorted hosts currently in lb's pool
+func poolHosts(lb *LoadBalancer) string {
+	var hosts []string
+	for _, b := range lb.Backends() {
+		hosts = append(hosts, b.URL.Host)
+	}
+	sort.Strings(hosts)
+	return strings.Join(hosts, ",")
+}
+
+// TestDiscovery tests that the pool tracks endpoint sets pushed by a Discoverer
+func TestDiscovery(t *testing.T) {
+	initial := newTestBackend(t, "http://localhost:3000")
+	initial.SetAlive(true)
+
+	lb, err := New([]*backend.Backend{initial})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	d := &fakeDiscoverer{updates: make(chan []Endpoint)}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- lb.Discover(ctx, d) }()
+
+	// push sends an update and waits for the pool to match expected
+	push := func(t *testing.T, endpoints []Endpoint, expected string) {
+		t.Helper()
+		d.updates <- endpoints
+		deadline := time.Now().Add(2 * time.Second)
+		for poolHosts(lb) != expected {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected pool %q, got %q", expected, poolHosts(lb))
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	t.Run("Additions", func(t *testing.T) {
+		push(t, []Endpoint{
+			{URL: "http://localhost:3000"},
+			{URL: "http://localhost:3001", Weight: 3},
+		}, "localhost:3000,localhost:3001")
+
+		if lb.Backends()[0] != initial {
+			t.Error("Expected the existing backend to be kept, not replaced")
+		}
+		added := lb.Backends()[1]
+		if !added.IsAlive() || added.Weight() != 3 {
+			t.Errorf("Expected new backend alive with weight 3, got alive=%v weight=%d", added.IsAlive(), added.Weight())
+		}
+	})
+
+	t.Run("Removals", func(t *testing.T) {
+		push(t, []Endpoint{{URL: "http://localhost:3001"}}, "localhost:3001")
+
+		selected, err := lb.SelectBackend()
+		if err != nil {
+			t.Fatalf("SelectBackend failed: %v", err)
+		}
+		if selected.URL.Host != "localhost:3001" {
+			t.Errorf("Expected removed backend to stop receiving traffic, got %s", selected.URL.Host)
+		}
+	})
+
+	t.Run("Empty Update Ignored", func(t *testing.T) {
+		d.updates <- nil
+		push(t, []Endpoint{{URL: "http://localhost:3001"}, {URL: "http://localhost:3002"}}, "localhost:3001,localhost:3002")
+	})
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Discover to stop with context.Canceled, got %v", err)
+	}
+}
+
+// TestDiscoveryHealthChecked tests that discovered endpoints are probed by the
+// balancer's health checker instead of joining alive
+func TestDiscoveryHealthChecked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	initial := newTestBackend(t, "http://localhost:3000")
+	hc := healthcheck.NewHealthChecker([]*backend.Backend{initial}, time.Hour)
+	lb, err := New([]*backend.Backend{initial}, WithHealthChecker(hc))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	if err := lb.applyEndpoints([]Endpoint{{URL: "http://localhost:3000"}, {URL: server.URL}}); err != nil {
+		t.Fatalf("applyEndpoints failed: %v", err)
+	}
+	discovered := lb.Backends()[1]
+	if discovered.HealthState() != backend.Unknown {
+		t.Errorf("Expected the discovered backend to wait for its first probe, got %v", discovered.HealthState())
+	}
+	if err := hc.CheckNow(discovered.URL.Host, false); err != nil {
+		t.Fatalf("Expected the checker to know the discovered backend: %v", err)
+	}
+	if !discovered.IsAlive() {
+		t.Error("Expected the probe to bring the discovered backend up")
+	}
+
+	if err := lb.applyEndpoints([]Endpoint{{URL: "http://localhost:3000"}}); err != nil {
+		t.Fatalf("applyEndpoints failed: %v", err)
+	}
+	if err := hc.CheckNow(discovered.URL.Host, false); err == nil {
+		t.Error("Expected the vanished endpoint unregistered from the health checker")
+	}
+}
+
+// TestSetBackends tests validation of pool replacements
+func TestSetBackends(t *testing.T) {
+	lb, err := New([]*backend.Backend{newTestBackend(t, "http://localhost:3000")})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	if err := lb.SetBackends(nil); !errors.Is(err, ErrNoBackends) {
+		t.Errorf("Expected ErrNoBackends for an empty pool, got %v", err)
+	}
+
+	duplicate := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+	if err := lb.SetBackends(duplicate); err == nil {
+		t.Error("Expected error for duplicate URLs")
+	}
+	if poolHosts(lb) != "localhost:3000" {
+		t.Errorf("Expected pool to be unchanged after rejected updates, got %q", poolHosts(lb))
+	}
+}
@@ -0,0 +1,133 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+)
+
+// TestDrain tests that a draining backend gets no new requests but finishes in-flight ones
+func TestDrain(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+
+	backends := []*backend.Backend{
+		newTestBackend(t, slow.URL),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	// Start a request on the slow backend and hold it open
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		done <- rec.Code
+	}()
+	for backends[0].ActiveConnections() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	backends[0].Drain()
+
+	t.Run("Draining Backend Not Selected", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			if selected == backends[0] {
+				t.Errorf("Request %d: draining backend was selected", i)
+			}
+		}
+		if len(lb.GetHealthyBackends()) != 2 {
+			t.Error("Expected the draining backend to still count as healthy")
+		}
+	})
+
+	t.Run("Wait Times Out While Busy", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+
+		if err := lb.WaitDrained(ctx, backends[0]); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+	})
+
+	t.Run("Wait Returns Once Drained", func(t *testing.T) {
+		close(release)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := lb.WaitDrained(ctx, backends[0]); err != nil {
+			t.Fatalf("WaitDrained failed: %v", err)
+		}
+		if code := <-done; code != http.StatusOK {
+			t.Errorf("Expected in-flight request to complete with 200, got %d", code)
+		}
+	})
+}
+
+// TestOutOfRotationPassesHealthChecks tests that a passing health check never puts a draining or disabled backend back into rotation
+func TestOutOfRotationPassesHealthChecks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		takeOut  func(*backend.Backend)
+		expected backend.EffectiveState
+	}{
+		{"Draining", func(b *backend.Backend) { b.Drain() }, backend.EffectiveDraining},
+		{"Disabled", func(b *backend.Backend) { b.SetEnabled(false) }, backend.EffectiveDisabled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backends := []*backend.Backend{
+				newTestBackend(t, server.URL),
+				newTestBackend(t, server.URL+"/other"),
+			}
+			lb, err := New(backends)
+			if err != nil {
+				t.Fatalf("Failed to create load balancer: %v", err)
+			}
+			hc := healthcheck.NewHealthChecker(backends, time.Second, healthcheck.WithThresholds(1, 1))
+
+			tt.takeOut(backends[0])
+			hc.CheckAllNow()
+
+			if state := backends[0].HealthState(); state != backend.Healthy {
+				t.Errorf("Expected the probe result to be recorded as healthy, got %v", state)
+			}
+			if state := backends[0].EffectiveState(); state != tt.expected {
+				t.Errorf("Expected effective state %v, got %v", tt.expected, state)
+			}
+			for i := 0; i < 20; i++ {
+				selected, err := lb.SelectBackend()
+				if err != nil {
+					t.Fatalf("SelectBackend failed: %v", err)
+				}
+				if selected == backends[0] {
+					t.Fatalf("Request %d: %s backend was selected", i, tt.expected)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package balancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+)
+
+// TestDisabledBackend tests that disabled backends leave rotation but keep being health checked
+func TestDisabledBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	backends := []*backend.Backend{
+		newTestBackend(t, server.URL),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+	backends[1].SetAlive(true)
+	backends[0].SetEnabled(false)
+
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	hc := healthcheck.NewHealthChecker(backends[:1], 20*time.Millisecond)
+	hc.Start()
+	defer hc.Stop()
+
+	t.Run("Disabled Backend Still Probed", func(t *testing.T) {
+		deadline := time.Now().Add(2 * time.Second)
+		for !backends[0].IsAlive() {
+			if time.Now().After(deadline) {
+				t.Fatal("Disabled backend was never health checked")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("Disabled Backend Not Selected", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			if selected == backends[0] {
+				t.Errorf("Request %d: disabled backend was selected", i)
+			}
+		}
+		if healthy := lb.GetHealthyBackends(); len(healthy) != 1 || healthy[0] != backends[1] {
+			t.Errorf("Expected only the enabled backend to be healthy, got %d backends", len(healthy))
+		}
+	})
+
+	t.Run("Re-enabled Backend Returns Instantly", func(t *testing.T) {
+		backends[0].SetEnabled(true)
+
+		seen := false
+		for i := 0; i < 4; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			seen = seen || selected == backends[0]
+		}
+		if !seen {
+			t.Error("Expected re-enabled backend to be selected")
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package balancer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by New, backend selection and pool changes. They may be wrapped, so
+// compare them with errors.Is.
+var (
+	// ErrNoBackends is returned by New when it is given an empty pool.
+	ErrNoBackends = errors.New("at least one backend is required")
+
+	// ErrAllBackendsOffline is returned when no backend is available for selection.
+	ErrAllBackendsOffline = errors.New("all backends are offline")
+
+	// ErrNoBackendsChecked is returned instead of ErrAllBackendsOffline, which
+	// it wraps, when no backend has been health checked yet, typically because
+	// traffic arrived before the checker's first probes finished. See
+	// healthcheck.HealthChecker.WaitForFirstCheck and WithSelectUnknown.
+	ErrNoBackendsChecked = fmt.Errorf("%w: no backends have passed a health check yet", ErrAllBackendsOffline)
+
+	// ErrAllBackendsRateLimited is returned when every available backend is at
+	// its declared request rate.
+	ErrAllBackendsRateLimited = errors.New("all backends are over their rate limit")
+
+	// ErrAllBackendsSaturated is returned when every available backend is at
+	// its MaxConnections cap. Callers should shed load rather than fail hard.
+	ErrAllBackendsSaturated = errors.New("all backends are at their connection limit")
+
+	// ErrLowPriorityShed is returned for low-priority requests that find
+	// every backend's spare capacity held for high-priority traffic when
+	// PriorityPolicy.Shed is set.
+	ErrLowPriorityShed = errors.New("low-priority request shed to keep capacity for high-priority traffic")
+
+	// ErrInsufficientHealthyBackends is returned when fewer backends are
+	// healthy than WithMinHealthy or WithMinHealthyFraction require, so the
+	// survivors are not overloaded.
+	ErrInsufficientHealthyBackends = errors.New("too few healthy backends to serve traffic")
+
+	// ErrNoMatchingBackends is returned when no backend in the pool carries
+	// the labels a selection asked for.
+	ErrNoMatchingBackends = errors.New("no backend matches the label selector")
+
+	// ErrBackendExists is returned when adding a backend whose URL is already
+	// in the pool.
+	ErrBackendExists = errors.New("backend already exists")
+
+	// ErrLastBackend is returned by RollingDrain when draining the next
+	// backend would leave none to serve traffic.
+	ErrLastBackend = errors.New("draining would leave no backend in rotation")
+
+	// ErrBackendNotFound is returned when no backend in the pool has the
+	// given host.
+	ErrBackendNotFound = errors.New("backend not found")
+)
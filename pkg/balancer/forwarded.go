@@ -0,0 +1,35 @@
+package balancer
+
+import (
+	"net"
+	"net/http"
+)
+
+// forwardedHeaders wraps a ReverseProxy Director so outbound requests tell
+// the backend who the client is and how it reached the balancer.
+//
+// X-Forwarded-For is extended by ReverseProxy itself after the Director runs,
+// appending the client address to any existing chain; when forwarded headers
+// are disabled the Director opts out of that too.
+func (lb *LoadBalancer) forwardedHeaders(director func(*http.Request)) func(*http.Request) {
+	return func(req *http.Request) {
+		host := req.Host
+		director(req)
+
+		if lb.noForwardedHeaders {
+			// A nil value stops ReverseProxy from adding X-Forwarded-For
+			req.Header["X-Forwarded-For"] = nil
+			return
+		}
+
+		if ip, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+			req.Header.Set("X-Real-IP", ip)
+		}
+		req.Header.Set("X-Forwarded-Host", host)
+		if req.TLS != nil {
+			req.Header.Set("X-Forwarded-Proto", "https")
+		} else {
+			req.Header.Set("X-Forwarded-Proto", "http")
+		}
+	}
+}
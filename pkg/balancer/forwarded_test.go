@@ -0,0 +1,106 @@
+package balancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestForwardedHeaders tests that the client address and original host reach the backend
+func TestForwardedHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	newLB := func(opts ...Option) *LoadBalancer {
+		b := newTestBackend(t, server.URL)
+		b.SetAlive(true)
+		lb, err := New([]*backend.Backend{b}, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		return lb
+	}
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "http://shop.example.com/cart", nil)
+		req.RemoteAddr = "203.0.113.7:51234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		return req
+	}
+
+	t.Run("Headers Injected", func(t *testing.T) {
+		newLB().ServeHTTP(httptest.NewRecorder(), newRequest())
+
+		expected := map[string]string{
+			"X-Forwarded-For":   "198.51.100.1, 203.0.113.7",
+			"X-Real-Ip":         "203.0.113.7",
+			"X-Forwarded-Host":  "shop.example.com",
+			"X-Forwarded-Proto": "http",
+		}
+		for name, value := range expected {
+			if got := received.Get(name); got != value {
+				t.Errorf("%s = %q, expected %q", name, got, value)
+			}
+		}
+	})
+
+	t.Run("Headers Disabled", func(t *testing.T) {
+		newLB(WithForwardedHeaders(false)).ServeHTTP(httptest.NewRecorder(), newRequest())
+
+		if got := received.Get("X-Forwarded-For"); got != "" {
+			t.Errorf("Expected no X-Forwarded-For, got %q", got)
+		}
+		for _, name := range []string{"X-Real-Ip", "X-Forwarded-Host", "X-Forwarded-Proto"} {
+			if got := received.Get(name); got != "" {
+				t.Errorf("Expected no %s, got %q", name, got)
+			}
+		}
+	})
+
+	t.Run("Re-added Backend Is Wrapped Once", func(t *testing.T) {
+		lb := newLB(WithResponseHeaders(HeaderRules{Add: map[string]string{"Via": "1.1 lb"}}))
+		b := lb.Backends()[0]
+		spare := newTestBackend(t, "http://localhost:3000")
+		if err := lb.AddBackend(spare); err != nil {
+			t.Fatalf("AddBackend failed: %v", err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := lb.RemoveBackend(b.URL.Host); err != nil {
+				t.Fatalf("RemoveBackend failed: %v", err)
+			}
+			if err := lb.AddBackend(b); err != nil {
+				t.Fatalf("AddBackend failed: %v", err)
+			}
+		}
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, newRequest())
+
+		if got := received.Get("X-Forwarded-For"); got != "198.51.100.1, 203.0.113.7" {
+			t.Errorf("Expected the client address appended once, got %q", got)
+		}
+		if got := rec.Result().Header.Values("Via"); len(got) != 1 {
+			t.Errorf("Expected the response header rules applied once, got Via %q", got)
+		}
+	})
+
+	t.Run("Forwarded Headers Win Over Backend Headers", func(t *testing.T) {
+		lb := newLB()
+		lb.Backends()[0].SetRequestHeaders(map[string]string{
+			"X-Forwarded-Proto": "https",
+			"X-Backend-Zone":    "us-east-1a",
+		}, true)
+		lb.ServeHTTP(httptest.NewRecorder(), newRequest())
+
+		if got := received.Get("X-Forwarded-Proto"); got != "http" {
+			t.Errorf("Expected the balancer's X-Forwarded-Proto, got %q", got)
+		}
+		if got := received.Get("X-Backend-Zone"); got != "us-east-1a" {
+			t.Errorf("Expected the backend's X-Backend-Zone, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package balancer
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// RegionHeader is the request header GeoLatency reads the client region from
+// when no custom region function is configured.
+const RegionHeader = "X-Client-Region"
+
+// GeoLatency routes each client to the available backend with the lowest
+// latency from the client's region. Requests from unknown regions, or whose
+// region has no latency data for any available backend, fall back to
+// round-robin.
+type GeoLatency struct {
+	mu        sync.RWMutex
+	latencies map[string]map[string]time.Duration // region → backend host → latency
+	region    func(r *http.Request) string
+	fallback  *RoundRobin
+}
+
+// NewGeoLatency creates a geo-latency strategy from a latency matrix keyed by
+// client region and then backend URL host. region derives the client region
+// from a request (e.g. via an IP geo lookup); nil reads RegionHeader.
+func NewGeoLatency(latencies map[string]map[string]time.Duration, region func(r *http.Request) string) *GeoLatency {
+	if region == nil {
+		region = func(r *http.Request) string {
+			return r.Header.Get(RegionHeader)
+		}
+	}
+
+	matrix := make(map[string]map[string]time.Duration, len(latencies))
+	for reg, hosts := range latencies {
+		row := make(map[string]time.Duration, len(hosts))
+		for host, d := range hosts {
+			row[host] = d
+		}
+		matrix[reg] = row
+	}
+
+	return &GeoLatency{
+		latencies: matrix,
+		region:    region,
+		fallback:  NewRoundRobin(),
+	}
+}
+
+// SetLatency records the latency between a client region and a backend host,
+// e.g. from a fresh RTT measurement.
+func (g *GeoLatency) SetLatency(region, host string, d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	row, ok := g.latencies[region]
+	if !ok {
+		row = make(map[string]time.Duration)
+		g.latencies[region] = row
+	}
+	row[host] = d
+}
+
+// Pick has no client region to go on, so it falls back to round-robin.
+func (g *GeoLatency) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	return g.fallback.Pick(backends, available)
+}
+
+// PickForRequest returns the nearest available backend for the request's region.
+func (g *GeoLatency) PickForRequest(r *http.Request, backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	g.mu.RLock()
+	row := g.latencies[g.region(r)]
+
+	var best *backend.Backend
+	var bestLatency time.Duration
+	for _, b := range backends {
+		latency, ok := row[b.URL.Host]
+		if !ok || !available(b) {
+			continue
+		}
+		if best == nil || latency < bestLatency {
+			best = b
+			bestLatency = latency
+		}
+	}
+	g.mu.RUnlock()
+
+	if best != nil {
+		return best, nil
+	}
+	return g.fallback.Pick(backends, available)
+}
@@ -0,0 +1,86 @@
+package balancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestGeoLatencyStrategy tests that clients are routed to the nearest alive backend for their region
+func TestGeoLatencyStrategy(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://us-east:3000"),
+		newTestBackend(t, "http://eu-west:3000"),
+		newTestBackend(t, "http://ap-south:3000"),
+	}
+
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	latencies := map[string]map[string]time.Duration{
+		"A": {"us-east:3000": 10 * time.Millisecond, "eu-west:3000": 80 * time.Millisecond, "ap-south:3000": 200 * time.Millisecond},
+		"B": {"us-east:3000": 150 * time.Millisecond, "eu-west:3000": 90 * time.Millisecond, "ap-south:3000": 20 * time.Millisecond},
+	}
+
+	lb, err := New(backends, WithStrategy(NewGeoLatency(latencies, nil)))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	requestFrom := func(region string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RegionHeader, region)
+		return req
+	}
+
+	t.Run("Nearest Backend Per Region", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			selected, err := lb.SelectBackendForRequest(requestFrom("A"))
+			if err != nil {
+				t.Fatalf("Selection failed: %v", err)
+			}
+			if selected != backends[0] {
+				t.Errorf("Region A: expected %s, got %s", backends[0].URL.Host, selected.URL.Host)
+			}
+
+			selected, err = lb.SelectBackendForRequest(requestFrom("B"))
+			if err != nil {
+				t.Fatalf("Selection failed: %v", err)
+			}
+			if selected != backends[2] {
+				t.Errorf("Region B: expected %s, got %s", backends[2].URL.Host, selected.URL.Host)
+			}
+		}
+	})
+
+	t.Run("Falls Back When Nearest Is Dead", func(t *testing.T) {
+		backends[0].SetAlive(false)
+		defer backends[0].SetAlive(true)
+
+		selected, err := lb.SelectBackendForRequest(requestFrom("A"))
+		if err != nil {
+			t.Fatalf("Selection failed: %v", err)
+		}
+		if selected != backends[1] {
+			t.Errorf("Expected next-nearest %s, got %s", backends[1].URL.Host, selected.URL.Host)
+		}
+	})
+
+	t.Run("Unknown Region Uses Round Robin", func(t *testing.T) {
+		served := make(map[*backend.Backend]bool)
+		for i := 0; i < 3; i++ {
+			selected, err := lb.SelectBackendForRequest(requestFrom("unknown"))
+			if err != nil {
+				t.Fatalf("Selection failed: %v", err)
+			}
+			served[selected] = true
+		}
+		if len(served) != 3 {
+			t.Errorf("Expected round-robin across 3 backends, got %d", len(served))
+		}
+	})
+}
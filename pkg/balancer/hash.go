@@ -0,0 +1,116 @@
+package balancer
+
+import (
+	"hash/fnv"
+	"net/http"
+	"strings"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// KeyFunc extracts an affinity key from a request. An empty key means the
+// request carries nothing usable to route on.
+type KeyFunc func(r *http.Request) string
+
+// HeaderKey uses the value of the named request header as the key.
+func HeaderKey(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// URLKey uses the full request URL (host, path and query) as the key.
+func URLKey(r *http.Request) string {
+	return r.Host + r.URL.RequestURI()
+}
+
+// CompositeKey joins the keys produced by parts. It is empty only when every
+// part is empty.
+func CompositeKey(parts ...KeyFunc) KeyFunc {
+	return func(r *http.Request) string {
+		keys := make([]string, len(parts))
+		empty := true
+		for i, part := range parts {
+			keys[i] = part(r)
+			if keys[i] != "" {
+				empty = false
+			}
+		}
+		if empty {
+			return ""
+		}
+		return strings.Join(keys, "|")
+	}
+}
+
+// KeyHash routes requests with the same key to the same available backend by
+// hashing the key over the available backends. Requests without a key use
+// the fallback key if one is configured, otherwise round-robin.
+type KeyHash struct {
+	key         KeyFunc
+	fallbackKey KeyFunc
+	fallback    *RoundRobin
+}
+
+// NewKeyHash creates a hash strategy keyed by key. fallbackKey, which may be
+// nil, is consulted for requests where key is empty so that keyless requests
+// still route deterministically (e.g. URLKey).
+func NewKeyHash(key, fallbackKey KeyFunc) *KeyHash {
+	return &KeyHash{
+		key:         key,
+		fallbackKey: fallbackKey,
+		fallback:    NewRoundRobin(),
+	}
+}
+
+// Pick has no request to take a key from, so it falls back to round-robin.
+func (h *KeyHash) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	return h.fallback.Pick(backends, available)
+}
+
+// PickForRequest hashes the request's key over the available backends.
+func (h *KeyHash) PickForRequest(r *http.Request, backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	key := h.key(r)
+	if key == "" && h.fallbackKey != nil {
+		key = h.fallbackKey(r)
+	}
+	return h.PickForKey(key, backends, available)
+}
+
+// PickForKey hashes key over the available backends. An empty key falls back
+// to round-robin.
+func (h *KeyHash) PickForKey(key string, backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	if key == "" {
+		return h.fallback.Pick(backends, available)
+	}
+	return pickByHash(hashKey(key), backends, available)
+}
+
+// pickByHash maps hash onto the available subset of backends.
+func pickByHash(hash uint64, backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	alive := make([]*backend.Backend, 0, len(backends))
+	for _, b := range backends {
+		if available(b) {
+			alive = append(alive, b)
+		}
+	}
+	if len(alive) == 0 {
+		return nil, ErrAllBackendsOffline
+	}
+	return alive[hash%uint64(len(alive))], nil
+}
+
+// hashKey returns a well-mixed 64-bit hash of key. FNV-1a alone clusters
+// keys that differ only in their last bytes (like "host#1", "host#2"), so its
+// output is run through the murmur3 finalizer.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,98 @@
+package balancer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestKeyHashFallbackKey tests that requests without the primary key still route deterministically
+func TestKeyHashFallbackKey(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	const sessionHeader = "X-Session-ID"
+
+	t.Run("Keyless Requests Route By URL", func(t *testing.T) {
+		lb, err := New(backends, WithStrategy(NewKeyHash(HeaderKey(sessionHeader), URLKey)))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		served := make(map[*backend.Backend]bool)
+		for i := 0; i < 20; i++ {
+			path := fmt.Sprintf("/items/%d?page=2", i)
+			first, err := lb.SelectBackendForRequest(httptest.NewRequest(http.MethodGet, path, nil))
+			if err != nil {
+				t.Fatalf("Selection failed: %v", err)
+			}
+			served[first] = true
+
+			for j := 0; j < 50; j++ {
+				selected, err := lb.SelectBackendForRequest(httptest.NewRequest(http.MethodGet, path, nil))
+				if err != nil {
+					t.Fatalf("Selection failed: %v", err)
+				}
+				if selected != first {
+					t.Fatalf("%s: routed to %s, previously %s", path, selected.URL.Host, first.URL.Host)
+				}
+			}
+		}
+
+		if len(served) < 2 {
+			t.Errorf("Expected different URLs to spread across backends, got %d backend(s)", len(served))
+		}
+	})
+
+	t.Run("Primary Key Takes Precedence", func(t *testing.T) {
+		lb, err := New(backends, WithStrategy(NewKeyHash(HeaderKey(sessionHeader), URLKey)))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		var first *backend.Backend
+		for i := 0; i < 20; i++ {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/items/%d", i), nil)
+			req.Header.Set(sessionHeader, "session-42")
+			selected, err := lb.SelectBackendForRequest(req)
+			if err != nil {
+				t.Fatalf("Selection failed: %v", err)
+			}
+			if first == nil {
+				first = selected
+			}
+			if selected != first {
+				t.Fatalf("Session routed to %s, previously %s", selected.URL.Host, first.URL.Host)
+			}
+		}
+	})
+
+	t.Run("No Fallback Key Uses Round Robin", func(t *testing.T) {
+		lb, err := New(backends, WithStrategy(NewKeyHash(HeaderKey(sessionHeader), nil)))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		served := make(map[*backend.Backend]bool)
+		for i := 0; i < 3; i++ {
+			selected, err := lb.SelectBackendForRequest(httptest.NewRequest(http.MethodGet, "/same", nil))
+			if err != nil {
+				t.Fatalf("Selection failed: %v", err)
+			}
+			served[selected] = true
+		}
+		if len(served) != 3 {
+			t.Errorf("Expected keyless requests to rotate across 3 backends, got %d", len(served))
+		}
+	})
+}
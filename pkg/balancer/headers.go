@@ -0,0 +1,58 @@
+package balancer
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// HeaderRules rewrites the headers of backend responses before they reach
+// the client. Remove runs first, then Set, then Add.
+type HeaderRules struct {
+	// Remove lists headers to delete, e.g. "Server" or "X-Internal-Trace".
+	Remove []string
+	// Set replaces any values the backend sent, e.g. for
+	// "Strict-Transport-Security".
+	Set map[string]string
+	// Add appends a value, keeping any the backend sent.
+	Add map[string]string
+}
+
+// apply rewrites h according to the rules.
+func (hr *HeaderRules) apply(h http.Header) {
+	for _, key := range hr.Remove {
+		h.Del(key)
+	}
+	for key, value := range hr.Set {
+		h.Set(key, value)
+	}
+	for key, value := range hr.Add {
+		h.Add(key, value)
+	}
+}
+
+// clone copies the rules so later changes by the caller don't affect them.
+func (hr HeaderRules) clone() HeaderRules {
+	return HeaderRules{
+		Remove: slices.Clone(hr.Remove),
+		Set:    maps.Clone(hr.Set),
+		Add:    maps.Clone(hr.Add),
+	}
+}
+
+// rewriteResponseHeaders wraps a ReverseProxy ModifyResponse so responses
+// pass through the WithResponseHeaders rules.
+func (lb *LoadBalancer) rewriteResponseHeaders(modify func(*http.Response) error) func(*http.Response) error {
+	if lb.responseHeaders == nil {
+		return modify
+	}
+	return func(res *http.Response) error {
+		if modify != nil {
+			if err := modify(res); err != nil {
+				return err
+			}
+		}
+		lb.responseHeaders.apply(res.Header)
+		return nil
+	}
+}
@@ -0,0 +1,54 @@
+package balancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestResponseHeaders tests that backend response headers are removed, set and added
+func TestResponseHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "backend-v1")
+		w.Header().Set("X-Internal-Trace", "abc123")
+		w.Header().Set("Strict-Transport-Security", "max-age=60")
+		w.Header().Set("Vary", "Accept")
+		w.Header().Set("Content-Type", "text/plain")
+	}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	b.SetAlive(true)
+	rules := HeaderRules{
+		Remove: []string{"server", "X-Internal-Trace"},
+		Set:    map[string]string{"Strict-Transport-Security": "max-age=31536000"},
+		Add:    map[string]string{"Vary": "Origin"},
+	}
+	lb, err := New([]*backend.Backend{b}, WithResponseHeaders(rules))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	rules.Remove = nil
+
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	h := rec.Header()
+
+	for _, key := range []string{"Server", "X-Internal-Trace"} {
+		if v := h.Get(key); v != "" {
+			t.Errorf("Expected %s to be removed, got %q", key, v)
+		}
+	}
+	if v := h.Values("Strict-Transport-Security"); !slices.Equal(v, []string{"max-age=31536000"}) {
+		t.Errorf("Expected Strict-Transport-Security to be replaced, got %q", v)
+	}
+	if v := h.Values("Vary"); !slices.Equal(v, []string{"Accept", "Origin"}) {
+		t.Errorf("Expected Vary to be appended to, got %q", v)
+	}
+	if v := h.Get("Content-Type"); v != "text/plain" {
+		t.Errorf("Expected other headers untouched, got Content-Type %q", v)
+	}
+}
@@ -0,0 +1,59 @@
+package balancer
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// MostHeadroom routes to the available backend with the most spare capacity,
+// MaxConnections minus ActiveConnections. Unlike least-connections, a large
+// backend carrying some load is still preferred over a small one near its
+// limit. Backends without a MaxConnections cap count as having unlimited
+// headroom and are compared by their active connections instead. Ties rotate
+// so equal backends share the traffic.
+type MostHeadroom struct {
+	next atomic.Uint64
+}
+
+// NewMostHeadroom creates a most-headroom strategy.
+func NewMostHeadroom() *MostHeadroom {
+	return &MostHeadroom{}
+}
+
+// Pick returns the available backend with the most remaining capacity.
+func (m *MostHeadroom) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	n := uint64(len(backends))
+	if n == 0 {
+		return nil, ErrAllBackendsOffline
+	}
+	start := m.next.Add(1) - 1
+
+	var best *backend.Backend
+	var bestHeadroom, bestActive int64
+	for offset := uint64(0); offset < n; offset++ {
+		b := backends[(start+offset)%n]
+		if !available(b) {
+			continue
+		}
+		room, active := headroom(b), b.ActiveConnections()
+		if best == nil || room > bestHeadroom || (room == bestHeadroom && active < bestActive) {
+			best, bestHeadroom, bestActive = b, room, active
+		}
+	}
+
+	if best == nil {
+		return nil, ErrAllBackendsOffline
+	}
+	return best, nil
+}
+
+// headroom returns how many more concurrent requests b can take.
+func headroom(b *backend.Backend) int64 {
+	limit := b.MaxConnections()
+	if limit == 0 {
+		return math.MaxInt64
+	}
+	return int64(limit) - b.ActiveConnections()
+}
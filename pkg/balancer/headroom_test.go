@@ -0,0 +1,86 @@
+package balancer
+
+import (
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestMostHeadroom tests that the backend with the most spare capacity is chosen
+func TestMostHeadroom(t *testing.T) {
+	newLoaded := func(url string, maxConns, active int) *backend.Backend {
+		b := newTestBackend(t, url)
+		b.SetAlive(true)
+		b.SetMaxConnections(maxConns)
+		for i := 0; i < active; i++ {
+			b.IncrementConnections()
+		}
+		return b
+	}
+
+	t.Run("Large Backend Under Load Beats Small Idle One", func(t *testing.T) {
+		backends := []*backend.Backend{
+			newLoaded("http://localhost:3000", 10, 0),    // headroom 10
+			newLoaded("http://localhost:3001", 200, 150), // headroom 50
+			newLoaded("http://localhost:3002", 50, 20),   // headroom 30
+		}
+		lb, err := New(backends, WithStrategy(NewMostHeadroom()))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		for i := 0; i < 5; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			if selected != backends[1] {
+				t.Errorf("Request %d: expected %s, got %s", i, backends[1].URL, selected.URL)
+			}
+		}
+	})
+
+	t.Run("Unlimited Backend Preferred", func(t *testing.T) {
+		backends := []*backend.Backend{
+			newLoaded("http://localhost:3000", 1000, 0),
+			newLoaded("http://localhost:3001", 0, 500),
+		}
+		lb, err := New(backends, WithStrategy(NewMostHeadroom()))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		selected, err := lb.SelectBackend()
+		if err != nil {
+			t.Fatalf("SelectBackend failed: %v", err)
+		}
+		if selected != backends[1] {
+			t.Errorf("Expected unlimited backend %s, got %s", backends[1].URL, selected.URL)
+		}
+	})
+
+	t.Run("Ties Rotate", func(t *testing.T) {
+		backends := []*backend.Backend{
+			newLoaded("http://localhost:3000", 0, 0),
+			newLoaded("http://localhost:3001", 0, 0),
+		}
+		lb, err := New(backends, WithStrategy(NewMostHeadroom()))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		count := make(map[*backend.Backend]int)
+		for i := 0; i < 10; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			count[selected]++
+		}
+		for i, b := range backends {
+			if count[b] != 5 {
+				t.Errorf("Backend %d: got %d requests, expected 5", i, count[b])
+			}
+		}
+	})
+}
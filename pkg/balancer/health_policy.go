@@ -0,0 +1,80 @@
+package balancer
+
+import (
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// defaultPassiveHealthTTL is how long a passive outcome counts as evidence
+// before the backend falls back to its active health check result.
+const defaultPassiveHealthTTL = 30 * time.Second
+
+// HealthPolicy resolves conflicts between the active health check and the
+// passive signal observed on proxied traffic.
+type HealthPolicy int
+
+const (
+	// PreferActive trusts the active health check only (the default).
+	PreferActive HealthPolicy = iota
+	// PreferPassive trusts a fresh passive outcome over the active check.
+	PreferPassive
+	// RequireBoth needs the active check and any fresh passive outcome to agree
+	// the backend is healthy.
+	RequireBoth
+)
+
+// String returns the policy name for logs.
+func (p HealthPolicy) String() string {
+	switch p {
+	case PreferActive:
+		return "prefer-active"
+	case PreferPassive:
+		return "prefer-passive"
+	case RequireBoth:
+		return "require-both"
+	default:
+		return "unknown"
+	}
+}
+
+// Resolve combines the two signals into the effective availability.
+// passiveKnown is false when there is no fresh passive outcome, in which case
+// every policy falls back to the active result.
+func (p HealthPolicy) Resolve(active, passive, passiveKnown bool) bool {
+	if !passiveKnown {
+		return active
+	}
+
+	switch p {
+	case PreferPassive:
+		return passive
+	case RequireBoth:
+		return active && passive
+	default:
+		return active
+	}
+}
+
+// isHealthy applies the balancer's health policy to b.
+func (lb *LoadBalancer) isHealthy(b *backend.Backend) bool {
+	active := b.IsAlive() || lb.selectUnknown && b.HealthState() == backend.Unknown
+	if lb.healthPolicy == PreferActive {
+		return active
+	}
+
+	passive, at := b.PassiveHealth()
+	passiveKnown := !at.IsZero() && lb.now().Sub(at) < lb.passiveHealthTTL
+	return lb.healthPolicy.Resolve(active, passive, passiveKnown)
+}
+
+// warnUnknown logs the first time traffic is sent to b before it has been
+// health checked.
+func (lb *LoadBalancer) warnUnknown(b *backend.Backend) {
+	if b.HealthState() != backend.Unknown {
+		return
+	}
+	if _, warned := lb.unknownWarned.LoadOrStore(b, struct{}{}); !warned {
+		lb.logger.Warn("routing to a backend that has not been health checked yet", "backend", b.URL.Host)
+	}
+}
@@ -0,0 +1,90 @@
+package balancer
+
+import (
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestHealthPolicyResolve tests each policy against conflicting active and passive signals
+func TestHealthPolicyResolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		active  bool
+		passive bool
+		want    map[HealthPolicy]bool
+	}{
+		{"Active Alive Passive Failing", true, false, map[HealthPolicy]bool{
+			PreferActive: true, PreferPassive: false, RequireBoth: false,
+		}},
+		{"Active Dead Passive Healthy", false, true, map[HealthPolicy]bool{
+			PreferActive: false, PreferPassive: true, RequireBoth: false,
+		}},
+		{"Both Healthy", true, true, map[HealthPolicy]bool{
+			PreferActive: true, PreferPassive: true, RequireBoth: true,
+		}},
+		{"Both Failing", false, false, map[HealthPolicy]bool{
+			PreferActive: false, PreferPassive: false, RequireBoth: false,
+		}},
+	}
+
+	for _, tt := range tests {
+		for policy, want := range tt.want {
+			t.Run(tt.name+"/"+policy.String(), func(t *testing.T) {
+				if got := policy.Resolve(tt.active, tt.passive, true); got != want {
+					t.Errorf("Resolve(active=%v, passive=%v) = %v, want %v", tt.active, tt.passive, got, want)
+				}
+				// Without a fresh passive outcome every policy follows the active check
+				if got := policy.Resolve(tt.active, tt.passive, false); got != tt.active {
+					t.Errorf("Resolve without passive data = %v, want %v", got, tt.active)
+				}
+			})
+		}
+	}
+}
+
+// TestHealthPolicySelection tests that the effective availability drives selection
+func TestHealthPolicySelection(t *testing.T) {
+	newPair := func() []*backend.Backend {
+		backends := []*backend.Backend{
+			newTestBackend(t, "http://localhost:3000"),
+			newTestBackend(t, "http://localhost:3001"),
+		}
+		// Backend 0: probe says alive, traffic is failing
+		backends[0].SetAlive(true)
+		backends[0].ReportPassive(false)
+		// Backend 1: probe says dead, traffic is succeeding
+		backends[1].SetAlive(false)
+		backends[1].ReportPassive(true)
+		return backends
+	}
+
+	tests := []struct {
+		policy    HealthPolicy
+		wantAlive []bool
+	}{
+		{PreferActive, []bool{true, false}},
+		{PreferPassive, []bool{false, true}},
+		{RequireBoth, []bool{false, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			backends := newPair()
+			lb, err := New(backends, WithHealthPolicy(tt.policy))
+			if err != nil {
+				t.Fatalf("Failed to create load balancer: %v", err)
+			}
+
+			healthy := make(map[*backend.Backend]bool)
+			for _, b := range lb.GetHealthyBackends() {
+				healthy[b] = true
+			}
+			for i, b := range backends {
+				if healthy[b] != tt.wantAlive[i] {
+					t.Errorf("Backend %d: effective availability %v, want %v", i, healthy[b], tt.wantAlive[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package balancer
+
+import (
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// healthySnapshot caches the result of GetHealthyBackends. It stays valid
+// while the pool and every backend's state are unchanged and, if some backend
+// is ejected, until the first of those ejections ends.
+type healthySnapshot struct {
+	backends   []*backend.Backend
+	pool       *[]*backend.Backend
+	generation uint64
+	expires    time.Time // zero if no ejection is pending
+}
+
+// GetHealthyBackends returns only the backends that are currently available
+// for selection under the balancer's health policy. Disabled backends are
+// left out even if they are healthy. With none available it returns an empty,
+// non-nil slice.
+//
+// Under the default PreferActive policy the result is cached and only rebuilt
+// after a health transition or pool change, so repeated calls neither lock
+// nor allocate. The returned slice is shared and must not be modified.
+func (lb *LoadBalancer) GetHealthyBackends() []*backend.Backend {
+	// Passive outcomes change on every request; there is nothing to cache
+	if lb.healthPolicy != PreferActive {
+		return lb.collectHealthyBackends()
+	}
+
+	pool := lb.backends.Load()
+	generation := backend.StateGeneration()
+	if snap := lb.healthy.Load(); snap != nil && snap.pool == pool && snap.generation == generation &&
+		(snap.expires.IsZero() || lb.now().Before(snap.expires)) {
+		return snap.backends
+	}
+
+	// generation was read before the backends, so a transition racing with
+	// the rebuild leaves a snapshot that is already stale, never a wrong one
+	snap := &healthySnapshot{
+		backends:   lb.collectHealthyBackends(),
+		pool:       pool,
+		generation: generation,
+	}
+	now := lb.now()
+	for _, b := range *pool {
+		if until := b.EjectedUntil(); until.After(now) && (snap.expires.IsZero() || until.Before(snap.expires)) {
+			snap.expires = until
+		}
+	}
+	lb.healthy.Store(snap)
+	return snap.backends
+}
+
+// collectHealthyBackends scans the pool for available backends.
+func (lb *LoadBalancer) collectHealthyBackends() []*backend.Backend {
+	backends := lb.Backends()
+	healthy := make([]*backend.Backend, 0, len(backends))
+	for _, b := range backends {
+		if lb.isAvailable(b) {
+			healthy = append(healthy, b)
+		}
+	}
+	return healthy
+}
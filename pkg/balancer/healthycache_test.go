@@ -0,0 +1,115 @@
+package balancer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+)
+
+// TestHealthyBackendsCache tests that the cached healthy set follows every kind of state change
+func TestHealthyBackendsCache(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+	clk := clock.NewFake(time.Now())
+	lb, err := New(backends, WithClock(clk))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	expectHealthy := func(t *testing.T, want int) {
+		t.Helper()
+		if got := len(lb.GetHealthyBackends()); got != want {
+			t.Errorf("Expected %d healthy backends, got %d", want, got)
+		}
+	}
+
+	t.Run("Cached Calls Don't Allocate", func(t *testing.T) {
+		expectHealthy(t, 3)
+		if allocs := testing.AllocsPerRun(100, func() { lb.GetHealthyBackends() }); allocs != 0 {
+			t.Errorf("Expected no allocations, got %.1f per call", allocs)
+		}
+	})
+
+	t.Run("Health Transition", func(t *testing.T) {
+		backends[0].SetAlive(false)
+		expectHealthy(t, 2)
+		backends[0].SetAlive(true)
+		expectHealthy(t, 3)
+	})
+
+	t.Run("Disabled Backend", func(t *testing.T) {
+		backends[1].SetEnabled(false)
+		expectHealthy(t, 2)
+		backends[1].SetEnabled(true)
+		expectHealthy(t, 3)
+	})
+
+	t.Run("Ejection Expires", func(t *testing.T) {
+		backends[2].Eject(time.Minute)
+		expectHealthy(t, 2)
+		clk.Advance(time.Minute)
+		expectHealthy(t, 3)
+	})
+
+	t.Run("Pool Change", func(t *testing.T) {
+		added := newTestBackend(t, "http://localhost:3003")
+		added.SetAlive(true)
+		if err := lb.AddBackend(added); err != nil {
+			t.Fatalf("AddBackend failed: %v", err)
+		}
+		expectHealthy(t, 4)
+	})
+
+	t.Run("None Healthy Is Empty Not Nil", func(t *testing.T) {
+		for _, b := range lb.Backends() {
+			b.SetAlive(false)
+		}
+		if healthy := lb.GetHealthyBackends(); healthy == nil || len(healthy) != 0 {
+			t.Errorf("Expected an empty non-nil slice, got %#v", healthy)
+		}
+		if healthy := lb.GetHealthyBackendsInTier(0); healthy == nil || len(healthy) != 0 {
+			t.Errorf("Expected an empty non-nil tier, got %#v", healthy)
+		}
+	})
+}
+
+// BenchmarkGetHealthyBackends compares scanning the pool on every call with the cached set
+func BenchmarkGetHealthyBackends(b *testing.B) {
+	for _, size := range []int{10, 100} {
+		backends := make([]*backend.Backend, size)
+		for i := range backends {
+			be, err := backend.NewBackend(fmt.Sprintf("http://10.0.0.%d:8080", i))
+			if err != nil {
+				b.Fatalf("Failed to create backend: %v", err)
+			}
+			be.SetAlive(i%4 != 0)
+			backends[i] = be
+		}
+		lb, err := New(backends)
+		if err != nil {
+			b.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		b.Run(fmt.Sprintf("Uncached/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				lb.collectHealthyBackends()
+			}
+		})
+		b.Run(fmt.Sprintf("Cached/%d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				lb.GetHealthyBackends()
+			}
+		})
+	}
+}
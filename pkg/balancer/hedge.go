@@ -0,0 +1,258 @@
+package balancer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// HedgePolicy configures request hedging, which trades extra backend load
+// for lower tail latency on read-heavy endpoints.
+type HedgePolicy struct {
+	// Delay is how long the first backend has to respond before the request
+	// is also sent to a second one, e.g. the backends' p95 latency.
+	Delay time.Duration
+
+	// Methods lists the HTTP methods that may be hedged; nil means GET and
+	// HEAD. Requests that aren't safe to replay are never hedged, whatever
+	// their method.
+	Methods []string
+
+	// PathPrefixes limits hedging to paths with one of these prefixes; nil
+	// means every path.
+	PathPrefixes []string
+
+	// MaxBuffer is how much of a response body is held back while the
+	// attempts race; zero means DefaultHedgeBuffer. The first response to
+	// outgrow it wins the race and is streamed to the client from there.
+	MaxBuffer int
+}
+
+// DefaultHedgeBuffer is how much of each racing response is buffered unless
+// HedgePolicy.MaxBuffer says otherwise.
+const DefaultHedgeBuffer = 1 << 20
+
+// errHedgeLost aborts an attempt whose response is too large to buffer after
+// another attempt already won the race.
+var errHedgeLost = errors.New("hedged attempt lost the race")
+
+// applies reports whether r matches the policy's methods and paths.
+func (p *HedgePolicy) applies(r *http.Request) bool {
+	methods := p.Methods
+	if methods == nil {
+		methods = []string{http.MethodGet, http.MethodHead}
+	}
+	if !slices.Contains(methods, r.Method) {
+		return false
+	}
+	if p.PathPrefixes == nil {
+		return true
+	}
+	return slices.ContainsFunc(p.PathPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(r.URL.Path, prefix)
+	})
+}
+
+// hedgeResult is the outcome of one of the racing attempts.
+type hedgeResult struct {
+	backend *backend.Backend
+	resp    *bufferedResponse
+	attempt *proxyAttempt
+}
+
+// serveHedged races the request against up to two backends. Responses are
+// buffered so the loser can be discarded, up to HedgePolicy.MaxBuffer: a
+// response that outgrows it wins and is streamed to the client instead.
+// Sticky sessions are not consulted.
+func (lb *LoadBalancer) serveHedged(w http.ResponseWriter, r *http.Request, body []byte, subset func(*backend.Backend) bool) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel() // abandons whichever attempt is still running
+
+	limit := lb.hedging.MaxBuffer
+	if limit <= 0 {
+		limit = DefaultHedgeBuffer
+	}
+	claim := &hedgeClaim{client: w}
+	results := make(chan hedgeResult, 2)
+	launch := func(b *backend.Backend) {
+		out := r.WithContext(ctx)
+		if body != nil {
+			out.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		go func() {
+			attemptCtx, cancel := lb.attemptContext(ctx)
+			defer cancel()
+			out := out.WithContext(attemptCtx)
+			res := hedgeResult{backend: b, resp: newBufferedResponse(claim, limit)}
+			defer func() {
+				// The ReverseProxy aborts when a body fails mid-copy; since
+				// nothing reached the client yet, that is just a failed attempt
+				if rec := recover(); rec != nil {
+					res.attempt = &proxyAttempt{err: fmt.Errorf("response aborted: %v", rec)}
+				}
+				results <- res
+			}()
+			res.attempt = lb.proxyTo(b, &committedWriter{ResponseWriter: res.resp}, out)
+		}()
+	}
+
+	primary, err := lb.reserveBackend(r.Context(), r, subset)
+	if err != nil {
+		lb.renderError(w, r, selectionError(err))
+		return
+	}
+	launch(primary)
+	pending, hedged, allTimedOut := 1, false, true
+
+	hedge := func() {
+		hedged = true
+		others := func(b *backend.Backend) bool { return b != primary && (subset == nil || subset(b)) }
+		b, err := lb.reserveBackend(r.Context(), r, others)
+		if err != nil {
+			return // nothing to hedge to; keep waiting on the primary
+		}
+		launch(b)
+		pending++
+	}
+
+	timer := time.NewTimer(lb.hedging.Delay)
+	defer timer.Stop()
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if !hedged && !claim.taken() {
+				hedge()
+			}
+		case res := <-results:
+			pending--
+			noteBackend(r, res.backend)
+			if r.Context().Err() == nil {
+				res.backend.ReportPassive(res.attempt.err == nil)
+			}
+			if res.resp.streaming {
+				// Its response has been going straight to the client; a
+				// failure now can only cut it off, as proxyTo would
+				if res.attempt.err != nil {
+					panic(http.ErrAbortHandler)
+				}
+				return
+			}
+			if res.attempt.err == nil {
+				if claim.take(res.resp) {
+					res.resp.writeTo(w)
+					return
+				}
+				continue // lost to a response being streamed
+			}
+			allTimedOut = allTimedOut && res.attempt.timedOut
+			lb.logger.Warn("hedged attempt failed",
+				"method", r.Method, "path", r.URL.Path, "backend", res.backend.URL.Host, "error", res.attempt.err)
+			// Don't wait out the delay once the first backend has failed
+			if !hedged && !claim.taken() {
+				hedge()
+			}
+		}
+	}
+
+	lb.renderError(w, r, attemptsFailedError(allTimedOut, true))
+}
+
+// hedgeClaim records which racing attempt gets to write to the client.
+type hedgeClaim struct {
+	client http.ResponseWriter
+	mu     sync.Mutex
+	winner *bufferedResponse
+}
+
+// take makes br the winner unless another attempt already is, and reports
+// whether br is the winner.
+func (c *hedgeClaim) take(br *bufferedResponse) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.winner == nil {
+		c.winner = br
+	}
+	return c.winner == br
+}
+
+// taken reports whether an attempt has won.
+func (c *hedgeClaim) taken() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.winner != nil
+}
+
+// bufferedResponse collects a response in memory so it can be written to the
+// client later, or dropped. A body that grows past limit can't wait: the
+// response claims the client and streams to it from then on, or, if another
+// attempt has already won, is aborted.
+type bufferedResponse struct {
+	header    http.Header
+	status    int
+	body      bytes.Buffer
+	limit     int
+	claim     *hedgeClaim
+	streaming bool // writing straight to claim.client
+}
+
+func newBufferedResponse(claim *hedgeClaim, limit int) *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header), claim: claim, limit: limit}
+}
+
+func (br *bufferedResponse) Header() http.Header {
+	return br.header
+}
+
+func (br *bufferedResponse) WriteHeader(code int) {
+	// Informational responses can't be replayed later; keep the final one
+	if br.status == 0 && code >= http.StatusOK {
+		br.status = code
+	}
+}
+
+func (br *bufferedResponse) Write(p []byte) (int, error) {
+	if br.streaming {
+		return br.claim.client.Write(p)
+	}
+	if br.status == 0 {
+		br.status = http.StatusOK
+	}
+	if br.body.Len()+len(p) <= br.limit {
+		return br.body.Write(p)
+	}
+
+	if !br.claim.take(br) {
+		return 0, errHedgeLost
+	}
+	br.streaming = true
+	br.writeTo(br.claim.client)
+	return br.claim.client.Write(p)
+}
+
+// Flush passes flushes on to the client once the response is streaming.
+func (br *bufferedResponse) Flush() {
+	if br.streaming {
+		http.NewResponseController(br.claim.client).Flush()
+	}
+}
+
+// writeTo sends the buffered response to w.
+func (br *bufferedResponse) writeTo(w http.ResponseWriter) {
+	for key, values := range br.header {
+		w.Header()[key] = values
+	}
+	if br.status == 0 {
+		br.status = http.StatusOK
+	}
+	w.WriteHeader(br.status)
+	w.Write(br.body.Bytes())
+}
@@ -0,0 +1,159 @@
+package balancer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestHedging tests that slow requests are hedged to a second backend and the loser is cancelled
+func TestHedging(t *testing.T) {
+	var slowCancelled atomic.Bool
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(300 * time.Millisecond):
+			io.WriteString(w, "slow")
+		case <-r.Context().Done():
+			slowCancelled.Store(true)
+		}
+	}))
+	defer slow.Close()
+	fast := newNamedServer(t, "fast")
+
+	// newLB returns a balancer whose round-robin sends the first request to the slow backend
+	newLB := func(opts ...Option) *LoadBalancer {
+		backends := []*backend.Backend{newTestBackend(t, slow.URL), newTestBackend(t, fast.URL)}
+		for _, b := range backends {
+			b.SetAlive(true)
+		}
+		lb, err := New(backends, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		return lb
+	}
+	serve := func(lb *LoadBalancer, req *http.Request) (*httptest.ResponseRecorder, time.Duration) {
+		rec := httptest.NewRecorder()
+		start := time.Now()
+		lb.ServeHTTP(rec, req)
+		return rec, time.Since(start)
+	}
+	policy := HedgePolicy{Delay: 50 * time.Millisecond, PathPrefixes: []string{"/reads"}}
+
+	t.Run("Disabled By Default", func(t *testing.T) {
+		rec, _ := serve(newLB(), httptest.NewRequest(http.MethodGet, "/reads/1", nil))
+		if rec.Body.String() != "slow" {
+			t.Errorf("Expected the unhedged request to wait for the slow backend, got %q", rec.Body)
+		}
+	})
+
+	t.Run("Hedge Wins", func(t *testing.T) {
+		slowCancelled.Store(false)
+		rec, elapsed := serve(newLB(WithHedging(policy)), httptest.NewRequest(http.MethodGet, "/reads/1", nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != "fast" {
+			t.Fatalf("Expected the hedged backend's response, got %d %q", rec.Code, rec.Body)
+		}
+		if elapsed > 200*time.Millisecond {
+			t.Errorf("Expected hedging to cut latency, took %v", elapsed)
+		}
+		deadline := time.Now().Add(time.Second)
+		for !slowCancelled.Load() && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if !slowCancelled.Load() {
+			t.Error("Expected the losing request to be cancelled")
+		}
+	})
+
+	t.Run("Ineligible Path", func(t *testing.T) {
+		rec, _ := serve(newLB(WithHedging(policy)), httptest.NewRequest(http.MethodGet, "/writes/1", nil))
+		if rec.Body.String() != "slow" {
+			t.Errorf("Expected a path outside the policy not to be hedged, got %q", rec.Body)
+		}
+	})
+
+	t.Run("Non-Idempotent Method", func(t *testing.T) {
+		lb := newLB(WithHedging(HedgePolicy{Delay: 50 * time.Millisecond, Methods: []string{http.MethodPost}}))
+		rec, _ := serve(lb, httptest.NewRequest(http.MethodPost, "/reads/1", strings.NewReader("x")))
+		if rec.Body.String() != "slow" {
+			t.Errorf("Expected a POST without an idempotency key not to be hedged, got %q", rec.Body)
+		}
+	})
+
+	t.Run("Primary Fails Fast", func(t *testing.T) {
+		backends := []*backend.Backend{newTestBackend(t, newDeadServerURL()), newTestBackend(t, fast.URL)}
+		for _, b := range backends {
+			b.SetAlive(true)
+		}
+		lb, err := New(backends, WithHedging(HedgePolicy{Delay: time.Second}))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		rec, elapsed := serve(lb, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Body.String() != "fast" || elapsed > 500*time.Millisecond {
+			t.Errorf("Expected an immediate hedge after the primary failed, got %q after %v", rec.Body, elapsed)
+		}
+	})
+}
+
+// TestHedgingLargeResponse tests that a response too large to buffer wins the
+// race and is streamed to the client instead of held in memory
+func TestHedgingLargeResponse(t *testing.T) {
+	first := strings.Repeat("a", 4<<10)
+	rest := strings.Repeat("b", 4<<10)
+	var hedged atomic.Bool
+	large := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, first)
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, rest)
+	}))
+	defer large.Close()
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hedged.Store(true)
+		io.WriteString(w, "other")
+	}))
+	defer other.Close()
+
+	backends := []*backend.Backend{newTestBackend(t, large.URL), newTestBackend(t, other.URL)}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+	lb, err := New(backends, WithHedging(HedgePolicy{Delay: 50 * time.Millisecond, MaxBuffer: 1 << 10}))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	front := httptest.NewServer(lb)
+	defer front.Close()
+
+	start := time.Now()
+	resp, err := http.Get(front.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	head := make([]byte, len(first))
+	if _, err := io.ReadFull(resp.Body, head); err != nil {
+		t.Fatalf("Failed to read the start of the body: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected the start of the body streamed right away, took %v", elapsed)
+	}
+	tail, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read the rest of the body: %v", err)
+	}
+	if string(head)+string(tail) != first+rest {
+		t.Errorf("Expected the whole large response, got %d bytes", len(head)+len(tail))
+	}
+	if hedged.Load() {
+		t.Error("Expected no hedge once the large response was streaming")
+	}
+}
@@ -0,0 +1,96 @@
+package balancer
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// HostRouter sends each request to the handler registered for its Host
+// header, so several pools, each a LoadBalancer with its own strategy and
+// health checks, can share one listener. Hosts are matched without their
+// port and regardless of case. It is safe to add hosts while serving.
+type HostRouter struct {
+	mu        sync.RWMutex
+	hosts     map[string]http.Handler
+	wildcards map[string]http.Handler // keyed by the suffix after "*"
+	fallback  http.Handler
+}
+
+// NewHostRouter creates a router with no hosts. Unmatched requests get a 404
+// until a default is set with SetDefault.
+func NewHostRouter() *HostRouter {
+	return &HostRouter{
+		hosts:     make(map[string]http.Handler),
+		wildcards: make(map[string]http.Handler),
+	}
+}
+
+// Handle routes requests for host to h. host may be a wildcard such as
+// "*.example.com", which matches any subdomain of example.com but not
+// example.com itself; exact hosts take precedence over wildcards and longer
+// wildcards over shorter ones.
+func (hr *HostRouter) Handle(host string, h http.Handler) {
+	host = normalizeHost(host)
+
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	if suffix, ok := strings.CutPrefix(host, "*"); ok {
+		hr.wildcards[suffix] = h
+		return
+	}
+	hr.hosts[host] = h
+}
+
+// SetDefault sets the handler for requests no host matches; nil restores the
+// 404 response.
+func (hr *HostRouter) SetDefault(h http.Handler) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.fallback = h
+}
+
+func (hr *HostRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h := hr.match(r.Host); h != nil {
+		h.ServeHTTP(w, r)
+		return
+	}
+	DefaultErrorRenderer(w, r, &ProxyError{
+		Status:  http.StatusNotFound,
+		Message: "no route for host " + r.Host,
+		Code:    CodeNoRoute,
+	})
+}
+
+// match returns the handler for host, or nil if nothing matches it.
+func (hr *HostRouter) match(host string) http.Handler {
+	host = normalizeHost(host)
+
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+	if h, ok := hr.hosts[host]; ok {
+		return h
+	}
+	// Try the longest wildcard suffix first: a.b.example.com checks
+	// .b.example.com, then .example.com, then .com
+	for i := strings.IndexByte(host, '.'); i >= 0; {
+		if h, ok := hr.wildcards[host[i:]]; ok {
+			return h
+		}
+		next := strings.IndexByte(host[i+1:], '.')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return hr.fallback
+}
+
+// normalizeHost lowercases host and strips any port.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
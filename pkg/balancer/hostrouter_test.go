@@ -0,0 +1,61 @@
+package balancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestHostRouter tests routing by Host header with wildcards and a default pool
+func TestHostRouter(t *testing.T) {
+	api := newPool(t, map[string]map[string]string{newNamedServer(t, "api").URL: nil})
+	static := newPool(t, map[string]map[string]string{newNamedServer(t, "static").URL: nil})
+	tenants := newPool(t, map[string]map[string]string{newNamedServer(t, "tenants").URL: nil})
+	fallback := newPool(t, map[string]map[string]string{newNamedServer(t, "default").URL: nil})
+
+	hr := NewHostRouter()
+	hr.Handle("api.example.com", api)
+	hr.Handle("Static.Example.com", static)
+	hr.Handle("*.example.com", tenants)
+
+	get := func(host string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		hr.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	tests := []struct {
+		name string
+		host string
+		code int
+		body string
+	}{
+		{"Exact Host", "api.example.com", http.StatusOK, "api"},
+		{"Case Insensitive", "API.Example.COM", http.StatusOK, "api"},
+		{"Port Ignored", "static.example.com:8080", http.StatusOK, "static"},
+		{"Wildcard Subdomain", "acme.example.com", http.StatusOK, "tenants"},
+		{"Nested Wildcard Subdomain", "eu.acme.example.com", http.StatusOK, "tenants"},
+		{"Wildcard Excludes Apex", "example.com", http.StatusNotFound, ""},
+		{"Unknown Host", "other.org", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := get(tt.host)
+			if code != tt.code {
+				t.Fatalf("Host %s: expected status %d, got %d (%s)", tt.host, tt.code, code, body)
+			}
+			if tt.body != "" && body != tt.body {
+				t.Errorf("Host %s: expected %q, got %q", tt.host, tt.body, body)
+			}
+		})
+	}
+
+	t.Run("Default Pool", func(t *testing.T) {
+		hr.SetDefault(fallback)
+		if code, body := get("other.org"); code != http.StatusOK || body != "default" {
+			t.Errorf("Expected unknown host to hit the default pool, got %d %q", code, body)
+		}
+	})
+}
@@ -1,6 +1,8 @@
 package balancer
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -12,6 +14,16 @@ import (
 	"github.com/akshaykumarthakur/load-balancer/internal/backend"
 )
 
+// newTestBackend creates a backend for the given URL, failing the test on error
+func newTestBackend(t *testing.T, urlStr string) *backend.Backend {
+	t.Helper()
+	b, err := backend.NewBackend(urlStr)
+	if err != nil {
+		t.Fatalf("Failed to create backend %s: %v", urlStr, err)
+	}
+	return b
+}
+
 // TestRoundRobinDistribution tests that requests are distributed in round-robin fashion
 func TestRoundRobinDistribution(t *testing.T) {
 	// Create 3 test backend servers
@@ -35,7 +47,7 @@ func TestRoundRobinDistribution(t *testing.T) {
 	// Create backends from test servers
 	backends := make([]*backend.Backend, len(servers))
 	for i, server := range servers {
-		backends[i] = backend.NewBackend(server.URL)
+		backends[i] = newTestBackend(t, server.URL)
 		backends[i].SetAlive(true)
 	}
 
@@ -79,9 +91,9 @@ func TestRoundRobinDistribution(t *testing.T) {
 // TestBackendFailureHandling tests that failed backends are skipped
 func TestBackendFailureHandling(t *testing.T) {
 	backends := []*backend.Backend{
-		backend.NewBackend("http://localhost:3000"),
-		backend.NewBackend("http://localhost:3001"),
-		backend.NewBackend("http://localhost:3002"),
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
 	}
 
 	// Mark all as alive initially
@@ -161,9 +173,9 @@ func TestBackendFailureHandling(t *testing.T) {
 // TestAllBackendsDown tests error handling when all backends are offline
 func TestAllBackendsDown(t *testing.T) {
 	backends := []*backend.Backend{
-		backend.NewBackend("http://localhost:3000"),
-		backend.NewBackend("http://localhost:3001"),
-		backend.NewBackend("http://localhost:3002"),
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
 	}
 
 	// Mark all as dead
@@ -181,17 +193,17 @@ func TestAllBackendsDown(t *testing.T) {
 		t.Error("Expected error when all backends are down")
 	}
 
-	if err.Error() != "all backends are offline" {
-		t.Errorf("Unexpected error message: %v", err)
+	if !errors.Is(err, ErrAllBackendsOffline) {
+		t.Errorf("Expected ErrAllBackendsOffline, got: %v", err)
 	}
 }
 
 // TestConcurrentRequests tests that concurrent requests are handled fairly
 func TestConcurrentRequests(t *testing.T) {
 	backends := []*backend.Backend{
-		backend.NewBackend("http://localhost:3000"),
-		backend.NewBackend("http://localhost:3001"),
-		backend.NewBackend("http://localhost:3002"),
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
 	}
 
 	for _, b := range backends {
@@ -254,9 +266,9 @@ func TestConcurrentRequests(t *testing.T) {
 // TestPartialFailureDuringConcurrentLoad tests recovery during load
 func TestPartialFailureDuringConcurrentLoad(t *testing.T) {
 	backends := []*backend.Backend{
-		backend.NewBackend("http://localhost:3000"),
-		backend.NewBackend("http://localhost:3001"),
-		backend.NewBackend("http://localhost:3002"),
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
 	}
 
 	for _, b := range backends {
@@ -314,8 +326,8 @@ func TestPartialFailureDuringConcurrentLoad(t *testing.T) {
 // TestHealthStatusChanges tests that health status changes are reflected immediately
 func TestHealthStatusChanges(t *testing.T) {
 	backends := []*backend.Backend{
-		backend.NewBackend("http://localhost:3000"),
-		backend.NewBackend("http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
 	}
 
 	for _, b := range backends {
@@ -373,14 +385,14 @@ func TestHealthStatusChanges(t *testing.T) {
 func TestLoadBalancerCreation(t *testing.T) {
 	t.Run("No Backends Error", func(t *testing.T) {
 		_, err := New([]*backend.Backend{})
-		if err == nil {
-			t.Error("Expected error when creating load balancer with no backends")
+		if !errors.Is(err, ErrNoBackends) {
+			t.Errorf("Expected ErrNoBackends, got: %v", err)
 		}
 	})
 
 	t.Run("Single Backend", func(t *testing.T) {
 		backends := []*backend.Backend{
-			backend.NewBackend("http://localhost:3000"),
+			newTestBackend(t, "http://localhost:3000"),
 		}
 		backends[0].SetAlive(true)
 
@@ -402,7 +414,7 @@ func TestLoadBalancerCreation(t *testing.T) {
 	t.Run("Many Backends", func(t *testing.T) {
 		backends := make([]*backend.Backend, 100)
 		for i := 0; i < 100; i++ {
-			backends[i] = backend.NewBackend(fmt.Sprintf("http://localhost:%d", 3000+i))
+			backends[i] = newTestBackend(t, fmt.Sprintf("http://localhost:%d", 3000+i))
 			backends[i].SetAlive(true)
 		}
 
@@ -432,11 +444,11 @@ func TestStressTest(t *testing.T) {
 	}
 
 	backends := []*backend.Backend{
-		backend.NewBackend("http://localhost:3000"),
-		backend.NewBackend("http://localhost:3001"),
-		backend.NewBackend("http://localhost:3002"),
-		backend.NewBackend("http://localhost:3003"),
-		backend.NewBackend("http://localhost:3004"),
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+		newTestBackend(t, "http://localhost:3003"),
+		newTestBackend(t, "http://localhost:3004"),
 	}
 
 	for _, b := range backends {
@@ -495,3 +507,111 @@ func TestStressTest(t *testing.T) {
 	}
 }
 
+// TestIntermittentFailureKeepsSurvivorsEven tests that a flapping backend doesn't skew the survivors
+func TestIntermittentFailureKeepsSurvivorsEven(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	count := make(map[*backend.Backend]int)
+	for i := 0; i < 3000; i++ {
+		// Flap backend 1 every 7 requests
+		if i%7 == 0 {
+			backends[1].SetAlive(!backends[1].IsAlive())
+		}
+
+		selected, err := lb.SelectBackend()
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i, err)
+		}
+		count[selected]++
+	}
+
+	ratio := float64(count[backends[0]]) / float64(count[backends[2]])
+	if ratio < 0.9 || ratio > 1.1 {
+		t.Errorf("Survivors diverged by more than 10%%: 0=%d, 2=%d (ratio=%.2f)",
+			count[backends[0]], count[backends[2]], ratio)
+	}
+}
+
+// TestSelectBackendContext tests that selection honors context cancellation and deadlines
+func TestSelectBackendContext(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("Live Context Selects", func(t *testing.T) {
+		selected, err := lb.SelectBackendContext(context.Background())
+		if err != nil {
+			t.Fatalf("Selection failed: %v", err)
+		}
+		if selected == nil {
+			t.Error("Expected a backend")
+		}
+	})
+
+	t.Run("Cancelled Context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := lb.SelectBackendContext(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("Expired Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		_, err := lb.SelectBackendContext(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+}
+
+// TestHealthRatio tests the pool-level health summary
+func TestHealthRatio(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+		newTestBackend(t, "http://localhost:3003"),
+	}
+
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	if lb.Ready() || lb.HealthRatio() != 0 {
+		t.Errorf("Expected not ready with ratio 0, got ready=%v ratio=%v", lb.Ready(), lb.HealthRatio())
+	}
+
+	backends[0].SetAlive(true)
+	if !lb.Ready() || lb.HealthRatio() != 0.25 {
+		t.Errorf("Expected ready with ratio 0.25, got ready=%v ratio=%v", lb.Ready(), lb.HealthRatio())
+	}
+}
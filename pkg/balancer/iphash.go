@@ -0,0 +1,80 @@
+package balancer
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// ClientIP returns the address of the client that sent r. When trustForwarded
+// is set, the first address in X-Forwarded-For wins over RemoteAddr; only
+// enable that behind a proxy that sets the header, since clients can forge it.
+func ClientIP(r *http.Request, trustForwarded bool) string {
+	if trustForwarded {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			first, _, _ := strings.Cut(xff, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ClientIPKey returns a KeyFunc keyed on the client address; see ClientIP.
+func ClientIPKey(trustForwarded bool) KeyFunc {
+	return func(r *http.Request) string {
+		return ClientIP(r, trustForwarded)
+	}
+}
+
+// IPHash pins each client IP to a backend by hashing it over the whole pool,
+// so clients keep their backend as long as it stays available. Clients whose
+// backend is unavailable are rehashed over the available subset, degrading
+// affinity only for them.
+type IPHash struct {
+	trustForwarded bool
+	fallback       *RoundRobin
+}
+
+// NewIPHash creates an IP-hash strategy. trustForwarded makes it key on the
+// X-Forwarded-For client address instead of the connection's RemoteAddr.
+func NewIPHash(trustForwarded bool) *IPHash {
+	return &IPHash{
+		trustForwarded: trustForwarded,
+		fallback:       NewRoundRobin(),
+	}
+}
+
+// Pick has no client address to hash, so it falls back to round-robin.
+func (h *IPHash) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	return h.fallback.Pick(backends, available)
+}
+
+// PickForRequest returns the backend the client's IP hashes to.
+func (h *IPHash) PickForRequest(r *http.Request, backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	return h.PickForKey(ClientIP(r, h.trustForwarded), backends, available)
+}
+
+// PickForKey returns the backend ip hashes to, so SelectBackendForKey with a
+// client's address routes like that client's requests. If that backend is
+// unavailable, ip is rehashed over the available ones, which is just as
+// deterministic.
+func (h *IPHash) PickForKey(ip string, backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	if ip == "" || len(backends) == 0 {
+		return h.fallback.Pick(backends, available)
+	}
+
+	hash := hashKey(ip)
+	if pinned := backends[hash%uint64(len(backends))]; available(pinned) {
+		return pinned, nil
+	}
+	return pickByHash(hash, backends, available)
+}
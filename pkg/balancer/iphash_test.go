@@ -0,0 +1,116 @@
+package balancer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestIPHash tests client affinity and graceful degradation when the pinned backend dies
+func TestIPHash(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	lb, err := New(backends, WithStrategy(NewIPHash(true)))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	requestFrom := func(ip string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":52000"
+		return req
+	}
+
+	selectFor := func(req *http.Request) *backend.Backend {
+		selected, err := lb.SelectBackendForRequest(req)
+		if err != nil {
+			t.Fatalf("Selection failed: %v", err)
+		}
+		return selected
+	}
+
+	t.Run("Same Client Same Backend", func(t *testing.T) {
+		served := make(map[*backend.Backend]bool)
+		for i := 0; i < 30; i++ {
+			ip := fmt.Sprintf("10.0.0.%d", i)
+			first := selectFor(requestFrom(ip))
+			served[first] = true
+			for j := 0; j < 20; j++ {
+				if selected := selectFor(requestFrom(ip)); selected != first {
+					t.Fatalf("%s moved from %s to %s", ip, first.URL.Host, selected.URL.Host)
+				}
+			}
+		}
+		if len(served) != 3 {
+			t.Errorf("Expected clients to spread across 3 backends, got %d", len(served))
+		}
+	})
+
+	t.Run("Stable Over Thousands Of Calls", func(t *testing.T) {
+		first := selectFor(requestFrom("192.168.1.20"))
+		for i := 0; i < 5000; i++ {
+			if selected := selectFor(requestFrom("192.168.1.20")); selected != first {
+				t.Fatalf("Call %d moved from %s to %s", i, first.URL.Host, selected.URL.Host)
+			}
+		}
+	})
+
+	t.Run("Key Matches Request", func(t *testing.T) {
+		for i := 0; i < 30; i++ {
+			ip := fmt.Sprintf("10.1.0.%d", i)
+			selected, err := lb.SelectBackendForKey(ip)
+			if err != nil {
+				t.Fatalf("SelectBackendForKey failed: %v", err)
+			}
+			if expected := selectFor(requestFrom(ip)); selected != expected {
+				t.Errorf("%s selected %s by key but %s by request", ip, selected.URL.Host, expected.URL.Host)
+			}
+		}
+	})
+
+	t.Run("X-Forwarded-For Is Honored", func(t *testing.T) {
+		direct := selectFor(requestFrom("203.0.113.7"))
+		for i := 0; i < 10; i++ {
+			req := requestFrom(fmt.Sprintf("192.168.1.%d", i))
+			req.Header.Set("X-Forwarded-For", "203.0.113.7, 192.168.1.1")
+			if selected := selectFor(req); selected != direct {
+				t.Errorf("Forwarded client routed to %s, expected %s", selected.URL.Host, direct.URL.Host)
+			}
+		}
+	})
+
+	t.Run("Dead Backend Only Moves Its Clients", func(t *testing.T) {
+		before := make(map[string]*backend.Backend)
+		for i := 0; i < 100; i++ {
+			ip := fmt.Sprintf("172.16.0.%d", i)
+			before[ip] = selectFor(requestFrom(ip))
+		}
+
+		backends[1].SetAlive(false)
+		defer backends[1].SetAlive(true)
+
+		for ip, prev := range before {
+			selected := selectFor(requestFrom(ip))
+			if selected == backends[1] {
+				t.Fatalf("%s routed to dead backend", ip)
+			}
+			if prev != backends[1] && selected != prev {
+				t.Errorf("%s moved from live %s to %s", ip, prev.URL.Host, selected.URL.Host)
+			}
+			if again := selectFor(requestFrom(ip)); again != selected {
+				t.Errorf("%s is not stable after failover", ip)
+			}
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package balancer
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// SelectBackendWithLabels is like SelectBackend but only considers backends
+// carrying every label in selector, e.g. {"region": "us-east"}. It returns
+// ErrNoMatchingBackends if no backend in the pool matches, and the usual
+// selection errors if matching backends exist but none can take traffic.
+func (lb *LoadBalancer) SelectBackendWithLabels(selector map[string]string) (*backend.Backend, error) {
+	return lb.selectBackend(context.Background(), nil, matchLabels(selector))
+}
+
+// SelectBackendForRequestWithLabels combines SelectBackendForRequest and
+// SelectBackendWithLabels.
+func (lb *LoadBalancer) SelectBackendForRequestWithLabels(r *http.Request, selector map[string]string) (*backend.Backend, error) {
+	return lb.selectBackend(r.Context(), r, matchLabels(selector))
+}
+
+// matchLabels returns a subset filter for backends carrying selector's labels.
+func matchLabels(selector map[string]string) func(*backend.Backend) bool {
+	return func(b *backend.Backend) bool { return b.MatchesLabels(selector) }
+}
+
+// WithLabels returns a handler that proxies like ServeHTTP but only to
+// backends carrying every label in selector, e.g. to give a route or a canary
+// cohort its own slice of the pool. Requests fail with 503 if no backend in
+// the pool matches.
+func (lb *LoadBalancer) WithLabels(selector map[string]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lb.serve(w, r, matchLabels(selector))
+	})
+}
@@ -0,0 +1,68 @@
+package balancer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestSelectBackendWithLabels tests that selection is limited to backends matching a label selector
+func TestSelectBackendWithLabels(t *testing.T) {
+	newLabeled := func(url string, labels map[string]string) *backend.Backend {
+		b, err := backend.NewBackendWithLabels(url, labels)
+		if err != nil {
+			t.Fatalf("Failed to create backend %s: %v", url, err)
+		}
+		b.SetAlive(true)
+		return b
+	}
+	backends := []*backend.Backend{
+		newLabeled("http://localhost:3000", map[string]string{"region": "us-east", "cohort": "stable"}),
+		newLabeled("http://localhost:3001", map[string]string{"region": "us-east", "cohort": "canary"}),
+		newLabeled("http://localhost:3002", map[string]string{"region": "eu-west", "cohort": "stable"}),
+	}
+
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("Filters By Selector", func(t *testing.T) {
+		count := make(map[*backend.Backend]int)
+		for i := 0; i < 10; i++ {
+			selected, err := lb.SelectBackendWithLabels(map[string]string{"region": "us-east"})
+			if err != nil {
+				t.Fatalf("SelectBackendWithLabels failed: %v", err)
+			}
+			count[selected]++
+		}
+		if count[backends[0]] != 5 || count[backends[1]] != 5 || count[backends[2]] != 0 {
+			t.Errorf("Expected 5/5/0 split over us-east backends, got %d/%d/%d",
+				count[backends[0]], count[backends[1]], count[backends[2]])
+		}
+	})
+
+	t.Run("Multiple Labels", func(t *testing.T) {
+		selected, err := lb.SelectBackendWithLabels(map[string]string{"region": "us-east", "cohort": "canary"})
+		if err != nil || selected != backends[1] {
+			t.Errorf("Expected the canary backend, got %v, %v", selected, err)
+		}
+	})
+
+	t.Run("No Matching Backend", func(t *testing.T) {
+		_, err := lb.SelectBackendWithLabels(map[string]string{"region": "ap-south"})
+		if !errors.Is(err, ErrNoMatchingBackends) {
+			t.Errorf("Expected ErrNoMatchingBackends, got %v", err)
+		}
+	})
+
+	t.Run("Matching Backends Offline", func(t *testing.T) {
+		backends[2].SetAlive(false)
+		defer backends[2].SetAlive(true)
+		_, err := lb.SelectBackendWithLabels(map[string]string{"region": "eu-west"})
+		if !errors.Is(err, ErrAllBackendsOffline) {
+			t.Errorf("Expected ErrAllBackendsOffline, got %v", err)
+		}
+	})
+}
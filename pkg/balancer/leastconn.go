@@ -0,0 +1,57 @@
+package balancer
+
+import (
+	"sync/atomic"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// LeastConnections routes to the available backend with the fewest requests
+// in flight (see backend.ActiveConnections), so slow requests don't pile up
+// on one backend while the others idle. Ties go to the first tied backend
+// after the previous pick in pool order, so equally loaded backends take
+// turns like round-robin.
+//
+// ServeHTTP counts its own requests. Callers that select a backend and route
+// the request themselves should use AcquireBackend so their requests count
+// too.
+type LeastConnections struct {
+	next atomic.Uint64
+}
+
+// NewLeastConnections creates a least-connections strategy.
+func NewLeastConnections() *LeastConnections {
+	return &LeastConnections{}
+}
+
+// Pick returns the available backend with the fewest active connections.
+func (l *LeastConnections) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	n := uint64(len(backends))
+	if n == 0 {
+		return nil, ErrAllBackendsOffline
+	}
+	// The pool may have shrunk since the position was stored
+	start := l.next.Load() % n
+
+	var best *backend.Backend
+	var bestIdx uint64
+	var bestActive int64
+	for offset := uint64(0); offset < n; offset++ {
+		idx := (start + offset) % n
+		b := backends[idx]
+		if !available(b) {
+			continue
+		}
+		if active := b.ActiveConnections(); best == nil || active < bestActive {
+			best, bestIdx, bestActive = b, idx, active
+		}
+	}
+
+	if best == nil {
+		return nil, ErrAllBackendsOffline
+	}
+	// Concurrent picks may overwrite each other's position; that only
+	// affects who wins the next tie
+	l.next.Store((bestIdx + 1) % n)
+	return best, nil
+}
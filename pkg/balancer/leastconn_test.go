@@ -0,0 +1,139 @@
+package balancer
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestLeastConnections tests that the least busy backend is picked and ties rotate
+func TestLeastConnections(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+	lb, err := New(backends, WithStrategy(NewLeastConnections()))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("Ties Rotate", func(t *testing.T) {
+		for i := 0; i < 6; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			if selected != backends[i%3] {
+				t.Errorf("Request %d: expected %s, got %s", i, backends[i%3].URL.Host, selected.URL.Host)
+			}
+		}
+	})
+
+	t.Run("Fewest Connections Wins", func(t *testing.T) {
+		backends[0].IncrementConnections()
+		backends[0].IncrementConnections()
+		backends[2].IncrementConnections()
+		defer func() {
+			backends[0].DecrementConnections()
+			backends[0].DecrementConnections()
+			backends[2].DecrementConnections()
+		}()
+
+		for i := 0; i < 3; i++ {
+			selected, done, err := lb.AcquireBackend(context.Background())
+			if err != nil {
+				t.Fatalf("AcquireBackend failed: %v", err)
+			}
+			defer done()
+			// 3001 takes the first, then ties with 3002 and they alternate
+			expected := []*backend.Backend{backends[1], backends[2], backends[1]}[i]
+			if selected != expected {
+				t.Errorf("Request %d: expected %s, got %s", i, expected.URL.Host, selected.URL.Host)
+			}
+		}
+	})
+
+	t.Run("Done Releases Once", func(t *testing.T) {
+		selected, done, err := lb.AcquireBackend(context.Background())
+		if err != nil {
+			t.Fatalf("AcquireBackend failed: %v", err)
+		}
+		if selected.ActiveConnections() != 1 {
+			t.Fatalf("Expected 1 active connection, got %d", selected.ActiveConnections())
+		}
+		done()
+		done()
+		if selected.ActiveConnections() != 0 {
+			t.Errorf("Expected the connection released exactly once, got %d active", selected.ActiveConnections())
+		}
+	})
+}
+
+// TestLeastConnectionsConcurrent tests that requests with uneven hold times stay balanced across backends
+func TestLeastConnectionsConcurrent(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+		newTestBackend(t, "http://localhost:3003"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+	lb, err := New(backends, WithStrategy(NewLeastConnections()))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	const requests = 400
+	var mu sync.Mutex
+	counts := make(map[*backend.Backend]int)
+	peak := make(map[*backend.Backend]int64)
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			selected, done, err := lb.AcquireBackend(context.Background())
+			if err != nil {
+				t.Errorf("AcquireBackend failed: %v", err)
+				return
+			}
+			defer done()
+
+			mu.Lock()
+			counts[selected]++
+			peak[selected] = max(peak[selected], selected.ActiveConnections())
+			mu.Unlock()
+			time.Sleep(time.Duration(1+rand.IntN(10)) * time.Millisecond)
+		}()
+	}
+	wg.Wait()
+
+	for _, b := range backends {
+		if n := counts[b]; n < requests/4*3/4 || n > requests/4*5/4 {
+			t.Errorf("Expected %s to get about %d requests, got %d", b.URL.Host, requests/4, n)
+		}
+		if b.ActiveConnections() != 0 {
+			t.Errorf("Expected %s to have no requests left in flight, got %d", b.URL.Host, b.ActiveConnections())
+		}
+	}
+	// The busiest any backend got should be near an even share of the
+	// requests in flight at once
+	var lowest, highest int64 = requests, 0
+	for _, b := range backends {
+		lowest, highest = min(lowest, peak[b]), max(highest, peak[b])
+	}
+	if highest > lowest*2 {
+		t.Errorf("Expected peak in-flight counts to stay close, got %d to %d", lowest, highest)
+	}
+}
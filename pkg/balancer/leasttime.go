@@ -0,0 +1,78 @@
+package balancer
+
+import (
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// latencyTolerance is how far above the lowest score a backend may be and
+// still be picked by LeastResponseTime, as a fraction of that score.
+const latencyTolerance = 0.1
+
+// LeastResponseTime routes to the available backend with the lowest smoothed
+// response latency (see backend.AvgLatency), multiplied by its requests in
+// flight plus one so that a burst doesn't all land on the backend that was
+// fastest a moment ago. Backends within latencyTolerance of the lowest score
+// are picked at random for the same reason. Backends that have not served a
+// request yet, or not since recovering, have no latency to compare, so they
+// are tried first, in round-robin order, until each has a sample.
+type LeastResponseTime struct {
+	randSource
+	unsampled RoundRobin
+}
+
+// NewLeastResponseTime creates a least-response-time strategy.
+func NewLeastResponseTime() *LeastResponseTime {
+	return &LeastResponseTime{}
+}
+
+// Pick returns an available backend with about the lowest latency score.
+func (l *LeastResponseTime) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	// Give brand-new backends a chance to report before comparing
+	if b, err := l.unsampled.Pick(backends, func(b *backend.Backend) bool {
+		return b.AvgLatency() == 0 && available(b)
+	}); err == nil {
+		return b, nil
+	}
+
+	var best *backend.Backend
+	bestScore := 0.0
+	for _, b := range backends {
+		if !available(b) {
+			continue
+		}
+		if score := latencyScore(b); best == nil || score < bestScore {
+			best, bestScore = b, score
+		}
+	}
+	if best == nil {
+		return nil, ErrAllBackendsOffline
+	}
+
+	limit := bestScore * (1 + latencyTolerance)
+	near := func(b *backend.Backend) bool { return available(b) && latencyScore(b) <= limit }
+	n := 0
+	for _, b := range backends {
+		if near(b) {
+			n++
+		}
+	}
+	if n <= 1 {
+		return best, nil
+	}
+	k := l.intN(n)
+	for _, b := range backends {
+		if near(b) {
+			if k == 0 {
+				return b, nil
+			}
+			k--
+		}
+	}
+	// The pool changed state between the passes
+	return best, nil
+}
+
+// latencyScore weighs b's average latency by its requests in flight.
+func latencyScore(b *backend.Backend) float64 {
+	return float64(b.AvgLatency()) * float64(b.ActiveConnections()+1)
+}
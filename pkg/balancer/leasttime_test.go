@@ -0,0 +1,158 @@
+package balancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestLeastResponseTime tests that traffic favors the fastest backend without starving new ones
+func TestLeastResponseTime(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+	backends[0].RecordLatency(50 * time.Millisecond)
+	backends[1].RecordLatency(10 * time.Millisecond)
+
+	lb, err := New(backends, WithStrategy(NewLeastResponseTime()))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("New Backend Tried First", func(t *testing.T) {
+		selected, err := lb.SelectBackend()
+		if err != nil {
+			t.Fatalf("SelectBackend failed: %v", err)
+		}
+		if selected != backends[2] {
+			t.Errorf("Expected the unsampled backend %s, got %s", backends[2].URL, selected.URL)
+		}
+	})
+
+	t.Run("Fastest Backend Wins", func(t *testing.T) {
+		backends[2].RecordLatency(30 * time.Millisecond)
+
+		for i := 0; i < 10; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			if selected != backends[1] {
+				t.Errorf("Request %d: expected fastest backend %s, got %s", i, backends[1].URL, selected.URL)
+			}
+		}
+	})
+
+	t.Run("Skip Dead Backends", func(t *testing.T) {
+		backends[1].SetAlive(false)
+		defer backends[1].SetAlive(true)
+
+		selected, err := lb.SelectBackend()
+		if err != nil {
+			t.Fatalf("SelectBackend failed: %v", err)
+		}
+		if selected != backends[2] {
+			t.Errorf("Expected next fastest backend %s, got %s", backends[2].URL, selected.URL)
+		}
+	})
+
+	t.Run("Requests In Flight Count Against A Backend", func(t *testing.T) {
+		// Recovering in the previous subtest reset its average
+		backends[1].RecordLatency(10 * time.Millisecond)
+		for i := 0; i < 3; i++ {
+			backends[1].IncrementConnections()
+		}
+		defer func() {
+			for i := 0; i < 3; i++ {
+				backends[1].DecrementConnections()
+			}
+		}()
+
+		selected, err := lb.SelectBackend()
+		if err != nil {
+			t.Fatalf("SelectBackend failed: %v", err)
+		}
+		if selected != backends[2] {
+			t.Errorf("Expected idle backend %s, got %s", backends[2].URL, selected.URL)
+		}
+	})
+
+	t.Run("Near Ties Are Shared", func(t *testing.T) {
+		backends[0].SetLatencySmoothing(1)
+		backends[0].RecordLatency(10500 * time.Microsecond)
+
+		served := make(map[*backend.Backend]int)
+		for i := 0; i < 200; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			served[selected]++
+		}
+		if served[backends[0]] < 50 || served[backends[1]] < 50 {
+			t.Errorf("Expected the two near-equal backends to share traffic, got %d and %d",
+				served[backends[0]], served[backends[1]])
+		}
+		if served[backends[2]] != 0 {
+			t.Errorf("Expected the slower backend to get nothing, got %d", served[backends[2]])
+		}
+	})
+}
+
+// TestLeastResponseTimeShiftsTraffic tests that proxied traffic moves to the faster of two backends
+func TestLeastResponseTimeShiftsTraffic(t *testing.T) {
+	newServer := func(delay time.Duration) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	slow := newTestBackend(t, newServer(200*time.Millisecond).URL)
+	fast := newTestBackend(t, newServer(10*time.Millisecond).URL)
+	slow.SetAlive(true)
+	fast.SetAlive(true)
+
+	lb, err := New([]*backend.Backend{slow, fast}, WithStrategy(NewLeastResponseTime()))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	for i := 0; i < 40; i++ {
+		lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	if requests := fast.TotalRequests(); requests < 36 {
+		t.Errorf("Expected at least 36 of 40 requests on the fast backend, got %d", requests)
+	}
+}
+
+// TestProxyRecordsLatency tests that ServeHTTP feeds completed requests into the backend's average
+func TestProxyRecordsLatency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	b.SetAlive(true)
+
+	lb, err := New([]*backend.Backend{b}, WithStrategy(NewLeastResponseTime()), WithLatencySmoothing(1))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if avg := b.AvgLatency(); avg < 20*time.Millisecond {
+		t.Errorf("Expected average latency of at least 20ms, got %v", avg)
+	}
+}
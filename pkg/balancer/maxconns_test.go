@@ -0,0 +1,196 @@
+package balancer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestMaxConnections tests that backends at their connection cap are skipped
+func TestMaxConnections(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+		b.SetMaxConnections(2)
+	}
+
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("Skip Saturated Backend", func(t *testing.T) {
+		backends[0].IncrementConnections()
+		backends[0].IncrementConnections()
+		defer backends[0].DecrementConnections()
+		defer backends[0].DecrementConnections()
+
+		for i := 0; i < 4; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			if selected != backends[1] {
+				t.Errorf("Request %d: expected %s, got saturated %s", i, backends[1].URL, selected.URL)
+			}
+		}
+	})
+
+	t.Run("All Saturated", func(t *testing.T) {
+		for _, b := range backends {
+			b.IncrementConnections()
+			b.IncrementConnections()
+			defer b.DecrementConnections()
+			defer b.DecrementConnections()
+		}
+
+		_, err := lb.SelectBackend()
+		if !errors.Is(err, ErrAllBackendsSaturated) {
+			t.Fatalf("Expected ErrAllBackendsSaturated, got %v", err)
+		}
+
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503, got %d", rec.Code)
+		}
+		if rec.Header().Get("Retry-After") == "" {
+			t.Error("Expected a Retry-After header")
+		}
+	})
+
+	t.Run("Dead Backends Are Not Saturated", func(t *testing.T) {
+		for _, b := range backends {
+			b.SetAlive(false)
+			defer b.SetAlive(true)
+		}
+
+		_, err := lb.SelectBackend()
+		if errors.Is(err, ErrAllBackendsSaturated) || err == nil {
+			t.Errorf("Expected the offline error, got %v", err)
+		}
+	})
+}
+
+// TestProxyTracksConnections tests that ServeHTTP counts requests in flight
+func TestProxyTracksConnections(t *testing.T) {
+	var b *backend.Backend
+	var during int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		during = b.ActiveConnections()
+	}))
+	defer server.Close()
+
+	b = newTestBackend(t, server.URL)
+	b.SetAlive(true)
+
+	lb, err := New([]*backend.Backend{b})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if during != 1 {
+		t.Errorf("Expected 1 active connection during the request, got %d", during)
+	}
+	if got := b.ActiveConnections(); got != 0 {
+		t.Errorf("Expected 0 active connections afterwards, got %d", got)
+	}
+}
+
+// TestMaxConnectionsConcurrent tests that concurrent requests never take a
+// backend past its connection cap together
+func TestMaxConnectionsConcurrent(t *testing.T) {
+	const limit, clients = 3, 50
+
+	var inFlight, peak atomic.Int64
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		<-release
+	}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	b.SetAlive(true)
+	b.SetMaxConnections(limit)
+	lb, err := New([]*backend.Backend{b})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("Acquire", func(t *testing.T) {
+		var acquired atomic.Int64
+		var dones []func()
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i := 0; i < clients; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				if _, done, err := lb.AcquireBackend(t.Context()); err == nil {
+					acquired.Add(1)
+					mu.Lock()
+					dones = append(dones, done)
+					mu.Unlock()
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		if got := acquired.Load(); got != limit {
+			t.Errorf("Expected exactly %d acquisitions, got %d", limit, got)
+		}
+		for _, done := range dones {
+			done()
+		}
+		if got := b.ActiveConnections(); got != 0 {
+			t.Errorf("Expected every slot released, got %d active", got)
+		}
+	})
+
+	t.Run("Proxy", func(t *testing.T) {
+		var wg sync.WaitGroup
+		var rejected atomic.Int64
+		for i := 0; i < clients; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				rec := httptest.NewRecorder()
+				lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+				if rec.Code == http.StatusServiceUnavailable {
+					rejected.Add(1)
+				}
+			}()
+		}
+		// Hold the admitted requests until every other one was turned away
+		for rejected.Load()+inFlight.Load() < clients {
+			runtime.Gosched()
+		}
+		close(release)
+		wg.Wait()
+
+		if got := peak.Load(); got > limit {
+			t.Errorf("Expected at most %d requests in flight, saw %d", limit, got)
+		}
+		if got := b.ActiveConnections(); got != 0 {
+			t.Errorf("Expected every slot released, got %d active", got)
+		}
+	})
+}
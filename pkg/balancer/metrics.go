@@ -0,0 +1,53 @@
+package balancer
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/metrics"
+)
+
+var (
+	healthyBackendsDesc = prometheus.NewDesc(
+		"lb_healthy_backends",
+		"Backends currently available for selection.",
+		nil, nil,
+	)
+	activeConnectionsDesc = prometheus.NewDesc(
+		"lb_backend_active_connections",
+		"Requests currently in flight to the backend.",
+		[]string{"backend"}, nil,
+	)
+)
+
+// MetricsHandler returns a Prometheus scrape handler for the balancer, its
+// backends and its health checks, suitable for mounting on an admin port.
+// Scrapers that accept OpenMetrics also get the histograms' exemplars, such as
+// the trace IDs of health probes; see healthcheck.WithTracer.
+func (lb *LoadBalancer) MetricsHandler() http.Handler {
+	pool := prometheus.NewRegistry()
+	pool.MustRegister(poolCollector{lb})
+	return promhttp.HandlerFor(prometheus.Gatherers{metrics.Registry, pool}, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
+
+// poolCollector reports gauges read from the pool at scrape time, so they
+// always reflect the current backends.
+type poolCollector struct {
+	lb *LoadBalancer
+}
+
+func (c poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- healthyBackendsDesc
+	ch <- activeConnectionsDesc
+}
+
+func (c poolCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(healthyBackendsDesc, prometheus.GaugeValue,
+		float64(len(c.lb.GetHealthyBackends())))
+	for _, b := range c.lb.Backends() {
+		ch <- prometheus.MustNewConstMetric(activeConnectionsDesc, prometheus.GaugeValue,
+			float64(b.ActiveConnections()), b.URL.Host)
+	}
+}
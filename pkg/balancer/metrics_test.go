@@ -0,0 +1,127 @@
+package balancer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+)
+
+// TestMetricsHandler tests that proxied traffic and pool state show up in the scrape output
+func TestMetricsHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	deadURL := newDeadServerURL()
+
+	backends := []*backend.Backend{
+		newTestBackend(t, server.URL),
+		newTestBackend(t, deadURL),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+	backends[0].SetAlive(true)
+	backends[1].SetAlive(true)
+
+	lb, err := New(backends, WithMaxRetries(0))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	// Round-robin alternates between the live and the dead backend
+	for i := 0; i < 4; i++ {
+		lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	admin := httptest.NewServer(lb.MetricsHandler())
+	defer admin.Close()
+	scrapeMetrics := func(t *testing.T) string {
+		resp, err := http.Get(admin.URL)
+		if err != nil {
+			t.Fatalf("Scrape failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	scrape := scrapeMetrics(t)
+
+	live, dead := backends[0].URL.Host, backends[1].URL.Host
+	expected := []string{
+		fmt.Sprintf(`lb_backend_requests_total{backend=%q} 2`, live),
+		fmt.Sprintf(`lb_backend_requests_total{backend=%q} 2`, dead),
+		fmt.Sprintf(`lb_backend_request_failures_total{backend=%q} 2`, dead),
+		fmt.Sprintf(`lb_backend_active_connections{backend=%q} 0`, live),
+		`lb_healthy_backends 2`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(scrape, line+"\n") {
+			t.Errorf("Expected scrape to contain %q", line)
+		}
+	}
+	if strings.Contains(scrape, fmt.Sprintf(`lb_backend_request_failures_total{backend=%q}`, live)) {
+		t.Error("Expected no failures recorded for the live backend")
+	}
+
+	t.Run("Removed Backend Series Dropped", func(t *testing.T) {
+		if _, err := lb.RemoveBackend(dead); err != nil {
+			t.Fatalf("RemoveBackend failed: %v", err)
+		}
+		scrape := scrapeMetrics(t)
+		if strings.Contains(scrape, fmt.Sprintf(`{backend=%q}`, dead)) {
+			t.Errorf("Expected the removed backend's series to be gone, got:\n%s", scrape)
+		}
+		if !strings.Contains(scrape, fmt.Sprintf(`lb_backend_requests_total{backend=%q} 2`, live)) {
+			t.Error("Expected the live backend's series to be kept")
+		}
+	})
+}
+
+// probeTracer puts every health probe in a sampled span with a fixed trace ID
+type probeTracer struct{}
+
+func (probeTracer) StartProbe(ctx context.Context, b *backend.Backend) (context.Context, string, func()) {
+	return ctx, "4bf92f3577b34da6a3ce929d0e0e4736", func() {}
+}
+
+// TestMetricsHandlerExemplars tests that OpenMetrics scrapes carry the trace IDs of health probes
+func TestMetricsHandlerExemplars(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	b := newTestBackend(t, server.URL)
+
+	hc := healthcheck.NewHealthChecker([]*backend.Backend{b}, time.Second, healthcheck.WithTracer(probeTracer{}))
+	lb, err := New([]*backend.Backend{b})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	if err := hc.CheckNow(b.URL.Host, false); err != nil {
+		t.Fatalf("CheckNow failed: %v", err)
+	}
+
+	admin := httptest.NewServer(lb.MetricsHandler())
+	defer admin.Close()
+	req, _ := http.NewRequest(http.MethodGet, admin.URL, nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("Expected an OpenMetrics scrape, got %q", ct)
+	}
+	pattern := regexp.MustCompile(fmt.Sprintf(`lb_health_check_duration_seconds_bucket\{backend=%q,le="[^"]+"\} 1 # \{trace_id="4bf92f3577b34da6a3ce929d0e0e4736"\}`,
+		b.URL.Host))
+	if !pattern.Match(body) {
+		t.Errorf("Expected the probe's trace ID as an exemplar, got:\n%s", body)
+	}
+}
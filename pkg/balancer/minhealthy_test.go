@@ -0,0 +1,77 @@
+package balancer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestMinHealthy tests that selection fails closed while too few backends are healthy
+func TestMinHealthy(t *testing.T) {
+	newRotation := func(t *testing.T, alive int, opts ...Option) *LoadBalancer {
+		backends := []*backend.Backend{
+			newTestBackend(t, "http://localhost:3000"),
+			newTestBackend(t, "http://localhost:3001"),
+			newTestBackend(t, "http://localhost:3002"),
+			newTestBackend(t, "http://localhost:3003"),
+		}
+		for _, b := range backends[:alive] {
+			b.SetAlive(true)
+		}
+		lb, err := New(backends, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		return lb
+	}
+
+	t.Run("Default Serves With One Healthy", func(t *testing.T) {
+		lb := newRotation(t, 1)
+		if _, err := lb.SelectBackend(); err != nil {
+			t.Errorf("Expected a backend, got %v", err)
+		}
+	})
+
+	t.Run("Count Threshold", func(t *testing.T) {
+		lb := newRotation(t, 1, WithMinHealthy(2))
+		if _, err := lb.SelectBackend(); !errors.Is(err, ErrInsufficientHealthyBackends) {
+			t.Errorf("Expected ErrInsufficientHealthyBackends, got %v", err)
+		}
+
+		lb = newRotation(t, 2, WithMinHealthy(2))
+		if _, err := lb.SelectBackend(); err != nil {
+			t.Errorf("Expected a backend at the threshold, got %v", err)
+		}
+	})
+
+	t.Run("Fraction Threshold Rounds Up", func(t *testing.T) {
+		lb := newRotation(t, 2, WithMinHealthyFraction(0.6))
+		if _, err := lb.SelectBackend(); !errors.Is(err, ErrInsufficientHealthyBackends) {
+			t.Errorf("Expected ErrInsufficientHealthyBackends with 2 of 4 healthy, got %v", err)
+		}
+
+		lb = newRotation(t, 3, WithMinHealthyFraction(0.6))
+		if _, err := lb.SelectBackend(); err != nil {
+			t.Errorf("Expected a backend with 3 of 4 healthy, got %v", err)
+		}
+	})
+
+	t.Run("None Healthy Is Still Offline", func(t *testing.T) {
+		lb := newRotation(t, 0, WithMinHealthy(2))
+		if _, err := lb.SelectBackend(); !errors.Is(err, ErrAllBackendsOffline) {
+			t.Errorf("Expected ErrAllBackendsOffline, got %v", err)
+		}
+	})
+
+	t.Run("ServeHTTP Returns 503", func(t *testing.T) {
+		lb := newRotation(t, 1, WithMinHealthy(3))
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// offlinePollInterval is how often a selection waiting for a backend to come
+// back retries on its own, for changes that aren't announced through
+// backend.StateChanged, such as an ejection running out.
+const offlinePollInterval = 50 * time.Millisecond
+
+// waitForBackend retries try each time a backend's state changes until it
+// finds one available, lb.offlineWait passes or ctx is done. Giving up on
+// the wait or the context's deadline fails with the selection's error
+// wrapped, so it still matches ErrAllBackendsOffline; a cancelled ctx fails
+// with ctx.Err().
+func (lb *LoadBalancer) waitForBackend(ctx context.Context, try func() (*backend.Backend, error)) (*backend.Backend, error) {
+	timer := time.NewTimer(lb.offlineWait)
+	defer timer.Stop()
+	poll := time.NewTicker(offlinePollInterval)
+	defer poll.Stop()
+	var offline error
+	for {
+		// Subscribe before retrying so a recovery in between isn't missed
+		changed := backend.StateChanged()
+		selected, err := try()
+		switch {
+		case errors.Is(err, ErrAllBackendsOffline):
+			offline = err
+		case offline != nil && ctx.Err() != nil:
+			// ctx ran out just as we retried
+			return nil, abandonWait(ctx, offline)
+		default:
+			return selected, err
+		}
+
+		select {
+		case <-changed:
+		case <-poll.C:
+		case <-timer.C:
+			return nil, fmt.Errorf("no backend came back within %v: %w", lb.offlineWait, offline)
+		case <-ctx.Done():
+			return nil, abandonWait(ctx, offline)
+		}
+	}
+}
+
+// abandonWait returns the error for a wait cut short by ctx.
+func abandonWait(ctx context.Context, offline error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("no backend came back before the deadline: %w", offline)
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,106 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestOfflineWait tests that selections can wait for an offline pool to recover
+func TestOfflineWait(t *testing.T) {
+	newDeadPool := func(t *testing.T) []*backend.Backend {
+		backends := []*backend.Backend{
+			newTestBackend(t, "http://localhost:3000"),
+			newTestBackend(t, "http://localhost:3001"),
+		}
+		for _, b := range backends {
+			b.SetAlive(false)
+		}
+		return backends
+	}
+
+	t.Run("Fails At Once By Default", func(t *testing.T) {
+		lb, err := New(newDeadPool(t))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		start := time.Now()
+		if _, err := lb.SelectBackendContext(ctx); !errors.Is(err, ErrAllBackendsOffline) {
+			t.Errorf("Expected ErrAllBackendsOffline, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Errorf("Expected no wait without WithOfflineWait, took %v", elapsed)
+		}
+	})
+
+	t.Run("Cancelled Context Fails At Once", func(t *testing.T) {
+		lb, err := New(newDeadPool(t), WithOfflineWait(time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := lb.SelectBackendContext(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("Backend Comes Alive Mid-Wait", func(t *testing.T) {
+		backends := newDeadPool(t)
+		lb, err := New(backends, WithOfflineWait(time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		time.AfterFunc(50*time.Millisecond, func() { backends[1].SetAlive(true) })
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		selected, err := lb.SelectBackendContext(ctx)
+		if err != nil {
+			t.Fatalf("Expected the recovered backend, got %v", err)
+		}
+		if selected != backends[1] {
+			t.Errorf("Expected %s, got %s", backends[1].URL.Host, selected.URL.Host)
+		}
+	})
+
+	t.Run("Deadline Expires", func(t *testing.T) {
+		lb, err := New(newDeadPool(t), WithOfflineWait(time.Minute))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err = lb.SelectBackendContext(ctx)
+		if !errors.Is(err, ErrAllBackendsOffline) {
+			t.Errorf("Expected an error wrapping ErrAllBackendsOffline, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("Expected to wait for the deadline, took %v", elapsed)
+		}
+	})
+
+	t.Run("Max Wait Bounds Selections Without A Deadline", func(t *testing.T) {
+		lb, err := New(newDeadPool(t), WithOfflineWait(100*time.Millisecond))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		start := time.Now()
+		if _, err := lb.SelectBackend(); !errors.Is(err, ErrAllBackendsOffline) {
+			t.Errorf("Expected an error wrapping ErrAllBackendsOffline, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("Expected to wait the full 100ms, took %v", elapsed)
+		}
+	})
+}
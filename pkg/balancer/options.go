@@ -0,0 +1,417 @@
+package balancer
+
+import (
+	"crypto/tls"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+	"github.com/akshaykumarthakur/load-balancer/internal/ratelimit"
+	"github.com/akshaykumarthakur/load-balancer/pkg/logging"
+)
+
+// Option configures optional LoadBalancer behavior.
+type Option func(*LoadBalancer)
+
+// WithMaxRetries sets how many additional backends ServeHTTP may try after a
+// transport failure. Only requests that are safe to replay are retried.
+func WithMaxRetries(n int) Option {
+	return func(lb *LoadBalancer) {
+		if n < 0 {
+			n = 0
+		}
+		lb.maxRetries = n
+	}
+}
+
+// WithMaxRetryBody sets how much of a request body ServeHTTP holds in memory
+// to replay it on another backend, DefaultMaxRetryBody by default. A larger
+// body is streamed to a single backend instead, without retries or hedging.
+// Zero or less never buffers bodies.
+func WithMaxRetryBody(n int64) Option {
+	return func(lb *LoadBalancer) {
+		lb.maxRetryBody = max(n, 0)
+	}
+}
+
+// WithMaxRequestBody rejects requests whose body is larger than n bytes with
+// 413 Request Entity Too Large and CodeRequestTooLarge: up front if their
+// Content-Length says so, otherwise once that much has been read. Zero or
+// less, the default, sets no limit.
+func WithMaxRequestBody(n int64) Option {
+	return func(lb *LoadBalancer) {
+		lb.maxRequestBody = max(n, 0)
+	}
+}
+
+// WithRequestTimeout abandons a proxy attempt whose backend hasn't finished
+// its response within d, so a hung backend can't tie up the connection
+// forever. The attempt counts as failed for passive health and outlier
+// detection and, for requests that are safe to replay, the next backend is
+// tried. If every attempt times out the client gets 504 Gateway Timeout. A
+// response whose headers were already sent is cut off instead. It is
+// disabled by default.
+func WithRequestTimeout(d time.Duration) Option {
+	return func(lb *LoadBalancer) {
+		if d > 0 {
+			lb.requestTimeout = d
+		}
+	}
+}
+
+// WithStrategy replaces the default round-robin selection strategy.
+func WithStrategy(s Strategy) Option {
+	return func(lb *LoadBalancer) {
+		if s != nil {
+			lb.strategy = s
+		}
+	}
+}
+
+// WithRandomStart starts the round-robin strategy at a random backend instead
+// of the first one, so a fleet of fresh balancers (per-test instances,
+// serverless cold starts) doesn't send every first request to the same
+// backend. See NewRoundRobin for the default order. It has no effect on other
+// strategies.
+func WithRandomStart() Option {
+	return func(lb *LoadBalancer) {
+		lb.randomStart = true
+	}
+}
+
+// WithRandSource makes the balancer's own random choices, such as slow-start
+// admission, access log sampling and WithRandomStart, draw from src, e.g. a
+// seeded rand.NewPCG for reproducible tests. The random strategies take
+// their source in their constructors; WithSeed seeds them and the balancer
+// at once. A nil source keeps the global one.
+func WithRandSource(src rand.Source) Option {
+	return func(lb *LoadBalancer) {
+		if src != nil {
+			lb.rng = rand.New(src)
+		}
+	}
+}
+
+// WithSeed derives every random choice the balancer makes from seed: the
+// picks of the random strategies, LeastResponseTime's choice among equally
+// fast backends, WithRandomStart, slow-start admission and access log
+// sampling. Balancers built with the same seed and options that
+// see the same sequence of requests route them identically, e.g. to compare
+// strategies in reproducible load tests; requests racing each other may still
+// draw in a different order. It takes precedence over WithRandSource and the
+// sources given to NewRandom, NewWeightedRandom and NewPowerOfTwoChoices.
+// Sticky session IDs keep coming from crypto/rand.
+func WithSeed(seed int64) Option {
+	return func(lb *LoadBalancer) {
+		lb.seed = &seed
+	}
+}
+
+// WithClock makes slow start, passive health expiry, outlier ejection and
+// the default sticky session store read the time from c instead of the real
+// clock, so tests can drive them with a clock.Fake. Backends are switched
+// to c as they join the pool; see backend.Backend.SetClock.
+func WithClock(c clock.Clock) Option {
+	return func(lb *LoadBalancer) {
+		lb.clock = c
+	}
+}
+
+// WithHealthChecker keeps hc in step with the pool: backends that join it
+// later, through AddBackend, SetBackends or discovery, are checked by hc too,
+// and those that leave it stop being checked. HealthChecker returns hc, and
+// Shutdown stops it.
+func WithHealthChecker(hc *healthcheck.HealthChecker) Option {
+	return func(lb *LoadBalancer) {
+		lb.checker = hc
+	}
+}
+
+// WithResolver makes ResolveHost look up hosts with r, e.g. a *net.Resolver
+// that queries a specific DNS server, instead of net.DefaultResolver.
+func WithResolver(r Resolver) Option {
+	return func(lb *LoadBalancer) {
+		lb.resolver = r
+	}
+}
+
+// WithMinHealthy makes selection fail closed with
+// ErrInsufficientHealthyBackends, which ServeHTTP answers with 503, while
+// fewer than n backends are healthy, rather than piling the whole load onto
+// the few survivors of a partial outage. It defaults to 1, i.e. any healthy
+// backend serves.
+func WithMinHealthy(n int) Option {
+	return func(lb *LoadBalancer) {
+		lb.minHealthy = n
+	}
+}
+
+// WithMinHealthyFraction is like WithMinHealthy with the threshold given as
+// a fraction of the pool, e.g. 0.5 to require half of the backends healthy.
+// The count is rounded up. If both are set, the stricter one applies.
+func WithMinHealthyFraction(f float64) Option {
+	return func(lb *LoadBalancer) {
+		lb.minHealthyFraction = min(f, 1)
+	}
+}
+
+// WithSelectUnknown lets backends that have not been health checked yet
+// (backend.Unknown) take traffic as if healthy, instead of the balancer
+// failing with ErrNoBackendsChecked until the first probes finish. The first
+// request sent to each such backend is logged as a warning. Backends found
+// dead stop receiving traffic as usual.
+func WithSelectUnknown() Option {
+	return func(lb *LoadBalancer) {
+		lb.selectUnknown = true
+	}
+}
+
+// WithHealthPolicy sets how disagreements between the active health check
+// and passive outcomes on proxied traffic are resolved.
+func WithHealthPolicy(p HealthPolicy) Option {
+	return func(lb *LoadBalancer) {
+		lb.healthPolicy = p
+	}
+}
+
+// WithPassiveHealthTTL sets how long a passive outcome stays authoritative
+// before the backend falls back to its active health check result.
+func WithPassiveHealthTTL(d time.Duration) Option {
+	return func(lb *LoadBalancer) {
+		if d > 0 {
+			lb.passiveHealthTTL = d
+		}
+	}
+}
+
+// WithSlowStart ramps a recovered backend's share of traffic linearly from
+// zero to its full share over d after it transitions from dead to alive, so
+// cold backends aren't overwhelmed. It is disabled by default.
+func WithSlowStart(d time.Duration) Option {
+	return func(lb *LoadBalancer) {
+		if d > 0 {
+			lb.slowStart = d
+		}
+	}
+}
+
+// WithStickySessions pins each client to the backend that served its first
+// request using a session cookie with the given name, which is HttpOnly,
+// SameSite=Lax and, on TLS connections, Secure. A client whose backend
+// becomes unavailable is re-pinned to a new one. Sessions are kept in memory
+// unless WithSessionStore says otherwise, and expire an hour after their last
+// request unless WithSessionTTL says otherwise.
+func WithStickySessions(cookieName string) Option {
+	return func(lb *LoadBalancer) {
+		lb.stickyCookie = cookieName
+	}
+}
+
+// WithSessionStore keeps sticky sessions in store instead of the default
+// MemorySessionStore, e.g. one shared by several balancer instances. It has
+// no effect without WithStickySessions.
+func WithSessionStore(store SessionStore) Option {
+	return func(lb *LoadBalancer) {
+		lb.sessionStore = store
+	}
+}
+
+// WithSessionTTL sets how long a sticky session lives after its last
+// request; zero or less keeps sessions until their backend leaves the pool.
+func WithSessionTTL(ttl time.Duration) Option {
+	return func(lb *LoadBalancer) {
+		lb.sessionTTL = ttl
+	}
+}
+
+// WithLatencySmoothing sets the EWMA weight in (0, 1] each new response
+// latency gets in the backends' AvgLatency, which LeastResponseTime routes on.
+// Higher values react to slowdowns faster but are noisier.
+func WithLatencySmoothing(alpha float64) Option {
+	return func(lb *LoadBalancer) {
+		if alpha > 0 && alpha <= 1 {
+			lb.latencySmoothing = alpha
+		}
+	}
+}
+
+// WithScoreCoefficients sets how the backends' HealthScore, which
+// ScoreWeighted routes on, weighs latency against errors.
+func WithScoreCoefficients(c backend.ScoreCoefficients) Option {
+	return func(lb *LoadBalancer) {
+		lb.scoreCoefficients = &c
+	}
+}
+
+// WithErrorRenderer replaces DefaultErrorRenderer for the error responses
+// ServeHTTP generates itself, such as when no backend can take the request.
+func WithErrorRenderer(fn ErrorRenderer) Option {
+	return func(lb *LoadBalancer) {
+		if fn != nil {
+			lb.errorRenderer = fn
+		}
+	}
+}
+
+// WithRequestClass classifies each request (e.g. "interactive" or "batch") so
+// that capacity reserved on a backend with ReserveCapacity is held back from
+// other classes. Requests the function returns "" for have no class of their
+// own and may only use unreserved capacity.
+func WithRequestClass(classify func(*http.Request) string) Option {
+	return func(lb *LoadBalancer) {
+		lb.classify = classify
+	}
+}
+
+// WithLogger sends the balancer's logs, and those of backends it manages that
+// have no logger of their own, to l instead of the standard library logger.
+func WithLogger(l logging.Logger) Option {
+	return func(lb *LoadBalancer) {
+		if l != nil {
+			lb.logger = l
+		}
+	}
+}
+
+// WithAccessLog logs one line per proxied request through the balancer's
+// logger, with the client address, method, path, backend that produced the
+// response, status, body size and duration. sampleRate is the fraction of
+// requests logged, from 0 (off, the default) to 1 (every request), so busy
+// deployments can keep the log volume down.
+func WithAccessLog(sampleRate float64) Option {
+	return func(lb *LoadBalancer) {
+		lb.accessLogRate = min(max(sampleRate, 0), 1)
+	}
+}
+
+// WithForwardedHeaders controls whether proxied requests carry the client's
+// address and the original host and scheme in X-Forwarded-For, X-Real-IP,
+// X-Forwarded-Host and X-Forwarded-Proto. It is enabled by default; disable
+// it for internal setups that must not see or trust these headers.
+func WithForwardedHeaders(enabled bool) Option {
+	return func(lb *LoadBalancer) {
+		lb.noForwardedHeaders = !enabled
+	}
+}
+
+// WithInsecureSkipVerify makes the balancer accept any TLS certificate from
+// HTTPS backends, e.g. the self-signed ones of a staging environment. It
+// turns off protection against impersonated backends, so it is logged as a
+// warning and should never be used in production. Backends whose
+// ReverseProxy already has a Transport keep it.
+func WithInsecureSkipVerify() Option {
+	return func(lb *LoadBalancer) {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		lb.insecureTransport = transport
+	}
+}
+
+// WithH2C proxies to every http backend over HTTP/2 cleartext (h2c) instead
+// of HTTP/1.1; see backend.Backend.EnableH2C. Backends whose ReverseProxy
+// already has a Transport keep it, and https backends are unaffected. To
+// switch single backends, call EnableH2C on them or set h2c in the config
+// file.
+func WithH2C() Option {
+	return func(lb *LoadBalancer) {
+		lb.h2c = true
+	}
+}
+
+// WithResponseHeaders rewrites the headers of every backend response, e.g. to
+// hide internal headers such as "Server: backend-v1" and add security headers
+// such as Strict-Transport-Security. Error responses generated by the
+// balancer itself are not affected. Hop-by-hop headers are always removed by
+// the proxy and need no rule.
+func WithResponseHeaders(rules HeaderRules) Option {
+	return func(lb *LoadBalancer) {
+		rules = rules.clone()
+		lb.responseHeaders = &rules
+	}
+}
+
+// WithOutlierDetection ejects backends whose share of failed requests (5xx
+// responses or transport errors) exceeds od.Threshold, even while their
+// health checks pass. It is disabled by default.
+func WithOutlierDetection(od OutlierDetection) Option {
+	return func(lb *LoadBalancer) {
+		od = od.withDefaults()
+		lb.outlierDetection = &od
+	}
+}
+
+// WithRateLimit caps the whole balancer at rps requests per second with
+// bursts of up to burst. ServeHTTP answers requests over the limit with 429
+// Too Many Requests and a Retry-After header. Per-backend limits are set with
+// Backend.SetRateLimit instead.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(lb *LoadBalancer) {
+		if rps > 0 {
+			lb.rateLimit = ratelimit.NewTokenBucket(rps, burst)
+		}
+	}
+}
+
+// WithQueue makes a selection that finds every backend at its MaxConnections
+// cap wait up to maxWait for a connection to be released instead of failing
+// at once with ErrAllBackendsSaturated. At most maxDepth selections wait at a
+// time; beyond that they fail immediately.
+func WithQueue(maxDepth int, maxWait time.Duration) Option {
+	return func(lb *LoadBalancer) {
+		if maxDepth > 0 && maxWait > 0 {
+			lb.queue = &requestQueue{maxDepth: int64(maxDepth), maxWait: maxWait}
+		}
+	}
+}
+
+// WithOfflineWait makes a selection that finds every backend offline wait up
+// to maxWait, or until its context is done if that comes first, for the
+// health checker to bring one back instead of failing at once. One that
+// gives up fails with an error wrapping ErrAllBackendsOffline, or with the
+// context's error if the context was cancelled. It is disabled by default.
+func WithOfflineWait(maxWait time.Duration) Option {
+	return func(lb *LoadBalancer) {
+		if maxWait > 0 {
+			lb.offlineWait = maxWait
+		}
+	}
+}
+
+// WithPriorities admits requests by the priority in a request header, so
+// bulk traffic marked low priority backs off first as backends fill up; see
+// PriorityPolicy. Without it every request has equal priority.
+func WithPriorities(p PriorityPolicy) Option {
+	return func(lb *LoadBalancer) {
+		if p.Reserve > 0 {
+			p.Reserve = min(p.Reserve, 1)
+			lb.priorities = &p
+		}
+	}
+}
+
+// WithRetryBudget limits retries to a share of recent requests; see
+// RetryBudget. Without it every retryable request may use all of its
+// WithMaxRetries retries.
+func WithRetryBudget(b RetryBudget) Option {
+	return func(lb *LoadBalancer) {
+		if b.Ratio >= 0 {
+			lb.retryBudget = newRetryBudget(b)
+		}
+	}
+}
+
+// WithHedging sends a second copy of eligible requests to another backend if
+// the first hasn't responded within p.Delay, and serves whichever response
+// completes first. Only requests that are safe to replay are ever hedged, and
+// a response larger than p.MaxBuffer is streamed as soon as it outgrows the
+// buffer, ending the race. It is disabled by default.
+func WithHedging(p HedgePolicy) Option {
+	return func(lb *LoadBalancer) {
+		if p.Delay > 0 {
+			lb.hedging = &p
+		}
+	}
+}
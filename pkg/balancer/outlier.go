@@ -0,0 +1,63 @@
+package balancer
+
+import (
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// OutlierDetection ejects backends that are up but keep failing requests,
+// e.g. answering 5xx, in the manner of Envoy's outlier detection. Zero fields
+// take the defaults noted below.
+type OutlierDetection struct {
+	// Threshold is the fraction of failed requests in (0, 1] above which a
+	// backend is ejected. Default 0.5.
+	Threshold float64
+
+	// Window is how far back failures are counted, in whole seconds up to a
+	// minute. Default 10s.
+	Window time.Duration
+
+	// MinRequests is how many requests a backend must have served within the
+	// window before it can be ejected. Default 20.
+	MinRequests int
+
+	// Cooldown is how long an ejected backend stays out of rotation before it
+	// is tentatively re-admitted with a clean slate. Default 30s.
+	Cooldown time.Duration
+}
+
+// withDefaults returns od with zero fields replaced by their defaults.
+func (od OutlierDetection) withDefaults() OutlierDetection {
+	if od.Threshold <= 0 || od.Threshold > 1 {
+		od.Threshold = 0.5
+	}
+	if od.Window <= 0 {
+		od.Window = 10 * time.Second
+	}
+	if od.MinRequests <= 0 {
+		od.MinRequests = 20
+	}
+	if od.Cooldown <= 0 {
+		od.Cooldown = 30 * time.Second
+	}
+	return od
+}
+
+// observeOutcome records how a request to b ended and ejects b if its error
+// rate has crossed the outlier threshold.
+func (lb *LoadBalancer) observeOutcome(b *backend.Backend, failed bool) {
+	b.RecordOutcome(failed)
+	od := lb.outlierDetection
+	if od == nil || !failed {
+		return
+	}
+
+	requests, errors := b.ErrorRate(od.Window)
+	if requests < od.MinRequests || float64(errors)/float64(requests) <= od.Threshold {
+		return
+	}
+	b.Eject(od.Cooldown)
+	lb.logger.Warn("ejecting backend with high error rate",
+		"backend", b.URL.Host, "requests", requests, "errors", errors, "cooldown", od.Cooldown)
+}
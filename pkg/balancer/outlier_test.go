@@ -0,0 +1,92 @@
+package balancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestOutlierDetection tests that a backend answering 5xx is ejected and
+// re-admitted after its cooldown
+func TestOutlierDetection(t *testing.T) {
+	var goodHits, badHits atomic.Int64
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		goodHits.Add(1)
+	}))
+	defer good.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		badHits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer bad.Close()
+
+	newPool := func() []*backend.Backend {
+		backends := []*backend.Backend{newTestBackend(t, good.URL), newTestBackend(t, bad.URL)}
+		for _, b := range backends {
+			b.SetAlive(true)
+		}
+		return backends
+	}
+	send := func(lb *LoadBalancer, n int) {
+		for i := 0; i < n; i++ {
+			lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+	}
+
+	t.Run("Disabled By Default", func(t *testing.T) {
+		backends := newPool()
+		lb, err := New(backends)
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		badHits.Store(0)
+		send(lb, 40)
+		if got := badHits.Load(); got != 20 {
+			t.Errorf("Expected the failing backend to keep its share of 20 requests, got %d", got)
+		}
+		if backends[1].IsEjected() {
+			t.Error("Expected no ejection without outlier detection")
+		}
+	})
+
+	t.Run("Ejects And Re-admits", func(t *testing.T) {
+		backends := newPool()
+		lb, err := New(backends, WithOutlierDetection(OutlierDetection{
+			Threshold:   0.5,
+			MinRequests: 5,
+			Cooldown:    200 * time.Millisecond,
+		}))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		badHits.Store(0)
+		send(lb, 40)
+		if got := badHits.Load(); got != 5 {
+			t.Errorf("Expected ejection after 5 failed requests, got %d", got)
+		}
+		if !backends[1].IsEjected() {
+			t.Fatal("Expected failing backend to be ejected")
+		}
+		if backends[0].IsEjected() {
+			t.Error("Healthy backend should not be ejected")
+		}
+		if len(lb.GetHealthyBackends()) != 1 {
+			t.Errorf("Expected ejected backend to be left out of healthy backends")
+		}
+
+		time.Sleep(250 * time.Millisecond)
+		if backends[1].IsEjected() {
+			t.Fatal("Expected backend to be re-admitted after cooldown")
+		}
+		badHits.Store(0)
+		send(lb, 2)
+		if got := badHits.Load(); got != 1 {
+			t.Errorf("Expected re-admitted backend to get traffic again, got %d requests", got)
+		}
+	})
+}
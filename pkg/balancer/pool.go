@@ -0,0 +1,208 @@
+package balancer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/metrics"
+)
+
+// Backends returns the current pool. The slice is a shared snapshot and must
+// not be modified.
+func (lb *LoadBalancer) Backends() []*backend.Backend {
+	return *lb.backends.Load()
+}
+
+// AddBackend adds b to the pool. It is safe to call while requests are being
+// selected; in-flight selections keep using the previous snapshot. The
+// balancer's health checker, from WithHealthChecker or the config file,
+// starts probing b as well.
+func (lb *LoadBalancer) AddBackend(b *backend.Backend) error {
+	lb.poolMu.Lock()
+	defer lb.poolMu.Unlock()
+
+	current := lb.Backends()
+	for _, existing := range current {
+		if existing.URL.String() == b.URL.String() {
+			return fmt.Errorf("%w: %s", ErrBackendExists, b.URL)
+		}
+	}
+
+	lb.attach(b)
+	pool := make([]*backend.Backend, len(current), len(current)+1)
+	copy(pool, current)
+	pool = append(pool, b)
+	lb.backends.Store(&pool)
+	if lb.checker != nil {
+		lb.checker.AddBackend(b)
+	}
+	return nil
+}
+
+// RemoveBackend removes the backend whose URL host matches host and returns
+// it. The balancer's health checker stops probing it.
+func (lb *LoadBalancer) RemoveBackend(host string) (*backend.Backend, error) {
+	lb.poolMu.Lock()
+	defer lb.poolMu.Unlock()
+
+	for _, existing := range lb.Backends() {
+		if existing.URL.Host == host {
+			lb.removeLocked(existing)
+			return existing, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrBackendNotFound, host)
+}
+
+// removeBackend removes b itself from the pool, where RemoveBackend would
+// take the first backend on b's host, and reports whether b was there.
+func (lb *LoadBalancer) removeBackend(b *backend.Backend) bool {
+	lb.poolMu.Lock()
+	defer lb.poolMu.Unlock()
+	return lb.removeLocked(b)
+}
+
+// removeLocked removes b from the pool if it is there and reports whether it
+// was. Callers must hold poolMu.
+func (lb *LoadBalancer) removeLocked(b *backend.Backend) bool {
+	current := lb.Backends()
+	i := slices.Index(current, b)
+	if i < 0 {
+		return false
+	}
+
+	pool := make([]*backend.Backend, 0, len(current)-1)
+	pool = append(pool, current[:i]...)
+	pool = append(pool, current[i+1:]...)
+	lb.backends.Store(&pool)
+	lb.detach(b)
+	return true
+}
+
+// SetBackends replaces the whole pool with backends, e.g. to follow a
+// service-discovery update. Backends already in the pool keep their state;
+// removed ones lose their sticky sessions. The health checker follows the
+// change as with AddBackend and RemoveBackend. The set must be non-empty and
+// free of duplicate URLs.
+func (lb *LoadBalancer) SetBackends(backends []*backend.Backend) error {
+	lb.poolMu.Lock()
+	defer lb.poolMu.Unlock()
+	return lb.setBackendsLocked(backends)
+}
+
+// setBackendsLocked is SetBackends for callers already holding poolMu.
+func (lb *LoadBalancer) setBackendsLocked(backends []*backend.Backend) error {
+	if len(backends) == 0 {
+		return ErrNoBackends
+	}
+	seen := make(map[string]bool, len(backends))
+	for _, b := range backends {
+		if seen[b.URL.String()] {
+			return fmt.Errorf("backend %s listed more than once", b.URL)
+		}
+		seen[b.URL.String()] = true
+	}
+
+	current := lb.Backends()
+	kept := make(map[*backend.Backend]bool, len(current))
+	for _, b := range current {
+		kept[b] = false
+	}
+
+	pool := make([]*backend.Backend, 0, len(backends))
+	for _, b := range backends {
+		if _, ok := kept[b]; ok {
+			kept[b] = true
+		} else {
+			lb.attach(b)
+			if lb.checker != nil {
+				lb.checker.AddBackend(b)
+			}
+		}
+		pool = append(pool, b)
+	}
+	lb.backends.Store(&pool)
+
+	for b, stillPresent := range kept {
+		if !stillPresent {
+			lb.detach(b)
+		}
+	}
+	return nil
+}
+
+// drainPollInterval is how often WaitDrained checks the in-flight count.
+const drainPollInterval = 10 * time.Millisecond
+
+// WaitDrained blocks until b has no requests in flight or ctx is done, in
+// which case it returns the context's error. Call b.Drain first so no new
+// requests are sent to it; a typical deploy drains, waits, then removes:
+//
+//	b.Drain()
+//	if err := lb.WaitDrained(ctx, b); err != nil { ... }
+//	lb.RemoveBackend(b.URL.Host)
+func (lb *LoadBalancer) WaitDrained(ctx context.Context, b *backend.Backend) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for b.ActiveConnections() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// attach wires b's ReverseProxy into the balancer's proxy path. It may run
+// more than once for the same backend, e.g. when it is added back after a
+// removal; the Director and ModifyResponse are only wrapped the first time.
+func (lb *LoadBalancer) attach(b *backend.Backend) {
+	b.ReverseProxy.ErrorHandler = proxyErrorHandler
+	if b.MarkProxyHooked() {
+		b.ReverseProxy.ModifyResponse = trackResponseBody(lb.rewriteResponseHeaders(b.ReverseProxy.ModifyResponse))
+		b.ReverseProxy.Director = lb.forwardedHeaders(b.ReverseProxy.Director)
+	}
+	if lb.h2c && b.ReverseProxy.Transport == nil {
+		b.EnableH2C()
+	}
+	if lb.insecureTransport != nil && b.ReverseProxy.Transport == nil {
+		b.ReverseProxy.Transport = lb.insecureTransport
+	}
+	if b.ReverseProxy.ErrorLog == nil {
+		b.SetLogger(lb.logger)
+	}
+	if lb.latencySmoothing > 0 {
+		b.SetLatencySmoothing(lb.latencySmoothing)
+	}
+	if lb.scoreCoefficients != nil {
+		b.SetScoreCoefficients(*lb.scoreCoefficients)
+	}
+	if lb.queue != nil {
+		b.OnConnectionReleased(lb.queue.notify)
+	}
+	if lb.clock != nil {
+		b.SetClock(lb.clock)
+	}
+}
+
+// detach lets go of b once it has left the pool: its sticky sessions, its
+// health checks and, unless a backend still in the pool shares its host
+// label, its metric series.
+func (lb *LoadBalancer) detach(b *backend.Backend) {
+	if lb.sticky != nil {
+		lb.sticky.unpinBackend(b)
+	}
+	if lb.checker != nil {
+		lb.checker.RemoveBackend(b)
+	}
+	sameHost := func(other *backend.Backend) bool { return other.URL.Host == b.URL.Host }
+	if !slices.ContainsFunc(lb.Backends(), sameHost) {
+		metrics.DeleteBackend(b.URL.Host)
+	}
+}
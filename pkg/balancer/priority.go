@@ -0,0 +1,61 @@
+package balancer
+
+import (
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// activeTier returns the lowest priority among backends in rotation, i.e.
+// available and not draining, optionally limited to those accepted by subset.
+// ok is false when no such backend is in rotation.
+func (lb *LoadBalancer) activeTier(subset func(*backend.Backend) bool) (tier int, ok bool) {
+	for _, b := range lb.Backends() {
+		if (subset != nil && !subset(b)) || !lb.isAvailable(b) || b.IsDraining() {
+			continue
+		}
+		if p := b.Priority(); !ok || p < tier {
+			tier, ok = p, true
+		}
+	}
+	return tier, ok
+}
+
+// noteTier logs when selections over the whole pool move to another priority
+// tier, so operators can tell when traffic fails over to fallback backends
+// and when it comes back.
+func (lb *LoadBalancer) noteTier(tier int) {
+	prev := lb.servingTier.Swap(int64(tier) + 1)
+	if prev == 0 || prev == int64(tier)+1 {
+		return
+	}
+	if from := int(prev - 1); tier > from {
+		lb.logger.Warn("every backend in the preferred priority tier is out of rotation, failing over", "tier", tier, "from", from)
+	} else {
+		lb.logger.Info("traffic is back on a preferred priority tier", "tier", tier, "from", from)
+	}
+}
+
+// inTier narrows available to backends in the given priority tier.
+func inTier(available func(*backend.Backend) bool, tier int) func(*backend.Backend) bool {
+	return func(b *backend.Backend) bool {
+		return b.Priority() == tier && available(b)
+	}
+}
+
+// GetHealthyBackendsInTier is like GetHealthyBackends but only returns
+// backends whose priority is tier.
+func (lb *LoadBalancer) GetHealthyBackendsInTier(tier int) []*backend.Backend {
+	healthy := []*backend.Backend{}
+	for _, b := range lb.GetHealthyBackends() {
+		if b.Priority() == tier {
+			healthy = append(healthy, b)
+		}
+	}
+	return healthy
+}
+
+// inActiveTier reports whether b belongs to the tier currently taking traffic
+// among the backends accepted by subset, or the whole pool if subset is nil.
+func (lb *LoadBalancer) inActiveTier(b *backend.Backend, subset func(*backend.Backend) bool) bool {
+	tier, ok := lb.activeTier(subset)
+	return !ok || b.Priority() == tier
+}
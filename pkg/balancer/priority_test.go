@@ -0,0 +1,140 @@
+package balancer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestPriorityFailover tests that traffic only reaches the fallback tier while
+// the whole primary tier is down
+func TestPriorityFailover(t *testing.T) {
+	primaries := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+	fallbacks := []*backend.Backend{
+		newTestBackend(t, "http://localhost:4000"),
+		newTestBackend(t, "http://localhost:4001"),
+	}
+	for _, b := range fallbacks {
+		b.SetPriority(1)
+	}
+	pool := append(append([]*backend.Backend{}, primaries...), fallbacks...)
+	for _, b := range pool {
+		b.SetAlive(true)
+	}
+
+	lb, err := New(pool)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	// expectTier asserts that a round of selections is spread over exactly tier
+	expectTier := func(t *testing.T, tier []*backend.Backend) {
+		t.Helper()
+		count := make(map[*backend.Backend]int)
+		for i := 0; i < 10*len(tier); i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			count[selected]++
+		}
+		for _, b := range tier {
+			if count[b] != 10 {
+				t.Errorf("Expected %s to get 10 requests, got %d", b.URL.Host, count[b])
+			}
+		}
+		if len(count) != len(tier) {
+			t.Errorf("Expected traffic on %d backends, got %d", len(tier), len(count))
+		}
+	}
+
+	t.Run("Primary Tier Serves Traffic", func(t *testing.T) {
+		expectTier(t, primaries)
+	})
+
+	t.Run("Partial Primary Outage Stays In Tier", func(t *testing.T) {
+		primaries[0].SetAlive(false)
+		defer primaries[0].SetAlive(true)
+		expectTier(t, primaries[1:])
+	})
+
+	t.Run("Fails Over When Primary Tier Dies", func(t *testing.T) {
+		for _, b := range primaries {
+			b.SetAlive(false)
+		}
+		expectTier(t, fallbacks)
+		if got := lb.GetHealthyBackendsInTier(0); len(got) != 0 {
+			t.Errorf("Expected no healthy primaries, got %d", len(got))
+		}
+		if got := lb.GetHealthyBackendsInTier(1); len(got) != 2 {
+			t.Errorf("Expected 2 healthy fallbacks, got %d", len(got))
+		}
+	})
+
+	t.Run("Shifts Back On Recovery", func(t *testing.T) {
+		primaries[1].SetAlive(true)
+		expectTier(t, primaries[1:])
+		primaries[0].SetAlive(true)
+		expectTier(t, primaries)
+	})
+
+	t.Run("All Tiers Down", func(t *testing.T) {
+		for _, b := range pool {
+			b.SetAlive(false)
+		}
+		defer func() {
+			for _, b := range pool {
+				b.SetAlive(true)
+			}
+		}()
+		if _, err := lb.SelectBackend(); !errors.Is(err, ErrAllBackendsOffline) {
+			t.Errorf("Expected ErrAllBackendsOffline, got %v", err)
+		}
+	})
+}
+
+// TestPriorityFailoverLogged tests that failing over to a fallback tier is logged once per failover
+func TestPriorityFailoverLogged(t *testing.T) {
+	primary := newTestBackend(t, "http://localhost:3000")
+	fallback := newTestBackend(t, "http://localhost:4000")
+	fallback.SetPriority(1)
+	primary.SetAlive(true)
+	fallback.SetAlive(true)
+
+	logger := &warnRecorder{}
+	lb, err := New([]*backend.Backend{primary, fallback}, WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	selectN := func(n int) {
+		for i := 0; i < n; i++ {
+			if _, err := lb.SelectBackend(); err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+		}
+	}
+
+	selectN(5)
+	if len(logger.messages) != 0 {
+		t.Fatalf("Expected no warnings while the primary serves, got %q", logger.messages)
+	}
+
+	primary.SetAlive(false)
+	selectN(5)
+	if len(logger.messages) != 1 {
+		t.Fatalf("Expected one failover warning, got %q", logger.messages)
+	}
+
+	primary.SetAlive(true)
+	selectN(5)
+	primary.SetAlive(false)
+	selectN(5)
+	if len(logger.messages) != 2 {
+		t.Errorf("Expected a second warning for the second failover, got %q", logger.messages)
+	}
+}
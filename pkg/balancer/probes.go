@@ -0,0 +1,32 @@
+package balancer
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ReadyHandler serves the balancer's own readiness, e.g. as a Kubernetes
+// readiness probe on /readyz: 200 while at least one backend is available,
+// 503 otherwise. The body reports how many of the backends are available.
+func (lb *LoadBalancer) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthy, total := len(lb.GetHealthyBackends()), len(lb.Backends())
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if healthy == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "not ready: %d/%d backends healthy\n", healthy, total)
+			return
+		}
+		fmt.Fprintf(w, "ready: %d/%d backends healthy\n", healthy, total)
+	})
+}
+
+// LiveHandler serves the process's liveness, e.g. as a Kubernetes liveness
+// probe on /livez. It always answers 200: an outage of every backend makes
+// the balancer unready, but restarting it would not help.
+func LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "ok")
+	})
+}
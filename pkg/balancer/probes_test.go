@@ -0,0 +1,52 @@
+package balancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestProbeHandlers tests the readiness and liveness endpoints as backends go down
+func TestProbeHandlers(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+	backends[0].SetAlive(true)
+
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	get := func(h http.Handler) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec
+	}
+
+	t.Run("Ready With One Backend", func(t *testing.T) {
+		rec := get(lb.ReadyHandler())
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "1/2") {
+			t.Errorf("Expected 200 reporting 1/2, got %d %q", rec.Code, rec.Body)
+		}
+	})
+
+	backends[0].SetAlive(false)
+
+	t.Run("Not Ready Without Backends", func(t *testing.T) {
+		rec := get(lb.ReadyHandler())
+		if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "0/2") {
+			t.Errorf("Expected 503 reporting 0/2, got %d %q", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("Live Regardless", func(t *testing.T) {
+		if rec := get(LiveHandler()); rec.Code != http.StatusOK {
+			t.Errorf("Expected 200, got %d", rec.Code)
+		}
+	})
+}
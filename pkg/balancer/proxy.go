@@ -0,0 +1,401 @@
+package balancer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/metrics"
+)
+
+// IdempotencyKeyHeader marks a request as safe to replay on another backend,
+// even when its method is not idempotent (e.g. POST).
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// attemptKey is the context key under which ServeHTTP stores the state of the
+// current proxy attempt so the ErrorHandler can report back instead of writing.
+type attemptKey struct{}
+
+// proxyAttempt records the outcome of a single ReverseProxy round trip.
+type proxyAttempt struct {
+	status   int   // status code of the backend's response, if one arrived
+	err      error // transport failure reported by the ReverseProxy
+	bodyErr  error // failure reading the backend body after the response was committed
+	timedOut bool  // the attempt ran past WithRequestTimeout
+}
+
+// errAttemptTimeout is the cause recorded on the context of a proxy attempt
+// that runs past WithRequestTimeout.
+var errAttemptTimeout = errors.New("backend did not respond within the request timeout")
+
+// ServeHTTP proxies the request to a backend chosen by SelectBackendForRequest.
+// If the transport fails before a response is received, requests that are
+// safe to replay are retried on another backend up to maxRetries times; a
+// body larger than WithMaxRetryBody is streamed to one backend instead.
+// Once response headers have reached the client the attempt is never retried,
+// since a second response would corrupt the one already in flight. Requests
+// over the WithRateLimit budget are rejected with 429 before any selection,
+// and bodies over WithMaxRequestBody with 413.
+// With WithRequestTimeout, an attempt that runs too long is abandoned like a
+// transport failure, and the client gets 504 if every attempt timed out.
+// With WithAccessLog, a line is logged for each (sampled) request.
+func (lb *LoadBalancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lb.serve(w, r, nil)
+}
+
+// serve implements ServeHTTP, restricted to the backends accepted by subset
+// if it is non-nil.
+func (lb *LoadBalancer) serve(w http.ResponseWriter, r *http.Request, subset func(*backend.Backend) bool) {
+	if lb.accessLogRate > 0 && lb.sampleAccess() {
+		var done func()
+		w, r, done = lb.beginAccessLog(w, r)
+		defer done()
+	}
+
+	if lb.rateLimit != nil && !lb.rateLimit.Allow() {
+		lb.renderError(w, r, &ProxyError{
+			Status:     http.StatusTooManyRequests,
+			Message:    http.StatusText(http.StatusTooManyRequests),
+			Code:       CodeTooManyRequests,
+			Retryable:  true,
+			RetryAfter: lb.rateLimit.Delay(),
+		})
+		return
+	}
+
+	if lb.retryBudget != nil {
+		lb.retryBudget.recordRequest(lb.now())
+	}
+	retryable := isRetryable(r)
+
+	if lb.maxRequestBody > 0 && r.Body != nil && r.Body != http.NoBody {
+		if r.ContentLength > lb.maxRequestBody {
+			lb.renderError(w, r, requestTooLargeError())
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, lb.maxRequestBody)
+	}
+
+	// Buffer the body so it can be replayed on the next backend
+	var body []byte
+	replayable := retryable
+	if retryable && r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, replayable, err = lb.bufferBody(r)
+		if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+			lb.renderError(w, r, requestTooLargeError())
+			return
+		}
+		if err != nil {
+			lb.renderError(w, r, &ProxyError{
+				Status:  http.StatusBadRequest,
+				Message: "failed to read request body",
+				Code:    CodeBadRequest,
+			})
+			return
+		}
+	}
+
+	if lb.hedging != nil && replayable && lb.hedging.applies(r) {
+		lb.serveHedged(w, r, body, subset)
+		return
+	}
+
+	maxAttempts := 1
+	if replayable {
+		maxAttempts += lb.maxRetries
+	}
+
+	var sessionID string
+	var pinned *backend.Backend
+	if lb.sticky != nil {
+		sessionID, pinned = lb.sticky.lookup(r)
+	}
+
+	cw := &committedWriter{ResponseWriter: w}
+	allTimedOut := true
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		// A pinned client keeps its backend until that backend fails it,
+		// leaves the pool or a preferred tier comes back
+		selected := pinned
+		if attempt > 1 || selected == nil || (subset != nil && !subset(selected)) ||
+			!lb.inActiveTier(selected, subset) ||
+			lb.lowPriority(r) && lb.inHighPriorityReserve(selected) ||
+			!slices.Contains(lb.Backends(), selected) ||
+			!lb.reservePinned(selected, lb.requestClass(r)) {
+			var err error
+			selected, err = lb.reserveBackend(r.Context(), r, subset)
+			if err != nil {
+				lb.renderError(w, r, selectionError(err))
+				return
+			}
+		}
+		noteBackend(r, selected)
+		if lb.sticky != nil && (selected != pinned || sessionID == "") {
+			sessionID = lb.sticky.pin(w, r, sessionID, selected)
+			pinned = selected
+		}
+
+		ctx, cancel := lb.attemptContext(r.Context())
+		outReq := r.WithContext(ctx)
+		if body != nil {
+			outReq.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		pa := lb.proxyTo(selected, cw, outReq)
+		cancel()
+		// A client that went away says nothing about the backend's health
+		if r.Context().Err() == nil {
+			selected.ReportPassive(pa.err == nil)
+		}
+		if pa.err == nil {
+			return
+		}
+		// The body streamed to the backend ran past WithMaxRequestBody
+		if tooLarge := new(http.MaxBytesError); errors.As(pa.err, &tooLarge) && !cw.committed {
+			lb.renderError(w, r, requestTooLargeError())
+			return
+		}
+
+		if cw.committed {
+			lb.recordPartialFailure(selected, r, pa.err)
+			return
+		}
+		allTimedOut = allTimedOut && pa.timedOut
+		if attempt < maxAttempts {
+			if lb.allowRetry() {
+				lb.logger.Warn("proxy attempt failed, retrying",
+					"method", r.Method, "path", r.URL.Path, "backend", selected.URL.Host, "attempt", attempt, "error", pa.err)
+				continue
+			}
+			lb.logger.Warn("retry budget exhausted, not retrying", "method", r.Method, "path", r.URL.Path)
+		}
+		lb.logger.Error("proxy attempt failed",
+			"method", r.Method, "path", r.URL.Path, "backend", selected.URL.Host, "attempt", attempt, "error", pa.err)
+		break
+	}
+
+	lb.renderError(w, r, attemptsFailedError(allTimedOut, retryable))
+}
+
+// attemptsFailedError is the error shown when no attempt produced a response:
+// 504 if they all timed out, 502 otherwise.
+func attemptsFailedError(timedOut, retryable bool) *ProxyError {
+	if timedOut {
+		return &ProxyError{
+			Status:    http.StatusGatewayTimeout,
+			Message:   http.StatusText(http.StatusGatewayTimeout),
+			Code:      CodeGatewayTimeout,
+			Retryable: retryable,
+		}
+	}
+	return &ProxyError{
+		Status:    http.StatusBadGateway,
+		Message:   http.StatusText(http.StatusBadGateway),
+		Code:      CodeBadGateway,
+		Retryable: retryable,
+	}
+}
+
+// attemptContext derives the context of a single proxy attempt, bounded by
+// WithRequestTimeout if set.
+func (lb *LoadBalancer) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if lb.requestTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, lb.requestTimeout, errAttemptTimeout)
+}
+
+// bufferBody reads r's body into memory so it can be replayed, and reports
+// true. A body larger than WithMaxRetryBody is not held: what was read is
+// put back in front of the rest, so r.Body streams to a single backend, and
+// bufferBody reports false.
+func (lb *LoadBalancer) bufferBody(r *http.Request) ([]byte, bool, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, lb.maxRetryBody+1))
+	if err != nil {
+		r.Body.Close()
+		return nil, false, err
+	}
+	if int64(len(body)) > lb.maxRetryBody {
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+		return nil, false, nil
+	}
+	r.Body.Close()
+	return body, true, nil
+}
+
+// requestTooLargeError is the error shown for a body over WithMaxRequestBody.
+func requestTooLargeError() *ProxyError {
+	return &ProxyError{
+		Status:  http.StatusRequestEntityTooLarge,
+		Message: http.StatusText(http.StatusRequestEntityTooLarge),
+		Code:    CodeRequestTooLarge,
+	}
+}
+
+// PartialFailures returns how many responses failed after they had already
+// been committed to the client.
+func (lb *LoadBalancer) PartialFailures() uint64 {
+	return lb.partialFailures.Load()
+}
+
+// reservePinned takes a connection slot on b, a client's pinned backend, if
+// it accepts new requests of the given class and is within its capacity and
+// rate limit, like reserveBackend does for the backend it selects.
+func (lb *LoadBalancer) reservePinned(b *backend.Backend, class string) bool {
+	return lb.isAvailable(b) && !b.IsDraining() && b.AllowRequest() && b.TryIncrementConnectionsFor(class)
+}
+
+// proxyTo runs a single attempt against b and reports its outcome. The
+// caller must have reserved a connection slot on b, e.g. with
+// reserveBackend; proxyTo releases it once the attempt ends.
+func (lb *LoadBalancer) proxyTo(b *backend.Backend, w *committedWriter, r *http.Request) (pa *proxyAttempt) {
+	defer b.DecrementConnections()
+	pa = &proxyAttempt{}
+	r = r.WithContext(context.WithValue(r.Context(), attemptKey{}, pa))
+
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+		// Under a real server the ReverseProxy aborts the connection when the
+		// backend body fails mid-copy; record it before letting that unwind.
+		if rec == http.ErrAbortHandler {
+			if pa.bodyErr != nil {
+				lb.recordPartialFailure(b, r, pa.bodyErr)
+			}
+			panic(rec)
+		}
+
+		// Any other panic, e.g. in a custom Director or ModifyResponse, fails
+		// this attempt like a transport error so it can be retried
+		pa.err = fmt.Errorf("panic while proxying: %v", rec)
+		metrics.RequestFailuresTotal.WithLabelValues(b.URL.Host).Inc()
+		lb.observeOutcome(b, true)
+		lb.logger.Error("recovered panic in proxy",
+			"method", r.Method, "path", r.URL.Path, "backend", b.URL.Host, "panic", rec, "stack", string(debug.Stack()))
+	}()
+
+	metrics.RequestsTotal.WithLabelValues(b.URL.Host).Inc()
+
+	start := time.Now()
+	b.ReverseProxy.ServeHTTP(w, r)
+	pa.timedOut = errors.Is(context.Cause(r.Context()), errAttemptTimeout)
+	if pa.err == nil {
+		b.RecordLatency(time.Since(start))
+	} else {
+		metrics.RequestFailuresTotal.WithLabelValues(b.URL.Host).Inc()
+	}
+	// An attempt abandoned by the client or a won hedge says nothing about b,
+	// unlike one that b took too long to answer
+	if r.Context().Err() == nil || pa.timedOut {
+		lb.observeOutcome(b, pa.err != nil || pa.timedOut || pa.status >= http.StatusInternalServerError)
+	}
+	if pa.bodyErr != nil {
+		lb.recordPartialFailure(b, r, pa.bodyErr)
+	}
+	return pa
+}
+
+// recordPartialFailure notes a response that failed after being committed.
+func (lb *LoadBalancer) recordPartialFailure(b *backend.Backend, r *http.Request, err error) {
+	lb.partialFailures.Add(1)
+	metrics.RequestFailuresTotal.WithLabelValues(b.URL.Host).Inc()
+	lb.logger.Error("response failed after it was committed, not retrying",
+		"method", r.Method, "path", r.URL.Path, "backend", b.URL.Host, "error", err)
+}
+
+// proxyErrorHandler is installed on every backend's ReverseProxy. Within
+// ServeHTTP it records the transport error so the caller can decide whether
+// to retry; outside of it, it falls back to a bare 502.
+func proxyErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	if pa, ok := r.Context().Value(attemptKey{}).(*proxyAttempt); ok {
+		pa.err = err
+		return
+	}
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+// trackResponseBody wraps modify so that the status and any body read errors
+// of a response during a ServeHTTP attempt are recorded on the attempt.
+func trackResponseBody(modify func(*http.Response) error) func(*http.Response) error {
+	return func(res *http.Response) error {
+		pa, ok := res.Request.Context().Value(attemptKey{}).(*proxyAttempt)
+		if ok {
+			pa.status = res.StatusCode
+		}
+		if modify != nil {
+			if err := modify(res); err != nil {
+				return err
+			}
+		}
+
+		// Upgraded connections need the raw io.ReadWriteCloser body
+		if res.StatusCode == http.StatusSwitchingProtocols {
+			return nil
+		}
+		if ok {
+			res.Body = &trackedBody{ReadCloser: res.Body, attempt: pa}
+		}
+		return nil
+	}
+}
+
+// trackedBody records non-EOF read errors from a backend response body.
+type trackedBody struct {
+	io.ReadCloser
+	attempt *proxyAttempt
+}
+
+func (tb *trackedBody) Read(p []byte) (int, error) {
+	n, err := tb.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		tb.attempt.bodyErr = err
+	}
+	return n, err
+}
+
+// committedWriter tracks whether a final response status has been sent.
+type committedWriter struct {
+	http.ResponseWriter
+	committed bool
+}
+
+func (cw *committedWriter) WriteHeader(code int) {
+	if code >= http.StatusOK {
+		cw.committed = true
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *committedWriter) Write(p []byte) (int, error) {
+	cw.committed = true
+	return cw.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer for Flush.
+func (cw *committedWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// isRetryable reports whether the request can be replayed on another backend
+// without risking duplicate side effects.
+func isRetryable(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return r.Header.Get(IdempotencyKeyHeader) != ""
+}
@@ -0,0 +1,100 @@
+package balancer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Machine-readable codes carried in ProxyError.Code.
+const (
+	CodeBadRequest             = "BAD_REQUEST"
+	CodeBadGateway             = "BAD_GATEWAY"
+	CodeGatewayTimeout         = "GATEWAY_TIMEOUT"
+	CodeAllBackendsDown        = "ALL_BACKENDS_DOWN"
+	CodeAllBackendsRateLimited = "ALL_BACKENDS_RATE_LIMITED"
+	CodeAllBackendsSaturated   = "ALL_BACKENDS_SATURATED"
+	CodeInsufficientHealthy    = "INSUFFICIENT_HEALTHY_BACKENDS"
+	CodeLowPriorityShed        = "LOW_PRIORITY_SHED"
+	CodeTooManyRequests        = "TOO_MANY_REQUESTS"
+	CodeRequestTooLarge        = "REQUEST_TOO_LARGE"
+)
+
+// ProxyError is a failure ServeHTTP reports to the client instead of a
+// backend response.
+type ProxyError struct {
+	Status    int    `json:"-"`
+	Message   string `json:"error"`
+	Code      string `json:"code"`
+	Retryable bool   `json:"retryable"`
+
+	// RetryAfter, if set, is sent to the client as a Retry-After header.
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *ProxyError) Error() string {
+	return fmt.Sprintf("%s (%d %s)", e.Message, e.Status, e.Code)
+}
+
+// ErrorRenderer writes e as the response to r. It is called before anything
+// else has been written to w; ServeHTTP sets any Retry-After header itself.
+type ErrorRenderer func(w http.ResponseWriter, r *http.Request, e *ProxyError)
+
+// renderError sets e's Retry-After header and hands it to the configured renderer.
+func (lb *LoadBalancer) renderError(w http.ResponseWriter, r *http.Request, e *ProxyError) {
+	if e.RetryAfter > 0 {
+		seconds := int(e.RetryAfter.Round(time.Second) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	}
+	lb.errorRenderer(w, r, e)
+}
+
+// DefaultErrorRenderer answers clients that accept application/json with
+// e encoded as a JSON object and everyone else with plain text.
+func DefaultErrorRenderer(w http.ResponseWriter, r *http.Request, e *ProxyError) {
+	if !acceptsJSON(r) {
+		http.Error(w, e.Message, e.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(e)
+}
+
+// acceptsJSON reports whether r's Accept header lists application/json.
+func acceptsJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, _, _ := strings.Cut(mediaRange, ";")
+			if strings.EqualFold(strings.TrimSpace(mediaType), "application/json") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectionError maps a backend selection failure to the error shown to the client.
+func selectionError(err error) *ProxyError {
+	switch {
+	case errors.Is(err, ErrAllBackendsRateLimited):
+		return &ProxyError{Status: http.StatusServiceUnavailable, Message: err.Error(), Code: CodeAllBackendsRateLimited, Retryable: true}
+	case errors.Is(err, ErrAllBackendsSaturated):
+		return &ProxyError{Status: http.StatusServiceUnavailable, Message: err.Error(), Code: CodeAllBackendsSaturated, Retryable: true, RetryAfter: time.Second}
+	case errors.Is(err, ErrLowPriorityShed):
+		return &ProxyError{Status: http.StatusServiceUnavailable, Message: err.Error(), Code: CodeLowPriorityShed, Retryable: true, RetryAfter: time.Second}
+	case errors.Is(err, ErrInsufficientHealthyBackends):
+		return &ProxyError{Status: http.StatusServiceUnavailable, Message: err.Error(), Code: CodeInsufficientHealthy, Retryable: true}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &ProxyError{Status: http.StatusGatewayTimeout, Message: err.Error(), Code: CodeGatewayTimeout, Retryable: true}
+	default:
+		return &ProxyError{Status: http.StatusServiceUnavailable, Message: err.Error(), Code: CodeAllBackendsDown, Retryable: true}
+	}
+}
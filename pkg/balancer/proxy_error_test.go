@@ -0,0 +1,84 @@
+package balancer
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestStructuredErrors tests that error responses are JSON only for clients that accept it
+func TestStructuredErrors(t *testing.T) {
+	b := newTestBackend(t, "http://localhost:3000")
+	b.SetAlive(false)
+
+	lb, err := New([]*backend.Backend{b})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("JSON Client", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", "text/html, application/json;q=0.9")
+		rec := httptest.NewRecorder()
+
+		lb.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503, got %d", rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected application/json, got %q", ct)
+		}
+
+		var body struct {
+			Error     string `json:"error"`
+			Code      string `json:"code"`
+			Retryable bool   `json:"retryable"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Response is not JSON: %v (%q)", err, rec.Body.String())
+		}
+		if body.Code != CodeAllBackendsDown || !body.Retryable || body.Error == "" {
+			t.Errorf("Unexpected error body: %+v", body)
+		}
+	})
+
+	t.Run("Plain Client", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+
+		lb.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503, got %d", rec.Code)
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+			t.Errorf("Expected text/plain, got %q", ct)
+		}
+		if !strings.Contains(rec.Body.String(), "all backends are offline") {
+			t.Errorf("Expected plain text error, got %q", rec.Body.String())
+		}
+	})
+
+	t.Run("Custom Renderer", func(t *testing.T) {
+		var rendered *ProxyError
+		lb, err := New([]*backend.Backend{b}, WithErrorRenderer(func(w http.ResponseWriter, r *http.Request, e *ProxyError) {
+			rendered = e
+			w.WriteHeader(e.Status)
+		}))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if rendered == nil || rendered.Code != CodeAllBackendsDown {
+			t.Errorf("Expected custom renderer to receive ALL_BACKENDS_DOWN, got %+v", rendered)
+		}
+	})
+}
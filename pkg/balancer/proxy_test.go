@@ -0,0 +1,370 @@
+package balancer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// newDeadServerURL returns the URL of a server that has already been closed,
+// so connecting to it fails with a transport error
+func newDeadServerURL() string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+	return server.URL
+}
+
+// TestIdempotencyKeyFailover tests that POSTs only fail over when they carry an Idempotency-Key
+func TestIdempotencyKeyFailover(t *testing.T) {
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer live.Close()
+	deadURL := newDeadServerURL()
+
+	newLB := func() *LoadBalancer {
+		backends := []*backend.Backend{
+			newTestBackend(t, deadURL),
+			newTestBackend(t, live.URL),
+		}
+		for _, b := range backends {
+			b.SetAlive(true)
+		}
+		lb, err := New(backends)
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		return lb
+	}
+
+	t.Run("POST With Key Fails Over", func(t *testing.T) {
+		lb := newLB()
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("payload"))
+		req.Header.Set(IdempotencyKeyHeader, "abc-123")
+		rec := httptest.NewRecorder()
+
+		lb.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200 after failover, got %d", rec.Code)
+		}
+		if rec.Body.String() != "payload" {
+			t.Errorf("Expected replayed body %q, got %q", "payload", rec.Body.String())
+		}
+	})
+
+	t.Run("POST Without Key Does Not Fail Over", func(t *testing.T) {
+		lb := newLB()
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("payload"))
+		rec := httptest.NewRecorder()
+
+		lb.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadGateway {
+			t.Errorf("Expected 502 without failover, got %d", rec.Code)
+		}
+	})
+
+	t.Run("GET Fails Over", func(t *testing.T) {
+		lb := newLB()
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		rec := httptest.NewRecorder()
+
+		lb.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected 200 after failover, got %d", rec.Code)
+		}
+	})
+}
+
+// TestMaxRetryBody tests that bodies over the buffering cap are streamed whole without failover
+func TestMaxRetryBody(t *testing.T) {
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer live.Close()
+	deadURL := newDeadServerURL()
+
+	newLB := func(urls ...string) *LoadBalancer {
+		var backends []*backend.Backend
+		for _, u := range urls {
+			b := newTestBackend(t, u)
+			b.SetAlive(true)
+			backends = append(backends, b)
+		}
+		lb, err := New(backends, WithMaxRetryBody(4))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		return lb
+	}
+
+	tests := []struct {
+		name         string
+		urls         []string
+		body         string
+		expectedCode int
+	}{
+		{"Small Body Fails Over", []string{deadURL, live.URL}, "tiny", http.StatusOK},
+		{"Large Body Is Not Retried", []string{deadURL, live.URL}, "payload", http.StatusBadGateway},
+		{"Large Body Streams Whole", []string{live.URL}, strings.Repeat("x", 1<<16), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/objects/1", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+
+			newLB(tt.urls...).ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedCode {
+				t.Fatalf("Expected %d, got %d", tt.expectedCode, rec.Code)
+			}
+			if rec.Code == http.StatusOK && rec.Body.String() != tt.body {
+				t.Errorf("Expected the backend to receive all %d bytes, got %d", len(tt.body), rec.Body.Len())
+			}
+		})
+	}
+}
+
+// TestMaxRequestBody tests that bodies over the limit are rejected with 413, whether or not they are buffered
+func TestMaxRequestBody(t *testing.T) {
+	var received atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received.Add(1)
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	b.SetAlive(true)
+	lb, err := New([]*backend.Backend{b}, WithMaxRequestBody(8))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		body          string
+		contentLength int64
+		expectedCode  int
+	}{
+		{"Within Limit", http.MethodPut, "small", 5, http.StatusOK},
+		{"Declared Too Large", http.MethodPut, "far too large", 13, http.StatusRequestEntityTooLarge},
+		{"Buffered Body Too Large", http.MethodPut, "far too large", -1, http.StatusRequestEntityTooLarge},
+		{"Streamed Body Too Large", http.MethodPost, "far too large", -1, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received.Store(0)
+			req := httptest.NewRequest(tt.method, "/upload", strings.NewReader(tt.body))
+			req.ContentLength = tt.contentLength
+			req.Header.Set("Accept", "application/json")
+			rec := httptest.NewRecorder()
+
+			lb.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedCode {
+				t.Fatalf("Expected %d, got %d", tt.expectedCode, rec.Code)
+			}
+			if tt.expectedCode == http.StatusRequestEntityTooLarge {
+				if !strings.Contains(rec.Body.String(), CodeRequestTooLarge) {
+					t.Errorf("Expected code %s, got %s", CodeRequestTooLarge, rec.Body)
+				}
+				if tt.method != http.MethodPost && received.Load() != 0 {
+					t.Error("Expected a body over the limit to be rejected before reaching a backend")
+				}
+			}
+		})
+	}
+}
+
+// TestPartialWriteIsNotRetried tests that a backend failing mid-body is recorded but never failed over
+func TestPartialWriteIsNotRetried(t *testing.T) {
+	partial := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+
+		// Drop the connection before the promised body is complete
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer partial.Close()
+
+	var fallbackHits atomic.Int64
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackHits.Add(1)
+		fmt.Fprint(w, "fallback")
+	}))
+	defer fallback.Close()
+
+	backends := []*backend.Backend{
+		newTestBackend(t, partial.URL),
+		newTestBackend(t, fallback.URL),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	rec := httptest.NewRecorder()
+	lb.ServeHTTP(rec, req)
+
+	if fallbackHits.Load() != 0 {
+		t.Errorf("Expected no failover after the response was committed, fallback got %d requests", fallbackHits.Load())
+	}
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected the committed 200 to be preserved, got %d", rec.Code)
+	}
+	if !strings.HasPrefix(rec.Body.String(), "partial") {
+		t.Errorf("Expected partial body to be preserved, got %q", rec.Body.String())
+	}
+	if got := lb.PartialFailures(); got != 1 {
+		t.Errorf("Expected 1 recorded partial failure, got %d", got)
+	}
+}
+
+// TestProxyPanicRecovery tests that a panic while proxying fails over instead of taking down the server
+func TestProxyPanicRecovery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	panicking := newTestBackend(t, server.URL)
+	panicking.ReverseProxy.ModifyResponse = func(*http.Response) error {
+		panic("broken response hook")
+	}
+	healthy := newTestBackend(t, server.URL)
+	for _, b := range []*backend.Backend{panicking, healthy} {
+		b.SetAlive(true)
+	}
+
+	lb, err := New([]*backend.Backend{panicking, healthy})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	front := httptest.NewServer(lb)
+	defer front.Close()
+
+	for i := 0; i < 4; i++ {
+		resp, err := http.Get(front.URL)
+		if err != nil {
+			t.Fatalf("Request %d failed, server went down: %v", i, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+			t.Errorf("Request %d: expected 200 ok after failover, got %d %q", i, resp.StatusCode, body)
+		}
+	}
+
+	if ok, _ := panicking.PassiveHealth(); ok {
+		t.Error("Expected the panicking backend to be reported passively unhealthy")
+	}
+}
+
+// TestInsecureSkipVerify tests that self-signed HTTPS backends are only reachable when opted in
+func TestInsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		status int
+	}{
+		{"Verified By Default", nil, http.StatusBadGateway},
+		{"Skipped When Opted In", []Option{WithInsecureSkipVerify()}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBackend(t, server.URL)
+			b.SetAlive(true)
+			lb, err := New([]*backend.Backend{b}, append(tt.opts, WithMaxRetries(0))...)
+			if err != nil {
+				t.Fatalf("Failed to create load balancer: %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+// TestH2C tests that backends are proxied over cleartext HTTP/2 only when opted in
+func TestH2C(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Trailer", "X-Body-Length")
+		fmt.Fprintf(w, "%s %s", r.Proto, body)
+		w.Header().Set("X-Body-Length", fmt.Sprint(len(body)))
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	tests := []struct {
+		name  string
+		opts  []Option
+		proto string
+	}{
+		{"HTTP/1 By Default", nil, "HTTP/1.1"},
+		{"H2C When Opted In", []Option{WithH2C()}, "HTTP/2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBackend(t, server.URL)
+			b.SetAlive(true)
+			lb, err := New([]*backend.Backend{b}, tt.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create load balancer: %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			lb.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello")))
+			resp := rec.Result()
+			if got, want := rec.Body.String(), tt.proto+" hello"; got != want {
+				t.Errorf("Expected body %q, got %q", want, got)
+			}
+			if got := resp.Trailer.Get("X-Body-Length"); got != "5" {
+				t.Errorf("Expected trailer X-Body-Length 5, got %q", got)
+			}
+		})
+	}
+
+	t.Run("HTTPS Backends Are Left Alone", func(t *testing.T) {
+		b := newTestBackend(t, "https://localhost:3000")
+		b.EnableH2C()
+		if b.H2C() || b.ReverseProxy.Transport != nil {
+			t.Error("Expected EnableH2C to ignore an https backend")
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// requestQueue holds selections that found every backend at its connection
+// cap until one frees up.
+type requestQueue struct {
+	maxDepth int64
+	maxWait  time.Duration
+	depth    atomic.Int64
+
+	mu    sync.Mutex
+	freed chan struct{} // closed and replaced when a connection is released
+}
+
+// released returns a channel that is closed the next time any backend
+// releases a connection.
+func (q *requestQueue) released() <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.freed == nil {
+		q.freed = make(chan struct{})
+	}
+	return q.freed
+}
+
+// notify wakes every queued selection.
+func (q *requestQueue) notify() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.freed != nil {
+		close(q.freed)
+		q.freed = nil
+	}
+}
+
+// wait retries try each time a connection is released until it finds a
+// backend with spare capacity, maxWait passes or ctx is done. It fails with
+// ErrAllBackendsSaturated at once if the queue is already full.
+func (q *requestQueue) wait(ctx context.Context, try func() (*backend.Backend, error)) (*backend.Backend, error) {
+	if q.depth.Add(1) > q.maxDepth {
+		q.depth.Add(-1)
+		return nil, ErrAllBackendsSaturated
+	}
+	defer q.depth.Add(-1)
+
+	timer := time.NewTimer(q.maxWait)
+	defer timer.Stop()
+	for {
+		// Subscribe before retrying so a release in between isn't missed
+		freed := q.released()
+		selected, err := try()
+		if !errors.Is(err, ErrAllBackendsSaturated) {
+			return selected, err
+		}
+
+		select {
+		case <-freed:
+		case <-timer.C:
+			return nil, ErrAllBackendsSaturated
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// QueueDepth returns how many selections are currently waiting for a backend
+// to free up capacity.
+func (lb *LoadBalancer) QueueDepth() int {
+	if lb.queue == nil {
+		return 0
+	}
+	return int(lb.queue.depth.Load())
+}
@@ -0,0 +1,77 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestRequestQueue tests that saturated selections wait for capacity within the queue's bounds
+func TestRequestQueue(t *testing.T) {
+	b := newTestBackend(t, "http://localhost:3000")
+	b.SetAlive(true)
+	b.SetMaxConnections(1)
+	b.IncrementConnections()
+
+	lb, err := New([]*backend.Backend{b}, WithQueue(1, 300*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("Waits For Released Connection", func(t *testing.T) {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			b.DecrementConnections()
+		}()
+		start := time.Now()
+		selected, err := lb.SelectBackend()
+		if err != nil || selected != b {
+			t.Fatalf("Expected queued selection to get the backend, got %v, %v", selected, err)
+		}
+		if elapsed := time.Since(start); elapsed < 40*time.Millisecond || elapsed > 250*time.Millisecond {
+			t.Errorf("Expected selection to return once the connection was released, took %v", elapsed)
+		}
+		b.IncrementConnections()
+	})
+
+	t.Run("Times Out", func(t *testing.T) {
+		start := time.Now()
+		if _, err := lb.SelectBackend(); !errors.Is(err, ErrAllBackendsSaturated) {
+			t.Fatalf("Expected ErrAllBackendsSaturated, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+			t.Errorf("Expected selection to wait the full 300ms, took %v", elapsed)
+		}
+	})
+
+	t.Run("Context Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+		if _, err := lb.SelectBackendContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("Full Queue Fails Fast", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			lb.SelectBackend()
+		}()
+		for lb.QueueDepth() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		start := time.Now()
+		if _, err := lb.SelectBackend(); !errors.Is(err, ErrAllBackendsSaturated) {
+			t.Errorf("Expected ErrAllBackendsSaturated, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+			t.Errorf("Expected a full queue to fail fast, took %v", elapsed)
+		}
+		<-done
+	})
+}
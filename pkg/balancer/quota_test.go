@@ -0,0 +1,81 @@
+package balancer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestClassReservations tests that batch traffic cannot consume capacity reserved for interactive requests
+func TestClassReservations(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+		b.SetMaxConnections(10)
+		b.ReserveCapacity("interactive", 0.2)
+	}
+
+	lb, err := New(backends, WithRequestClass(func(r *http.Request) string {
+		return r.Header.Get("X-Request-Class")
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	// hold selects a backend for a request of class and keeps a connection open on it
+	hold := func(class string) (*backend.Backend, error) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Class", class)
+		selected, err := lb.SelectBackendForRequest(req)
+		if err == nil {
+			selected.IncrementConnections()
+		}
+		return selected, err
+	}
+
+	t.Run("Batch Stops At Unreserved Capacity", func(t *testing.T) {
+		count := make(map[*backend.Backend]int)
+		for {
+			selected, err := hold("batch")
+			if errors.Is(err, ErrAllBackendsSaturated) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			count[selected]++
+		}
+
+		for i, b := range backends {
+			if count[b] != 8 {
+				t.Errorf("Backend %d: batch got %d connections, expected 8", i, count[b])
+			}
+		}
+	})
+
+	t.Run("Interactive Finds Reserved Capacity", func(t *testing.T) {
+		count := make(map[*backend.Backend]int)
+		for i := 0; i < 4; i++ {
+			selected, err := hold("interactive")
+			if err != nil {
+				t.Fatalf("Interactive request %d found no capacity: %v", i, err)
+			}
+			count[selected]++
+		}
+
+		for i, b := range backends {
+			if count[b] != 2 {
+				t.Errorf("Backend %d: interactive got %d connections, expected 2", i, count[b])
+			}
+		}
+		if _, err := hold("interactive"); !errors.Is(err, ErrAllBackendsSaturated) {
+			t.Errorf("Expected ErrAllBackendsSaturated once every backend is full, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package balancer
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// Random picks uniformly among the available backends. It is meant for load
+// testing, where a non-deterministic spread is wanted.
+type Random struct {
+	randSource
+}
+
+// NewRandom creates a random strategy drawing from src. Pass a seeded source
+// such as rand.NewPCG to make the sequence of picks reproducible; draws from
+// it are serialized. With a nil src it draws from the global source, which
+// needs no lock and doesn't slow down under concurrent picks.
+func NewRandom(src rand.Source) *Random {
+	rs := &Random{}
+	rs.setSource(src)
+	return rs
+}
+
+// Pick returns a uniformly chosen available backend.
+func (rs *Random) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	candidates := make([]*backend.Backend, 0, len(backends))
+	for _, b := range backends {
+		if available(b) {
+			candidates = append(candidates, b)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, ErrAllBackendsOffline
+	case 1:
+		return candidates[0], nil
+	}
+	return candidates[rs.intN(len(candidates))], nil
+}
+
+// WeightedRandom picks among the available backends with probability
+// proportional to their weight, or to their MaxRPS where one is declared,
+// like WeightedRoundRobin but without its fixed interleaving.
+type WeightedRandom struct {
+	randSource
+}
+
+// NewWeightedRandom creates a weighted random strategy drawing from src, or
+// from the global source if src is nil, like NewRandom.
+func NewWeightedRandom(src rand.Source) *WeightedRandom {
+	wr := &WeightedRandom{}
+	wr.setSource(src)
+	return wr
+}
+
+// Pick returns an available backend chosen in proportion to its weight.
+func (wr *WeightedRandom) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	candidates := make([]*backend.Backend, 0, len(backends))
+	total := 0.0
+	for _, b := range backends {
+		if available(b) {
+			candidates = append(candidates, b)
+			total += effectiveWeight(b)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, ErrAllBackendsOffline
+	case 1:
+		// Nothing to weigh
+		return candidates[0], nil
+	}
+
+	target := wr.float64() * total
+	for _, b := range candidates {
+		target -= effectiveWeight(b)
+		if target < 0 {
+			return b, nil
+		}
+	}
+	// Rounding can leave target at a hair above zero
+	return candidates[len(candidates)-1], nil
+}
+
+// PowerOfTwoChoices draws two different available backends at random and
+// routes to the one with fewer requests in flight, or the first drawn if they
+// tie. It spreads load nearly as evenly as LeastConnections while only ever
+// comparing two backends, so a burst of concurrent picks doesn't all land on
+// the single least loaded one.
+type PowerOfTwoChoices struct {
+	randSource
+}
+
+// NewPowerOfTwoChoices creates a power-of-two-choices strategy drawing from
+// src, or from the global source if src is nil, like NewRandom.
+func NewPowerOfTwoChoices(src rand.Source) *PowerOfTwoChoices {
+	p := &PowerOfTwoChoices{}
+	p.setSource(src)
+	return p
+}
+
+// Pick returns the less busy of two randomly drawn available backends.
+func (p *PowerOfTwoChoices) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	candidates := make([]*backend.Backend, 0, len(backends))
+	for _, b := range backends {
+		if available(b) {
+			candidates = append(candidates, b)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil, ErrAllBackendsOffline
+	case 1:
+		return candidates[0], nil
+	}
+
+	i := p.intN(len(candidates))
+	// Draw the second from the others so the two always differ
+	j := p.intN(len(candidates) - 1)
+	if j >= i {
+		j++
+	}
+	first, second := candidates[i], candidates[j]
+	if second.ActiveConnections() < first.ActiveConnections() {
+		return second, nil
+	}
+	return first, nil
+}
+
+// randSource draws the random numbers of a strategy from a seeded source, or
+// from the global source when none is set.
+type randSource struct {
+	mu  sync.Mutex // serializes draws from rng, which isn't safe for concurrent use
+	rng atomic.Pointer[rand.Rand]
+}
+
+// setSource makes later draws come from src; nil selects the global source.
+// It implements seedable for WithSeed.
+func (s *randSource) setSource(src rand.Source) {
+	if src == nil {
+		s.rng.Store(nil)
+		return
+	}
+	s.rng.Store(rand.New(src))
+}
+
+// intN draws from [0, n).
+func (s *randSource) intN(n int) int {
+	rng := s.rng.Load()
+	if rng == nil {
+		return rand.IntN(n)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return rng.IntN(n)
+}
+
+// float64 draws from [0, 1).
+func (s *randSource) float64() float64 {
+	rng := s.rng.Load()
+	if rng == nil {
+		return rand.Float64()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return rng.Float64()
+}
+
+// seedable is implemented by strategies that make random choices, so that
+// WithSeed can replace their source with one derived from its seed.
+type seedable interface {
+	setSource(src rand.Source)
+}
+
+// Streams of a WithSeed seed, so the balancer's draws and the strategy's
+// don't shift each other's sequences.
+const (
+	balancerStream uint64 = iota
+	strategyStream
+)
+
+// applySeed gives the balancer and its strategy sources derived from seed.
+func (lb *LoadBalancer) applySeed(seed int64) {
+	lb.rng = rand.New(rand.NewPCG(uint64(seed), balancerStream))
+	if s, ok := lb.strategy.(seedable); ok {
+		s.setSource(rand.NewPCG(uint64(seed), strategyStream))
+	}
+}
+
+// randFloat64 draws from the WithSeed or WithRandSource source, or the
+// global one.
+func (lb *LoadBalancer) randFloat64() float64 {
+	if lb.rng == nil {
+		return rand.Float64()
+	}
+	lb.rngMu.Lock()
+	defer lb.rngMu.Unlock()
+	return lb.rng.Float64()
+}
+
+// randUint64N draws from [0, n) like randFloat64.
+func (lb *LoadBalancer) randUint64N(n uint64) uint64 {
+	if lb.rng == nil {
+		return rand.Uint64N(n)
+	}
+	lb.rngMu.Lock()
+	defer lb.rngMu.Unlock()
+	return lb.rng.Uint64N(n)
+}
@@ -0,0 +1,270 @@
+package balancer
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestRandom tests uniform random selection over alive backends
+func TestRandom(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	t.Run("Skip Dead Backends", func(t *testing.T) {
+		backends[1].SetAlive(false)
+		defer backends[1].SetAlive(true)
+
+		lb, err := New(backends, WithStrategy(NewRandom(rand.NewPCG(1, 2))))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		count := make(map[*backend.Backend]int)
+		for i := 0; i < 3000; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			count[selected]++
+		}
+		if count[backends[1]] != 0 {
+			t.Errorf("Dead backend was selected %d times", count[backends[1]])
+		}
+		for _, i := range []int{0, 2} {
+			if math.Abs(float64(count[backends[i]])-1500) > 150 {
+				t.Errorf("Backend %d: got %d requests, expected about 1500", i, count[backends[i]])
+			}
+		}
+	})
+
+	t.Run("Single Alive Backend", func(t *testing.T) {
+		backends[0].SetAlive(false)
+		backends[1].SetAlive(false)
+		defer backends[0].SetAlive(true)
+		defer backends[1].SetAlive(true)
+
+		lb, err := New(backends, WithStrategy(NewRandom(nil)))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		for i := 0; i < 10; i++ {
+			if selected, err := lb.SelectBackend(); err != nil || selected != backends[2] {
+				t.Fatalf("Expected the only alive backend, got %v, %v", selected, err)
+			}
+		}
+	})
+
+	t.Run("Deterministic With Seeded Source", func(t *testing.T) {
+		picks := func() []*backend.Backend {
+			strategy := NewRandom(rand.NewPCG(42, 42))
+			var seq []*backend.Backend
+			for i := 0; i < 20; i++ {
+				b, _ := strategy.Pick(backends, func(*backend.Backend) bool { return true })
+				seq = append(seq, b)
+			}
+			return seq
+		}
+		first, second := picks(), picks()
+		for i := range first {
+			if first[i] != second[i] {
+				t.Fatalf("Pick %d differs between identically seeded strategies", i)
+			}
+		}
+	})
+}
+
+// TestWeightedRandom tests that random picks follow backend weights
+func TestWeightedRandom(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+	for i, b := range backends {
+		b.SetAlive(true)
+		b.SetWeight(i + 1)
+	}
+	backends[2].SetAlive(false)
+
+	lb, err := New(backends, WithStrategy(NewWeightedRandom(rand.NewPCG(7, 7))))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	count := make(map[*backend.Backend]int)
+	for i := 0; i < 3000; i++ {
+		selected, err := lb.SelectBackend()
+		if err != nil {
+			t.Fatalf("SelectBackend failed: %v", err)
+		}
+		count[selected]++
+	}
+
+	if count[backends[2]] != 0 {
+		t.Errorf("Dead backend was selected %d times", count[backends[2]])
+	}
+	// Weights 1:2 over the alive backends
+	if math.Abs(float64(count[backends[0]])-1000) > 100 || math.Abs(float64(count[backends[1]])-2000) > 100 {
+		t.Errorf("Expected about 1000/2000 split, got %d/%d", count[backends[0]], count[backends[1]])
+	}
+}
+
+// TestSeed tests that balancers built with the same seed make the same selections
+func TestSeed(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+		newTestBackend(t, "http://localhost:3003"),
+	}
+	for i, b := range backends {
+		b.SetAlive(true)
+		b.SetWeight(i + 1)
+	}
+
+	tests := []struct {
+		name    string
+		options func() []Option
+		// Whether another seed surely changes the picks; a random start
+		// lands on the same backend for a quarter of the seeds
+		seedChangesPicks bool
+	}{
+		{"Random", func() []Option { return []Option{WithStrategy(NewRandom(nil))} }, true},
+		{"Weighted Random", func() []Option { return []Option{WithStrategy(NewWeightedRandom(rand.NewPCG(7, 7)))} }, true},
+		{"Power Of Two Choices", func() []Option { return []Option{WithStrategy(NewPowerOfTwoChoices(nil))} }, true},
+		{"Random Start", func() []Option { return []Option{WithRandomStart()} }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			picks := func(seed int64) []string {
+				t.Helper()
+				lb, err := New(backends, append(tt.options(), WithSeed(seed))...)
+				if err != nil {
+					t.Fatalf("Failed to create load balancer: %v", err)
+				}
+				var hosts []string
+				for i := 0; i < 200; i++ {
+					selected, err := lb.SelectBackend()
+					if err != nil {
+						t.Fatalf("SelectBackend failed: %v", err)
+					}
+					hosts = append(hosts, selected.URL.Host)
+				}
+				return hosts
+			}
+
+			first, again := picks(42), picks(42)
+			if !slices.Equal(first, again) {
+				t.Error("Expected the same seed to produce the same selections")
+			}
+			if tt.seedChangesPicks && slices.Equal(first, picks(43)) {
+				t.Error("Expected another seed to produce other selections")
+			}
+		})
+	}
+}
+
+// TestPowerOfTwoChoices tests that the less busy of two distinct random backends is picked
+func TestPowerOfTwoChoices(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+	lb, err := New(backends, WithStrategy(NewPowerOfTwoChoices(rand.NewPCG(1, 2))))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	pickCounts := func(t *testing.T) map[*backend.Backend]int {
+		t.Helper()
+		count := make(map[*backend.Backend]int)
+		for i := 0; i < 3000; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			count[selected]++
+		}
+		return count
+	}
+
+	t.Run("Even When Idle", func(t *testing.T) {
+		count := pickCounts(t)
+		for i, b := range backends {
+			if math.Abs(float64(count[b])-1000) > 150 {
+				t.Errorf("Backend %d: got %d requests, expected about 1000", i, count[b])
+			}
+		}
+	})
+
+	t.Run("Busiest Never Picked", func(t *testing.T) {
+		// Both draws differ, so the busiest always loses to the other one
+		for i := 0; i < 5; i++ {
+			backends[0].IncrementConnections()
+			defer backends[0].DecrementConnections()
+		}
+		backends[1].IncrementConnections()
+		defer backends[1].DecrementConnections()
+
+		count := pickCounts(t)
+		if count[backends[0]] != 0 {
+			t.Errorf("Expected the busiest backend never to be picked, got %d", count[backends[0]])
+		}
+		// The middle one only wins against the busiest: a third of the draws
+		if math.Abs(float64(count[backends[1]])-1000) > 150 {
+			t.Errorf("Expected about 1000 picks of the less busy backend, got %d", count[backends[1]])
+		}
+	})
+}
+
+// BenchmarkRandomStrategies compares the throughput of round-robin and the random strategies under parallel picks
+func BenchmarkRandomStrategies(b *testing.B) {
+	strategies := []struct {
+		name string
+		new  func() Strategy
+	}{
+		{"RoundRobin", func() Strategy { return NewRoundRobin() }},
+		{"Random", func() Strategy { return NewRandom(nil) }},
+		{"RandomSeeded", func() Strategy { return NewRandom(rand.NewPCG(1, 2)) }},
+		{"PowerOfTwoChoices", func() Strategy { return NewPowerOfTwoChoices(nil) }},
+	}
+	backends := make([]*backend.Backend, 8)
+	for i := range backends {
+		be, err := backend.NewBackend(fmt.Sprintf("http://10.0.0.%d:8080", i))
+		if err != nil {
+			b.Fatalf("Failed to create backend: %v", err)
+		}
+		be.SetAlive(true)
+		backends[i] = be
+	}
+
+	for _, s := range strategies {
+		b.Run(s.name, func(b *testing.B) {
+			lb, err := New(backends, WithStrategy(s.new()))
+			if err != nil {
+				b.Fatalf("Failed to create load balancer: %v", err)
+			}
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					lb.SelectBackend()
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package balancer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestBackendRateLimit tests that a backend over its rate limit is skipped during selection
+func TestBackendRateLimit(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+	backends[0].SetRateLimit(1, 2)
+
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	count := make(map[*backend.Backend]int)
+	for i := 0; i < 10; i++ {
+		selected, err := lb.SelectBackend()
+		if err != nil {
+			t.Fatalf("SelectBackend failed: %v", err)
+		}
+		count[selected]++
+	}
+	if count[backends[0]] != 2 || count[backends[1]] != 8 {
+		t.Errorf("Expected the limited backend to stop after its burst of 2, got %d/%d",
+			count[backends[0]], count[backends[1]])
+	}
+	if w := backends[0].Weight(); w != 1 {
+		t.Errorf("Expected rate limit to leave weight alone, got %d", w)
+	}
+
+	t.Run("All Backends Limited", func(t *testing.T) {
+		backends[1].SetRateLimit(1, 1)
+		defer backends[1].SetRateLimit(0, 0)
+		lb.SelectBackend()
+		if _, err := lb.SelectBackend(); !errors.Is(err, ErrAllBackendsRateLimited) {
+			t.Errorf("Expected ErrAllBackendsRateLimited, got %v", err)
+		}
+	})
+
+	t.Run("Pinned Client Falls Back", func(t *testing.T) {
+		const cookieName = "lb_session"
+		pinned := []*backend.Backend{
+			newTestBackend(t, newNamedServer(t, "backend-0").URL),
+			newTestBackend(t, newNamedServer(t, "backend-1").URL),
+		}
+		for _, b := range pinned {
+			b.SetAlive(true)
+		}
+		pinned[0].SetRateLimit(1, 2)
+		lb, err := New(pinned, WithStickySessions(cookieName))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		// Round-robin pins the first session to backend-0, which serves its
+		// burst of 2 before the client is moved over
+		var session string
+		var served []string
+		for i := 0; i < 4; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if session != "" {
+				req.AddCookie(&http.Cookie{Name: cookieName, Value: session})
+			}
+			rec := httptest.NewRecorder()
+			lb.ServeHTTP(rec, req)
+			for _, c := range rec.Result().Cookies() {
+				if c.Name == cookieName {
+					session = c.Value
+				}
+			}
+			served = append(served, rec.Body.String())
+		}
+		want := []string{"backend-0", "backend-0", "backend-1", "backend-1"}
+		if !slices.Equal(served, want) {
+			t.Errorf("Expected requests served by %v, got %v", want, served)
+		}
+	})
+}
+
+// TestGlobalRateLimit tests that requests over the balancer's budget get 429 with Retry-After
+func TestGlobalRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	b := newTestBackend(t, server.URL)
+	b.SetAlive(true)
+	lb, err := New([]*backend.Backend{b}, WithRateLimit(0.5, 3))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	codes := make(map[int]int)
+	var retryAfter string
+	for i := 0; i < 5; i++ {
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes[rec.Code]++
+		if rec.Code == http.StatusTooManyRequests {
+			retryAfter = rec.Header().Get("Retry-After")
+		}
+	}
+
+	if codes[http.StatusOK] != 3 || codes[http.StatusTooManyRequests] != 2 {
+		t.Errorf("Expected 3 requests served and 2 rejected, got %v", codes)
+	}
+	// The next token is 2s away at 0.5 requests per second
+	if seconds, err := strconv.Atoi(retryAfter); err != nil || seconds < 1 || seconds > 2 {
+		t.Errorf("Expected Retry-After of 1-2 seconds, got %q", retryAfter)
+	}
+}
@@ -0,0 +1,189 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+)
+
+// reloadDrainTimeout bounds how long a backend dropped by Reload may keep
+// serving in-flight requests before it is removed anyway.
+const reloadDrainTimeout = 30 * time.Second
+
+// errNotFromConfig is returned by Reload on balancers built without a config file.
+var errNotFromConfig = errors.New("load balancer was not built from a config file")
+
+// HealthChecker returns the health checker built from the config file, which
+// Reload reconfigures in place, or the one given to WithHealthChecker. It is
+// nil otherwise.
+func (lb *LoadBalancer) HealthChecker() *healthcheck.HealthChecker {
+	lb.reloadMu.Lock()
+	defer lb.reloadMu.Unlock()
+	return lb.checker
+}
+
+// Reload applies the config file at path to a balancer built with
+// FromConfig or FromConfigFile without dropping traffic: new backends are
+// added, backends that are kept have their weight, health path, connection
+// cap, path rewrite and request headers updated in place, and removed
+// backends are drained in the background so in-flight requests finish before
+// they leave the pool. The health checker takes the new settings but keeps
+// what it knows about the backends, such as their threshold streaks and
+// backoff. If the file is invalid nothing changes. The strategy and retry
+// settings are not reloaded.
+func (lb *LoadBalancer) Reload(path string) error {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	lb.reloadMu.Lock()
+	defer lb.reloadMu.Unlock()
+	if lb.config == nil {
+		return errNotFromConfig
+	}
+	if cfg.Strategy != lb.config.Strategy || cfg.TrustForwarded != lb.config.TrustForwarded || !reflect.DeepEqual(cfg.MaxRetries, lb.config.MaxRetries) {
+		lb.logger.Warn("strategy and retry changes need a restart, ignoring them", "path", path)
+	}
+
+	current := make(map[string]*backend.Backend)
+	for _, b := range lb.Backends() {
+		current[b.URL.String()] = b
+	}
+
+	var added, removed int
+	desired := make([]*backend.Backend, 0, len(cfg.Backends))
+	wanted := make(map[*backend.Backend]bool)
+	for _, bc := range cfg.Backends {
+		b, err := newConfiguredBackend(bc)
+		if err != nil {
+			return err
+		}
+		if existing, ok := current[b.URL.String()]; ok {
+			existing.SetWeight(bc.Weight)
+			existing.SetPriority(bc.Priority)
+			existing.SetHealthPath(bc.HealthPath)
+			existing.SetHealthCheckInterval(time.Duration(bc.HealthCheckInterval))
+			existing.SetMaxConnections(bc.MaxConnections)
+			existing.SetPathRewrite(bc.StripPrefix, bc.PathPrefix)
+			existing.SetRequestHeaders(bc.RequestHeaders, bc.OverrideRequestHeaders)
+			if bc.H2C != existing.H2C() && !lb.h2c {
+				lb.logger.Warn("h2c changes need a restart, ignoring them", "backend", existing.URL.Host)
+			}
+			if tlsChanged(existing, b) {
+				lb.logger.Warn("TLS changes need a restart, ignoring them", "backend", existing.URL.Host)
+			}
+			lb.cancelDrain(existing)
+			b = existing
+		} else {
+			if err := lb.AddBackend(b); err != nil {
+				return fmt.Errorf("adding backend: %w", err)
+			}
+			added++
+		}
+		wanted[b] = true
+		desired = append(desired, b)
+	}
+
+	for _, b := range current {
+		// A backend an operator drained by hand still has to go
+		if _, draining := lb.drains[b]; !wanted[b] && !draining {
+			lb.drainAndRemove(b)
+			removed++
+		}
+	}
+
+	interval, opts := cfg.HealthCheck.checkerOptions()
+	lb.checker.Reconfigure(interval, opts...)
+	lb.config = cfg
+
+	lb.logger.Info("config reloaded", "path", path, "added", added, "removed", removed, "backends", len(desired))
+	return nil
+}
+
+// ReloadOnSignal calls Reload with path every time the process receives
+// SIGHUP, until ctx is done. Failed reloads are logged and leave the running
+// config in place.
+func (lb *LoadBalancer) ReloadOnSignal(ctx context.Context, path string) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if err := lb.Reload(path); err != nil {
+				lb.logger.Error("config reload failed", "path", path, "error", err)
+			}
+		}
+	}
+}
+
+// drainAndRemove stops sending new requests to b and removes it from the pool
+// once its in-flight requests finish or reloadDrainTimeout passes. Callers
+// must hold reloadMu.
+func (lb *LoadBalancer) drainAndRemove(b *backend.Backend) {
+	b.Drain()
+	ctx, cancel := context.WithTimeout(context.Background(), reloadDrainTimeout)
+	if lb.drains == nil {
+		lb.drains = make(map[*backend.Backend]context.CancelFunc)
+	}
+	lb.drains[b] = cancel
+
+	go func() {
+		defer cancel()
+		err := lb.WaitDrained(ctx, b)
+
+		lb.reloadMu.Lock()
+		defer lb.reloadMu.Unlock()
+		// A later reload brought the backend back
+		if _, ok := lb.drains[b]; !ok {
+			return
+		}
+		delete(lb.drains, b)
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			lb.logger.Warn("backend did not drain in time, removing it anyway",
+				"backend", b.URL.Host, "active", b.ActiveConnections())
+		}
+		lb.removeBackend(b)
+	}()
+}
+
+// cancelDrain puts a backend that is being drained back into rotation.
+// Callers must hold reloadMu.
+func (lb *LoadBalancer) cancelDrain(b *backend.Backend) {
+	cancel, ok := lb.drains[b]
+	if !ok {
+		return
+	}
+	delete(lb.drains, b)
+	cancel()
+	b.Undrain()
+}
+
+// tlsChanged reports whether b was configured with other TLS settings than
+// the running backend old.
+func tlsChanged(old, b *backend.Backend) bool {
+	was, now := old.TLSClientConfig(), b.TLSClientConfig()
+	if was == nil || now == nil {
+		return was != now
+	}
+	if was.ServerName != now.ServerName {
+		return true
+	}
+	if was.RootCAs == nil || now.RootCAs == nil {
+		return was.RootCAs != now.RootCAs
+	}
+	return !was.RootCAs.Equal(now.RootCAs)
+}
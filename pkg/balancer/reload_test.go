@@ -0,0 +1,229 @@
+package balancer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestReload tests that reloading a config adds, updates and drains backends without cutting requests
+func TestReload(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			return
+		}
+		<-release
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fast.Close()
+
+	path := writeConfig(t, "lb.yaml", fmt.Sprintf(`
+backends:
+  - url: %s
+  - url: %s
+`, slow.URL, fast.URL))
+
+	lb, hc, err := FromConfigFile(path)
+	if err != nil {
+		t.Fatalf("FromConfigFile failed: %v", err)
+	}
+	hc.Start()
+	defer func() { lb.HealthChecker().Stop() }()
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitFor("initial health checks", func() bool { return len(lb.GetHealthyBackends()) == 2 })
+
+	// Hold a request open on the backend that is about to be removed; it is
+	// first in round-robin order
+	removed := lb.Backends()[0]
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		done <- rec.Code
+	}()
+	waitFor("request in flight", func() bool { return removed.ActiveConnections() == 1 })
+
+	added := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer added.Close()
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(`
+backends:
+  - url: %s
+    weight: 5
+  - url: %s
+`, fast.URL, added.URL)), 0o644); err != nil {
+		t.Fatalf("Failed to rewrite config: %v", err)
+	}
+
+	if err := lb.Reload(path); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	t.Run("Removed Backend Drains", func(t *testing.T) {
+		if !removed.IsDraining() {
+			t.Fatal("Expected removed backend to be draining")
+		}
+		for i := 0; i < 6; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			if selected == removed {
+				t.Errorf("Request %d: draining backend was selected", i)
+			}
+		}
+
+		close(release)
+		if code := <-done; code != http.StatusOK {
+			t.Errorf("Expected in-flight request to finish with 200, got %d", code)
+		}
+		waitFor("drained backend removal", func() bool { return len(lb.Backends()) == 2 })
+	})
+
+	t.Run("Kept Backend Updated In Place", func(t *testing.T) {
+		kept := lb.Backends()[0]
+		if kept.URL.String() != fast.URL || kept.Weight() != 5 {
+			t.Errorf("Expected %s with weight 5, got %s with weight %d", fast.URL, kept.URL, kept.Weight())
+		}
+	})
+
+	t.Run("New Backend Health Checked", func(t *testing.T) {
+		if lb.HealthChecker() != hc || !hc.Running() {
+			t.Fatal("Expected the health checker reconfigured in place and still running")
+		}
+		waitFor("new backend to pass health checks", func() bool { return len(lb.GetHealthyBackends()) == 2 })
+	})
+
+	t.Run("Invalid Config Leaves Pool Alone", func(t *testing.T) {
+		os.WriteFile(path, []byte("backends: []\n"), 0o644)
+		if err := lb.Reload(path); err == nil {
+			t.Error("Expected error for invalid config")
+		}
+		if len(lb.Backends()) != 2 {
+			t.Errorf("Expected pool unchanged, got %d backends", len(lb.Backends()))
+		}
+	})
+}
+
+// TestReloadRemovals tests which backends a reload removes
+func TestReloadRemovals(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	waitForPool := func(t *testing.T, lb *LoadBalancer, want ...string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			var got []string
+			for _, b := range lb.Backends() {
+				got = append(got, b.URL.String())
+			}
+			if slices.Equal(got, want) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected pool %v, got %v", want, got)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	t.Run("Same Host Backends Told Apart", func(t *testing.T) {
+		path := writeConfig(t, "lb.yaml", fmt.Sprintf("backends:\n  - url: %s/a\n  - url: %s/b\n", server.URL, server.URL))
+		lb, _, err := FromConfigFile(path)
+		if err != nil {
+			t.Fatalf("FromConfigFile failed: %v", err)
+		}
+
+		os.WriteFile(path, []byte(fmt.Sprintf("backends:\n  - url: %s/a\n", server.URL)), 0o644)
+		if err := lb.Reload(path); err != nil {
+			t.Fatalf("Reload failed: %v", err)
+		}
+		waitForPool(t, lb, server.URL+"/a")
+	})
+
+	t.Run("Backend Drained By Hand", func(t *testing.T) {
+		path := writeConfig(t, "lb.yaml", fmt.Sprintf("backends:\n  - url: %s/a\n  - url: %s/b\n", server.URL, server.URL))
+		lb, _, err := FromConfigFile(path)
+		if err != nil {
+			t.Fatalf("FromConfigFile failed: %v", err)
+		}
+		lb.Backends()[1].Drain()
+
+		os.WriteFile(path, []byte(fmt.Sprintf("backends:\n  - url: %s/a\n", server.URL)), 0o644)
+		if err := lb.Reload(path); err != nil {
+			t.Fatalf("Reload failed: %v", err)
+		}
+		waitForPool(t, lb, server.URL+"/a")
+	})
+}
+
+// TestReloadKeepsHealthState tests that a reload doesn't reset what the health checker knows
+func TestReloadKeepsHealthState(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	config := func(interval string) string {
+		return fmt.Sprintf("backends:\n  - url: %s\nhealth_check:\n  interval: %s\n  healthy_threshold: 2\n", server.URL, interval)
+	}
+	path := writeConfig(t, "lb.yaml", config("1h"))
+	lb, hc, err := FromConfigFile(path)
+	if err != nil {
+		t.Fatalf("FromConfigFile failed: %v", err)
+	}
+	b := lb.Backends()[0]
+	host := b.URL.Host
+
+	hc.CheckNow(host, false)
+	healthy.Store(true)
+	hc.CheckNow(host, false) // first of the two passes needed
+
+	os.WriteFile(path, []byte(config("30m")), 0o644)
+	if err := lb.Reload(path); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if lb.HealthChecker() != hc {
+		t.Fatal("Expected the health checker reconfigured in place")
+	}
+
+	hc.CheckNow(host, false)
+	if !b.IsAlive() {
+		t.Error("Expected the second pass across the reload to bring the backend up")
+	}
+}
+
+// TestReloadRequiresConfig tests that Reload rejects balancers built in code
+func TestReloadRequiresConfig(t *testing.T) {
+	lb, err := New([]*backend.Backend{newTestBackend(t, "http://localhost:3000")})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	path := writeConfig(t, "lb.yaml", "backends:\n  - url: http://localhost:3001\n")
+	if err := lb.Reload(path); err == nil {
+		t.Error("Expected error reloading a balancer not built from config")
+	}
+}
@@ -0,0 +1,73 @@
+package balancer
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// defaultPriorityHeader carries a request's priority unless
+// PriorityPolicy.Header names another header.
+const defaultPriorityHeader = "X-Priority"
+
+// PriorityPolicy lets interactive traffic keep capacity that bulk traffic
+// sharing the balancer cannot use up. Requests whose priority header is
+// "low" are low priority; all others, including those without the header,
+// are high priority. Like ReserveCapacity it works on backends' MaxConnections
+// caps and has no effect on uncapped backends.
+type PriorityPolicy struct {
+	// Header carries the priority; empty means X-Priority.
+	Header string
+
+	// Reserve is the fraction of each backend's MaxConnections that only
+	// high-priority requests may use, e.g. 0.2 to stop admitting low-priority
+	// requests once a backend is 80% full.
+	Reserve float64
+
+	// Shed fails low-priority requests that find no spare capacity at once
+	// with ErrLowPriorityShed. Otherwise they are treated as saturated, so
+	// they wait in the WithQueue queue if there is one, retrying each time a
+	// connection frees up while queued high-priority requests take it first.
+	Shed bool
+}
+
+// lowPriority reports whether r is a low-priority request under the
+// balancer's policy. Without a policy every request has equal priority.
+func (lb *LoadBalancer) lowPriority(r *http.Request) bool {
+	if lb.priorities == nil || r == nil {
+		return false
+	}
+	header := lb.priorities.Header
+	if header == "" {
+		header = defaultPriorityHeader
+	}
+	return strings.EqualFold(strings.TrimSpace(r.Header.Get(header)), "low")
+}
+
+// inHighPriorityReserve reports whether b is so busy that only
+// high-priority requests may still be sent to it.
+func (lb *LoadBalancer) inHighPriorityReserve(b *backend.Backend) bool {
+	limit := b.MaxConnections()
+	if lb.priorities == nil || limit <= 0 {
+		return false
+	}
+	return float64(b.ActiveConnections()) >= (1-lb.priorities.Reserve)*float64(limit)
+}
+
+// outsideHighPriorityReserve narrows available to backends that still admit
+// low-priority requests.
+func (lb *LoadBalancer) outsideHighPriorityReserve(available func(*backend.Backend) bool) func(*backend.Backend) bool {
+	return func(b *backend.Backend) bool {
+		return !lb.inHighPriorityReserve(b) && available(b)
+	}
+}
+
+// lowPriorityRefused returns the error for a low-priority request that found
+// every backend it could use in the high-priority reserve.
+func (lb *LoadBalancer) lowPriorityRefused() error {
+	if lb.priorities.Shed {
+		return ErrLowPriorityShed
+	}
+	return ErrAllBackendsSaturated
+}
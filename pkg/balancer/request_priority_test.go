@@ -0,0 +1,98 @@
+package balancer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestRequestPriorities tests that low-priority requests leave reserved capacity to high-priority ones
+func TestRequestPriorities(t *testing.T) {
+	newBusy := func(t *testing.T, active int, opts ...Option) (*LoadBalancer, *backend.Backend) {
+		b := newTestBackend(t, "http://localhost:3000")
+		b.SetAlive(true)
+		b.SetMaxConnections(10)
+		for range active {
+			b.IncrementConnections()
+		}
+		lb, err := New([]*backend.Backend{b}, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		return lb, b
+	}
+	request := func(priority string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if priority != "" {
+			r.Header.Set("X-Priority", priority)
+		}
+		return r
+	}
+	policy := PriorityPolicy{Reserve: 0.2}
+
+	t.Run("Equal Priority By Default", func(t *testing.T) {
+		lb, _ := newBusy(t, 8)
+		if _, err := lb.SelectBackendForRequest(request("low")); err != nil {
+			t.Errorf("Expected the header to be ignored without a policy, got %v", err)
+		}
+	})
+
+	t.Run("High Priority Uses The Reserve", func(t *testing.T) {
+		lb, _ := newBusy(t, 8, WithPriorities(policy))
+		for _, priority := range []string{"", "high"} {
+			if _, err := lb.SelectBackendForRequest(request(priority)); err != nil {
+				t.Errorf("Priority %q: expected a backend, got %v", priority, err)
+			}
+		}
+	})
+
+	t.Run("Low Priority Stays Below The Reserve", func(t *testing.T) {
+		lb, _ := newBusy(t, 7, WithPriorities(policy))
+		if _, err := lb.SelectBackendForRequest(request("low")); err != nil {
+			t.Errorf("Expected a backend below the reserve, got %v", err)
+		}
+
+		lb, _ = newBusy(t, 8, WithPriorities(policy))
+		if _, err := lb.SelectBackendForRequest(request("LOW")); !errors.Is(err, ErrAllBackendsSaturated) {
+			t.Errorf("Expected ErrAllBackendsSaturated, got %v", err)
+		}
+	})
+
+	t.Run("Shed When Configured", func(t *testing.T) {
+		lb, _ := newBusy(t, 8, WithPriorities(PriorityPolicy{Header: "X-Class", Reserve: 0.2, Shed: true}), WithQueue(10, time.Second))
+		r := request("")
+		r.Header.Set("X-Class", "low")
+
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, r)
+		if rec.Code != http.StatusServiceUnavailable || lb.QueueDepth() != 0 {
+			t.Errorf("Expected an immediate 503, got %d with %d queued", rec.Code, lb.QueueDepth())
+		}
+		if _, err := lb.SelectBackendForRequest(r); !errors.Is(err, ErrLowPriorityShed) {
+			t.Errorf("Expected ErrLowPriorityShed, got %v", err)
+		}
+	})
+
+	t.Run("Queued Until Below The Reserve", func(t *testing.T) {
+		lb, b := newBusy(t, 8, WithPriorities(policy), WithQueue(10, time.Second))
+
+		errc := make(chan error, 1)
+		go func() {
+			_, err := lb.SelectBackendForRequest(request("low"))
+			errc <- err
+		}()
+		deadline := time.Now().Add(time.Second)
+		for lb.QueueDepth() == 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		b.DecrementConnections()
+
+		if err := <-errc; err != nil {
+			t.Errorf("Expected the queued request to be admitted, got %v", err)
+		}
+	})
+}
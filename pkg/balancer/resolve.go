@@ -0,0 +1,175 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// ResolvedFromLabel is the label NewBackendsFromHost puts on every backend it
+// creates. Its value is the host and port of the URL the backend was resolved
+// from, e.g. "api.internal:8080", which ResolveHost uses to tell which pool
+// members belong to a name.
+const ResolvedFromLabel = "resolved-from"
+
+// errNoAddresses is returned when a lookup succeeds without any address.
+var errNoAddresses = errors.New("host resolved to no addresses")
+
+// Resolver looks up the addresses of a host. *net.Resolver implements it.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// NewBackendsFromHost resolves the host of rawURL, e.g.
+// "https://api.internal:8443", and returns a backend per address instead of
+// a single one the transport would spread over hosts nobody health checks.
+// The backends keep the URL's scheme, port and path, carry
+// ResolvedFromLabel, and for https verify certificates against the original
+// host name (see backend.Backend.SetTLS). They are ordered by address.
+func NewBackendsFromHost(ctx context.Context, rawURL string) ([]*backend.Backend, error) {
+	return resolveBackends(ctx, net.DefaultResolver, rawURL)
+}
+
+// resolveBackends is NewBackendsFromHost with the given resolver.
+func resolveBackends(ctx context.Context, r Resolver, rawURL string) ([]*backend.Backend, error) {
+	template, err := backend.NewBackend(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := template.URL.Hostname()
+	addrs, err := r.LookupHost(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("%w: %s", errNoAddresses, host)
+	}
+	addrs = slices.Compact(slices.Sorted(slices.Values(addrs)))
+
+	backends := make([]*backend.Backend, 0, len(addrs))
+	for _, addr := range addrs {
+		b, err := newResolvedBackend(template.URL, addr)
+		if err != nil {
+			return nil, err
+		}
+		backends = append(backends, b)
+	}
+	return backends, nil
+}
+
+// newResolvedBackend creates the backend for one address of u's host.
+func newResolvedBackend(u *url.URL, addr string) (*backend.Backend, error) {
+	resolved := *u
+	switch {
+	case u.Port() != "":
+		resolved.Host = net.JoinHostPort(addr, u.Port())
+	case strings.Contains(addr, ":"):
+		resolved.Host = "[" + addr + "]"
+	default:
+		resolved.Host = addr
+	}
+	b, err := backend.NewBackendWithLabels(resolved.String(), map[string]string{ResolvedFromLabel: u.Host})
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "https" && net.ParseIP(u.Hostname()) == nil {
+		if err := b.SetTLS(backend.TLSConfig{ServerName: u.Hostname()}); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// ResolveHost resolves the host of rawURL now and every interval after until
+// ctx is done, reconciling the pool with each answer: new addresses join as
+// backends, and backends resolved from the same host earlier whose address
+// is gone are drained in the background and removed. A failed or empty
+// lookup is logged and ignored so a DNS glitch never empties the pool. New
+// backends are handed to the balancer's health checker and stay
+// backend.Unknown until its first probe of them; without a checker nothing
+// would ever probe them, so they are marked alive instead. Lookups use the
+// WithResolver resolver, or net.DefaultResolver.
+func (lb *LoadBalancer) ResolveHost(ctx context.Context, rawURL string, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("resolve interval must be positive, got %v", interval)
+	}
+	if _, err := backend.NewBackend(rawURL); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := lb.reresolve(ctx, rawURL); err != nil && ctx.Err() == nil {
+			lb.logger.Warn("ignoring DNS update", "url", rawURL, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// reresolve adds and removes the backends of rawURL's host to match its
+// current addresses.
+func (lb *LoadBalancer) reresolve(ctx context.Context, rawURL string) error {
+	resolver := lb.resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	resolved, err := resolveBackends(ctx, resolver, rawURL)
+	if err != nil {
+		return err
+	}
+
+	lb.reloadMu.Lock()
+	defer lb.reloadMu.Unlock()
+
+	selector := map[string]string{ResolvedFromLabel: resolved[0].Labels()[ResolvedFromLabel]}
+	current := make(map[string]*backend.Backend)
+	for _, b := range lb.Backends() {
+		if b.MatchesLabels(selector) {
+			current[b.URL.String()] = b
+		}
+	}
+
+	var added, removed int
+	wanted := make(map[string]bool)
+	for _, b := range resolved {
+		wanted[b.URL.String()] = true
+		if existing, ok := current[b.URL.String()]; ok {
+			lb.cancelDrain(existing)
+			continue
+		}
+		if lb.checker == nil {
+			b.SetAlive(true)
+		}
+		if err := lb.AddBackend(b); err != nil {
+			// Configured separately, without the label; leave it be
+			if errors.Is(err, ErrBackendExists) {
+				continue
+			}
+			return fmt.Errorf("adding backend: %w", err)
+		}
+		added++
+	}
+	for key, b := range current {
+		if !wanted[key] && !b.IsDraining() {
+			lb.drainAndRemove(b)
+			removed++
+		}
+	}
+
+	if added > 0 || removed > 0 {
+		lb.logger.Info("DNS update changed the pool", "host", selector[ResolvedFromLabel], "added", added, "removed", removed)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+)
+
+// fakeResolver answers lookups from a table the test can change
+type fakeResolver struct {
+	mu    sync.Mutex
+	addrs map[string][]string
+	err   error
+}
+
+func (f *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.addrs[host], f.err
+}
+
+func (f *fakeResolver) set(host string, addrs []string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.addrs[host] = addrs
+	f.err = err
+}
+
+// TestNewBackendsFromHost tests that every address of a host becomes its own backend
+func TestNewBackendsFromHost(t *testing.T) {
+	resolver := &fakeResolver{addrs: map[string][]string{
+		"api.internal": {"10.0.0.6", "10.0.0.5", "fd00::1", "10.0.0.5"},
+	}}
+
+	tests := []struct {
+		name     string
+		url      string
+		expected []string
+	}{
+		{"Port Kept", "http://api.internal:8080/v1", []string{
+			"http://10.0.0.5:8080/v1", "http://10.0.0.6:8080/v1", "http://[fd00::1]:8080/v1",
+		}},
+		{"No Port", "http://api.internal", []string{
+			"http://10.0.0.5", "http://10.0.0.6", "http://[fd00::1]",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backends, err := resolveBackends(context.Background(), resolver, tt.url)
+			if err != nil {
+				t.Fatalf("Failed to resolve backends: %v", err)
+			}
+			if len(backends) != len(tt.expected) {
+				t.Fatalf("Expected %d backends, got %d", len(tt.expected), len(backends))
+			}
+			for i, b := range backends {
+				if b.URL.String() != tt.expected[i] {
+					t.Errorf("Backend %d: expected %s, got %s", i, tt.expected[i], b.URL)
+				}
+				if b.TLSClientConfig() != nil {
+					t.Errorf("Backend %d: expected no TLS settings for http", i)
+				}
+			}
+		})
+	}
+
+	t.Run("HTTPS Verifies Host Name", func(t *testing.T) {
+		backends, err := resolveBackends(context.Background(), resolver, "https://api.internal:8443")
+		if err != nil {
+			t.Fatalf("Failed to resolve backends: %v", err)
+		}
+		for _, b := range backends {
+			if cfg := b.TLSClientConfig(); cfg == nil || cfg.ServerName != "api.internal" {
+				t.Errorf("Expected %s to verify api.internal, got %+v", b.URL, cfg)
+			}
+			if label := b.Labels()[ResolvedFromLabel]; label != "api.internal:8443" {
+				t.Errorf("Expected label api.internal:8443, got %q", label)
+			}
+		}
+	})
+
+	t.Run("No Addresses", func(t *testing.T) {
+		if _, err := resolveBackends(context.Background(), resolver, "http://gone.internal"); !errors.Is(err, errNoAddresses) {
+			t.Errorf("Expected errNoAddresses, got %v", err)
+		}
+	})
+}
+
+// TestResolveHost tests that re-resolution adds new addresses and drains vanished ones
+func TestResolveHost(t *testing.T) {
+	resolver := &fakeResolver{addrs: map[string][]string{"api.internal": {"10.0.0.5", "10.0.0.6"}}}
+	backends, err := resolveBackends(context.Background(), resolver, "http://api.internal:8080")
+	if err != nil {
+		t.Fatalf("Failed to resolve backends: %v", err)
+	}
+	other := newTestBackend(t, "http://localhost:3000")
+	lb, err := New(append(backends, other), WithResolver(resolver))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- lb.ResolveHost(ctx, "http://api.internal:8080", 10*time.Millisecond) }()
+
+	t.Run("Unchanged Answer Keeps Backends", func(t *testing.T) {
+		time.Sleep(30 * time.Millisecond)
+		if got := poolHosts(lb); got != "10.0.0.5:8080,10.0.0.6:8080,localhost:3000" {
+			t.Errorf("Unexpected pool %s", got)
+		}
+	})
+
+	t.Run("Failed Lookup Ignored", func(t *testing.T) {
+		resolver.set("api.internal", nil, errors.New("SERVFAIL"))
+		time.Sleep(30 * time.Millisecond)
+		if got := poolHosts(lb); got != "10.0.0.5:8080,10.0.0.6:8080,localhost:3000" {
+			t.Errorf("Expected the pool to survive a failed lookup, got %s", got)
+		}
+	})
+
+	t.Run("Address Added And Removed", func(t *testing.T) {
+		resolver.set("api.internal", []string{"10.0.0.6", "10.0.0.7"}, nil)
+		waitFor("pool update", func() bool {
+			return poolHosts(lb) == "10.0.0.6:8080,10.0.0.7:8080,localhost:3000"
+		})
+		for _, b := range lb.Backends() {
+			if b.URL.Host == "10.0.0.7:8080" && !b.IsAlive() {
+				t.Error("Expected the new backend to join alive")
+			}
+		}
+	})
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// TestResolveHostHealthChecked tests that resolved backends are handed to the
+// balancer's health checker instead of joining alive
+func TestResolveHostHealthChecked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(serverURL.Host)
+
+	resolver := &fakeResolver{addrs: map[string][]string{"api.internal": {"127.0.0.1"}}}
+	other := newTestBackend(t, "http://localhost:3000")
+	hc := healthcheck.NewHealthChecker([]*backend.Backend{other}, time.Hour)
+	lb, err := New([]*backend.Backend{other}, WithResolver(resolver), WithHealthChecker(hc))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	rawURL := "http://api.internal:" + port
+	if err := lb.reresolve(context.Background(), rawURL); err != nil {
+		t.Fatalf("Failed to resolve: %v", err)
+	}
+	var resolved *backend.Backend
+	for _, b := range lb.Backends() {
+		if b.URL.Port() == port {
+			resolved = b
+		}
+	}
+	if resolved == nil {
+		t.Fatalf("Expected the resolved backend in the pool, got %s", poolHosts(lb))
+	}
+	if resolved.HealthState() != backend.Unknown {
+		t.Errorf("Expected the resolved backend to wait for its first probe, got %v", resolved.HealthState())
+	}
+
+	if err := hc.CheckNow(resolved.URL.Host, false); err != nil {
+		t.Fatalf("Expected the checker to know the resolved backend: %v", err)
+	}
+	if !resolved.IsAlive() {
+		t.Errorf("Expected the probe to bring the resolved backend up, got %v", resolved.HealthState())
+	}
+}
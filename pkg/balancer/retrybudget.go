@@ -0,0 +1,119 @@
+package balancer
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/metrics"
+)
+
+// retryBudgetBuckets is how many slices the retry budget's sliding window is
+// counted in; the window moves on one slice at a time.
+const retryBudgetBuckets = 10
+
+// RetryBudget caps retries at a share of recent traffic, so a partial outage
+// where many requests fail doesn't turn into a retry storm that overloads
+// the backends still up. Retries beyond the budget are dropped and the
+// request fails with the error of its last attempt.
+type RetryBudget struct {
+	// Ratio is the fraction of requests that may be retried, e.g. 0.1 to
+	// allow one retry per ten requests.
+	Ratio float64
+
+	// Window is how far back requests and retries are counted; zero means
+	// ten seconds.
+	Window time.Duration
+
+	// MinRetries are always allowed per window, so retries still work while
+	// traffic is too low for Ratio to allow any.
+	MinRetries int
+}
+
+// retryBucket counts the requests and retries seen within one slice of the window.
+type retryBucket struct {
+	slice    int64
+	requests int
+	retries  int
+}
+
+// retryBudget tracks a RetryBudget over a ring of per-slice counts.
+type retryBudget struct {
+	RetryBudget
+	width time.Duration // of one slice
+
+	mu      sync.Mutex
+	buckets [retryBudgetBuckets]retryBucket
+
+	exhausted atomic.Uint64
+}
+
+func newRetryBudget(b RetryBudget) *retryBudget {
+	if b.Window <= 0 {
+		b.Window = 10 * time.Second
+	}
+	return &retryBudget{
+		RetryBudget: b,
+		width:       max(b.Window/retryBudgetBuckets, time.Millisecond),
+	}
+}
+
+// bucket returns the bucket for now, clearing it if it held an older slice.
+// The caller must hold mu.
+func (rb *retryBudget) bucket(now time.Time) *retryBucket {
+	slice := now.UnixNano() / int64(rb.width)
+	b := &rb.buckets[slice%retryBudgetBuckets]
+	if b.slice != slice {
+		*b = retryBucket{slice: slice}
+	}
+	return b
+}
+
+// recordRequest counts a request from a client.
+func (rb *retryBudget) recordRequest(now time.Time) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.bucket(now).requests++
+}
+
+// allowRetry reports whether a retry fits the budget and, if so, counts it.
+func (rb *retryBudget) allowRetry(now time.Time) bool {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	current := rb.bucket(now)
+	oldest := current.slice - retryBudgetBuckets + 1
+	var requests, retries int
+	for _, b := range rb.buckets {
+		if b.slice >= oldest {
+			requests += b.requests
+			retries += b.retries
+		}
+	}
+
+	allowed := max(float64(rb.MinRetries), rb.Ratio*float64(requests))
+	if float64(retries+1) > allowed {
+		rb.exhausted.Add(1)
+		return false
+	}
+	current.retries++
+	return true
+}
+
+// allowRetry reports whether the retry budget, if any, has room for another
+// retry and counts it.
+func (lb *LoadBalancer) allowRetry() bool {
+	if lb.retryBudget == nil || lb.retryBudget.allowRetry(lb.now()) {
+		return true
+	}
+	metrics.RetriesOverBudgetTotal.Inc()
+	return false
+}
+
+// RetriesOverBudget returns how many retries WithRetryBudget has dropped.
+func (lb *LoadBalancer) RetriesOverBudget() uint64 {
+	if lb.retryBudget == nil {
+		return 0
+	}
+	return lb.retryBudget.exhausted.Load()
+}
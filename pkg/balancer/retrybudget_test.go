@@ -0,0 +1,112 @@
+package balancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+)
+
+// TestRetryBudget tests that retries are capped at a share of recent requests
+func TestRetryBudget(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Ratio Of Requests", func(t *testing.T) {
+		rb := newRetryBudget(RetryBudget{Ratio: 0.1, Window: time.Minute})
+		for range 20 {
+			rb.recordRequest(t0)
+		}
+		if !rb.allowRetry(t0) || !rb.allowRetry(t0.Add(time.Second)) {
+			t.Fatal("Expected 2 retries to fit a 10% budget of 20 requests")
+		}
+		if rb.allowRetry(t0.Add(time.Second)) {
+			t.Error("Expected a third retry to be dropped")
+		}
+		if got := rb.exhausted.Load(); got != 1 {
+			t.Errorf("Expected 1 dropped retry, got %d", got)
+		}
+	})
+
+	t.Run("Window Slides", func(t *testing.T) {
+		rb := newRetryBudget(RetryBudget{Ratio: 0.5, Window: 10 * time.Second})
+		for range 4 {
+			rb.recordRequest(t0)
+		}
+		if rb.allowRetry(t0.Add(11 * time.Second)) {
+			t.Error("Expected requests older than the window to stop counting")
+		}
+		rb.recordRequest(t0.Add(12 * time.Second))
+		rb.recordRequest(t0.Add(13 * time.Second))
+		if !rb.allowRetry(t0.Add(14 * time.Second)) {
+			t.Error("Expected recent requests to fund a retry")
+		}
+	})
+
+	t.Run("Minimum Retries", func(t *testing.T) {
+		rb := newRetryBudget(RetryBudget{Ratio: 0.1, MinRetries: 1})
+		if !rb.allowRetry(t0) || rb.allowRetry(t0) {
+			t.Error("Expected exactly one retry without traffic")
+		}
+	})
+
+	t.Run("ServeHTTP Drops Retries Over Budget", func(t *testing.T) {
+		for _, tt := range []struct {
+			name     string
+			opts     []Option
+			attempts uint64
+			dropped  uint64
+		}{
+			{"Without Budget", nil, 3, 0},
+			{"With Budget", []Option{WithRetryBudget(RetryBudget{Ratio: 0.1})}, 1, 1},
+		} {
+			b := newTestBackend(t, newDeadServerURL())
+			b.SetAlive(true)
+			lb, err := New([]*backend.Backend{b}, append(tt.opts, WithMaxRetries(2))...)
+			if err != nil {
+				t.Fatalf("Failed to create load balancer: %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusBadGateway {
+				t.Errorf("%s: expected 502, got %d", tt.name, rec.Code)
+			}
+			if got := b.TotalRequests(); got != tt.attempts {
+				t.Errorf("%s: expected %d attempts, got %d", tt.name, tt.attempts, got)
+			}
+			if got := lb.RetriesOverBudget(); got != tt.dropped {
+				t.Errorf("%s: expected %d retries over budget, got %d", tt.name, tt.dropped, got)
+			}
+		}
+	})
+
+	t.Run("Window Follows The Balancer's Clock", func(t *testing.T) {
+		b := newTestBackend(t, newDeadServerURL())
+		b.SetAlive(true)
+		clk := clock.NewFake(t0)
+		lb, err := New([]*backend.Backend{b}, WithClock(clk), WithMaxRetries(1),
+			WithRetryBudget(RetryBudget{Ratio: 0.5, Window: 10 * time.Second}))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		serve := func() {
+			lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+
+		// At a 50% ratio three requests get one retry between them, and a
+		// fourth one in the same window would fund another
+		for range 3 {
+			serve()
+		}
+		dropped := lb.RetriesOverBudget()
+
+		clk.Advance(11 * time.Second)
+		serve()
+		if got := lb.RetriesOverBudget(); got != dropped+1 {
+			t.Errorf("Expected the retry dropped once the earlier requests left the window, got %d dropped after %d", got, dropped)
+		}
+	})
+}
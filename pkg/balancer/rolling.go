@@ -0,0 +1,60 @@
+package balancer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// RollingDrain drains backends one after another, waiting for each to finish
+// its in-flight requests before moving on to the next, e.g. for a deploy
+// script that takes them out of rotation in turn. Drained backends stay out
+// of rotation; put each back with Undrain once it has been restarted.
+//
+// It stops with ErrLastBackend, before draining anything more, if the next
+// backend is the only one left taking traffic; with the context's error if
+// ctx is done; and with context.DeadlineExceeded if a backend still has
+// requests in flight after perBackendTimeout (zero means no per-backend
+// limit). The backend that failed to drain stays drained.
+func (lb *LoadBalancer) RollingDrain(ctx context.Context, backends []*backend.Backend, perBackendTimeout time.Duration) error {
+	for _, b := range backends {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !lb.othersInRotation(b) {
+			return fmt.Errorf("draining %s: %w", b.URL.Host, ErrLastBackend)
+		}
+
+		b.Drain()
+		lb.logger.Info("draining backend", "backend", b.URL.Host, "active", b.ActiveConnections())
+		if err := lb.waitDrainedWithin(ctx, b, perBackendTimeout); err != nil {
+			lb.logger.Warn("backend did not drain",
+				"backend", b.URL.Host, "active", b.ActiveConnections(), "error", err)
+			return fmt.Errorf("draining %s: %w", b.URL.Host, err)
+		}
+	}
+	return nil
+}
+
+// othersInRotation reports whether some backend in the pool other than b is
+// available and not draining.
+func (lb *LoadBalancer) othersInRotation(b *backend.Backend) bool {
+	for _, other := range lb.Backends() {
+		if other != b && lb.isAvailable(other) && !other.IsDraining() {
+			return true
+		}
+	}
+	return false
+}
+
+// waitDrainedWithin is WaitDrained bounded by timeout, if positive.
+func (lb *LoadBalancer) waitDrainedWithin(ctx context.Context, b *backend.Backend, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return lb.WaitDrained(ctx, b)
+}
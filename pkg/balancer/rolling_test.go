@@ -0,0 +1,90 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestRollingDrain tests that backends are drained one at a time and never all at once
+func TestRollingDrain(t *testing.T) {
+	newRotation := func(t *testing.T) (*LoadBalancer, []*backend.Backend) {
+		backends := []*backend.Backend{
+			newTestBackend(t, "http://localhost:3000"),
+			newTestBackend(t, "http://localhost:3001"),
+			newTestBackend(t, "http://localhost:3002"),
+		}
+		for _, b := range backends {
+			b.SetAlive(true)
+		}
+		lb, err := New(backends)
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		return lb, backends
+	}
+
+	t.Run("Waits For Each Backend", func(t *testing.T) {
+		lb, backends := newRotation(t)
+		backends[0].IncrementConnections()
+		secondDrainedEarly := make(chan bool, 1)
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			secondDrainedEarly <- backends[1].IsDraining()
+			backends[0].DecrementConnections()
+		}()
+
+		if err := lb.RollingDrain(context.Background(), backends[:2], time.Second); err != nil {
+			t.Fatalf("RollingDrain failed: %v", err)
+		}
+		if <-secondDrainedEarly {
+			t.Error("Expected the second backend to wait until the first had drained")
+		}
+		if !backends[0].IsDraining() || !backends[1].IsDraining() || backends[2].IsDraining() {
+			t.Error("Expected exactly the first two backends to be drained")
+		}
+	})
+
+	t.Run("Keeps The Last Backend", func(t *testing.T) {
+		lb, backends := newRotation(t)
+		backends[1].SetAlive(false)
+
+		err := lb.RollingDrain(context.Background(), []*backend.Backend{backends[0], backends[2]}, time.Second)
+		if !errors.Is(err, ErrLastBackend) {
+			t.Fatalf("Expected ErrLastBackend, got %v", err)
+		}
+		if !backends[0].IsDraining() || backends[2].IsDraining() {
+			t.Error("Expected the last backend in rotation to be left alone")
+		}
+	})
+
+	t.Run("Per-Backend Timeout", func(t *testing.T) {
+		lb, backends := newRotation(t)
+		backends[0].IncrementConnections()
+		defer backends[0].DecrementConnections()
+
+		err := lb.RollingDrain(context.Background(), backends[:2], 20*time.Millisecond)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected context.DeadlineExceeded, got %v", err)
+		}
+		if backends[1].IsDraining() {
+			t.Error("Expected draining to stop at the backend that timed out")
+		}
+	})
+
+	t.Run("Cancelled Context", func(t *testing.T) {
+		lb, backends := newRotation(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := lb.RollingDrain(ctx, backends[:2], time.Second); !errors.Is(err, context.Canceled) {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+		if backends[0].IsDraining() {
+			t.Error("Expected nothing to be drained after cancellation")
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package balancer
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CodeNoRoute is the ProxyError code for requests a Router has no route for.
+const CodeNoRoute = "NO_ROUTE"
+
+// Router sends each request to the handler registered for the longest path
+// prefix that matches it, typically a LoadBalancer per service or a label
+// subset of one from LoadBalancer.WithLabels. It is safe to add routes while
+// serving.
+type Router struct {
+	mu       sync.RWMutex
+	routes   []route // longest prefix first
+	fallback http.Handler
+}
+
+type route struct {
+	prefix  string
+	handler http.Handler
+}
+
+// NewRouter creates a router with no routes. Unmatched requests get a 404
+// until a default is set with SetDefault.
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle routes requests under prefix to h. A prefix matches its own path
+// and everything below it: "/users" matches "/users" and "/users/42" but not
+// "/usersettings". Registering a prefix again replaces its handler.
+func (rt *Router) Handle(prefix string, h http.Handler) {
+	prefix = "/" + strings.Trim(prefix, "/")
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	for i := range rt.routes {
+		if rt.routes[i].prefix == prefix {
+			rt.routes[i].handler = h
+			return
+		}
+	}
+	rt.routes = append(rt.routes, route{prefix: prefix, handler: h})
+	sort.SliceStable(rt.routes, func(i, j int) bool {
+		return len(rt.routes[i].prefix) > len(rt.routes[j].prefix)
+	})
+}
+
+// SetDefault sets the handler for requests no prefix matches; nil restores
+// the 404 response.
+func (rt *Router) SetDefault(h http.Handler) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.fallback = h
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h := rt.match(r.URL.Path); h != nil {
+		h.ServeHTTP(w, r)
+		return
+	}
+	DefaultErrorRenderer(w, r, &ProxyError{
+		Status:  http.StatusNotFound,
+		Message: "no route for " + r.URL.Path,
+		Code:    CodeNoRoute,
+	})
+}
+
+// match returns the handler for path, or nil if nothing matches it.
+func (rt *Router) match(path string) http.Handler {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	for _, rte := range rt.routes {
+		if rte.prefix == "/" || path == rte.prefix || strings.HasPrefix(path, rte.prefix+"/") {
+			return rte.handler
+		}
+	}
+	return rt.fallback
+}
@@ -0,0 +1,96 @@
+package balancer
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// newNamedServer starts a server that answers every request with name
+func newNamedServer(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, name)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newPool creates a balancer over alive backends with the given labels, keyed by URL
+func newPool(t *testing.T, labeled map[string]map[string]string) *LoadBalancer {
+	t.Helper()
+	var backends []*backend.Backend
+	for url, labels := range labeled {
+		b, err := backend.NewBackendWithLabels(url, labels)
+		if err != nil {
+			t.Fatalf("Failed to create backend %s: %v", url, err)
+		}
+		b.SetAlive(true)
+		backends = append(backends, b)
+	}
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	return lb
+}
+
+// TestRouter tests longest-prefix routing to balancers and label subsets
+func TestRouter(t *testing.T) {
+	users := newPool(t, map[string]map[string]string{newNamedServer(t, "users").URL: nil})
+	admin := newPool(t, map[string]map[string]string{newNamedServer(t, "admin").URL: nil})
+	shared := newPool(t, map[string]map[string]string{
+		newNamedServer(t, "orders").URL:  {"service": "orders"},
+		newNamedServer(t, "catalog").URL: {"service": "catalog"},
+	})
+
+	rt := NewRouter()
+	rt.Handle("/users", users)
+	rt.Handle("/users/admin/", admin)
+	rt.Handle("/orders", shared.WithLabels(map[string]string{"service": "orders"}))
+	rt.Handle("/catalog", shared.WithLabels(map[string]string{"service": "catalog"}))
+	rt.Handle("/inventory", shared.WithLabels(map[string]string{"service": "inventory"}))
+
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		rt.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	tests := []struct {
+		name string
+		path string
+		code int
+		body string
+	}{
+		{"Exact Prefix", "/users", http.StatusOK, "users"},
+		{"Below Prefix", "/users/42", http.StatusOK, "users"},
+		{"Longest Prefix Wins", "/users/admin/roles", http.StatusOK, "admin"},
+		{"Label Subset", "/orders/7", http.StatusOK, "orders"},
+		{"Other Label Subset", "/catalog", http.StatusOK, "catalog"},
+		{"Segment Boundary", "/usersettings", http.StatusNotFound, ""},
+		{"Unmatched Path", "/payments", http.StatusNotFound, ""},
+		{"Subset Without Backends", "/inventory", http.StatusServiceUnavailable, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := get(tt.path)
+			if code != tt.code {
+				t.Fatalf("GET %s: expected status %d, got %d (%s)", tt.path, tt.code, code, body)
+			}
+			if tt.body != "" && body != tt.body {
+				t.Errorf("GET %s: expected %q, got %q", tt.path, tt.body, body)
+			}
+		})
+	}
+
+	t.Run("Default Pool", func(t *testing.T) {
+		rt.SetDefault(users)
+		if code, body := get("/payments"); code != http.StatusOK || body != "users" {
+			t.Errorf("Expected unmatched path to hit the default pool, got %d %q", code, body)
+		}
+	})
+}
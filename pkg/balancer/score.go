@@ -0,0 +1,29 @@
+package balancer
+
+import (
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// ScoreWeighted spreads requests like WeightedRoundRobin but weighs each
+// backend by its HealthScore, so traffic shifts away from backends as they
+// slow down or start failing and back as they recover. A backend scoring 0
+// still gets a trickle of requests, since its score only improves with new
+// samples. See WithScoreCoefficients for tuning the score.
+type ScoreWeighted struct {
+	wrr *WeightedRoundRobin
+}
+
+// NewScoreWeighted creates a score-weighted strategy.
+func NewScoreWeighted() *ScoreWeighted {
+	return &ScoreWeighted{wrr: NewWeightedRoundRobin()}
+}
+
+// Pick returns the available backend that is furthest behind its share by score.
+func (s *ScoreWeighted) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	return s.wrr.pick(backends, available, scoreWeight)
+}
+
+// scoreWeight is b's health score as a weight, at least 1.
+func scoreWeight(b *backend.Backend) float64 {
+	return float64(max(b.HealthScore(), 1))
+}
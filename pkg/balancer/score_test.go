@@ -0,0 +1,43 @@
+package balancer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestScoreWeighted tests that traffic follows the backends' health scores
+func TestScoreWeighted(t *testing.T) {
+	healthy := newTestBackend(t, "http://localhost:3000")
+	failing := newTestBackend(t, "http://localhost:3001")
+	for _, b := range []*backend.Backend{healthy, failing} {
+		b.SetAlive(true)
+		b.RecordLatency(10 * time.Millisecond)
+	}
+	for range 10 {
+		healthy.RecordOutcome(false)
+		failing.RecordOutcome(true)
+	}
+
+	lb, err := New([]*backend.Backend{healthy, failing}, WithStrategy(NewScoreWeighted()))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	count := make(map[*backend.Backend]int)
+	for range 1000 {
+		selected, err := lb.SelectBackend()
+		if err != nil {
+			t.Fatalf("SelectBackend failed: %v", err)
+		}
+		count[selected]++
+	}
+
+	// Scores of 96 and 36 split the traffic about 73/27
+	hs, fs := healthy.HealthScore(), failing.HealthScore()
+	want := 1000 * hs / (hs + fs)
+	if got := count[healthy]; got < want-5 || got > want+5 {
+		t.Errorf("Expected about %d of 1000 requests on the healthy backend (scores %d and %d), got %d", want, hs, fs, got)
+	}
+}
@@ -0,0 +1,93 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// SelectionInfo describes how SelectBackendDetailed arrived at its choice,
+// for debugging routing decisions.
+type SelectionInfo struct {
+	Strategy   string // name of the strategy, as accepted by StrategyByName where possible
+	Considered int    // backends that were alive when the selection started
+	Skipped    int    // backends that could not take the request: dead, disabled, ejected, draining or saturated
+	Index      int    // position of the chosen backend in the pool, or -1 if none was chosen
+}
+
+// SelectBackendDetailed is like SelectBackend but also reports how the
+// backend was chosen. The counts are taken just before the selection runs.
+func (lb *LoadBalancer) SelectBackendDetailed() (*backend.Backend, SelectionInfo, error) {
+	info := SelectionInfo{Strategy: strategyName(lb.strategy), Index: -1}
+	pool := lb.Backends()
+	for _, b := range pool {
+		switch {
+		case !lb.isAvailable(b):
+			info.Skipped++
+		case b.IsDraining() || b.AtCapacityFor(""):
+			info.Considered++
+			info.Skipped++
+		default:
+			info.Considered++
+		}
+	}
+
+	selected, err := lb.selectBackend(context.Background(), nil, nil)
+	if err != nil {
+		return nil, info, err
+	}
+	info.Index = slices.Index(pool, selected)
+	return selected, info, nil
+}
+
+// SelectBackendExcluding is like SelectBackend but never returns one of
+// exclude, e.g. the backends a retry loop has already seen fail. It fails
+// with ErrAllBackendsOffline if no other backend is available. Strategies see
+// the excluded backends as unavailable, so round-robin moves on by a single
+// backend just as for any other selection.
+func (lb *LoadBalancer) SelectBackendExcluding(exclude ...*backend.Backend) (*backend.Backend, error) {
+	if len(exclude) == 0 {
+		return lb.selectBackend(context.Background(), nil, nil)
+	}
+	selected, err := lb.selectBackend(context.Background(), nil, func(b *backend.Backend) bool {
+		return !slices.Contains(exclude, b)
+	})
+	if errors.Is(err, ErrNoMatchingBackends) {
+		return nil, ErrAllBackendsOffline
+	}
+	return selected, err
+}
+
+// strategyName returns the StrategyByName name of the built-in strategies
+// and the Go type of any other.
+func strategyName(s Strategy) string {
+	switch s.(type) {
+	case *RoundRobin:
+		return "round-robin"
+	case *WeightedRoundRobin:
+		return "weighted-round-robin"
+	case *Random:
+		return "random"
+	case *WeightedRandom:
+		return "weighted-random"
+	case *PowerOfTwoChoices:
+		return "power-of-two-choices"
+	case *LeastConnections:
+		return "least-connections"
+	case *LeastResponseTime:
+		return "least-response-time"
+	case *MostHeadroom:
+		return "most-headroom"
+	case *ScoreWeighted:
+		return "score-weighted"
+	case *IPHash:
+		return "ip-hash"
+	case *ConsistentHash:
+		return "consistent-hash"
+	default:
+		return fmt.Sprintf("%T", s)
+	}
+}
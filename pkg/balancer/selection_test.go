@@ -0,0 +1,164 @@
+package balancer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestSelectBackendDetailed tests that selections report the strategy, pool counts and index
+func TestSelectBackendDetailed(t *testing.T) {
+	dead := newTestBackend(t, "http://localhost:8081")
+	first := newTestBackend(t, "http://localhost:8082")
+	draining := newTestBackend(t, "http://localhost:8083")
+	second := newTestBackend(t, "http://localhost:8084")
+	for _, b := range []*backend.Backend{first, draining, second} {
+		b.SetAlive(true)
+	}
+	draining.Drain()
+
+	t.Run("Reports Counts And Index", func(t *testing.T) {
+		lb, err := New([]*backend.Backend{dead, first, draining, second})
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		for _, want := range []int{1, 3, 1} {
+			b, info, err := lb.SelectBackendDetailed()
+			if err != nil {
+				t.Fatalf("Selection failed: %v", err)
+			}
+			expected := SelectionInfo{Strategy: "round-robin", Considered: 3, Skipped: 2, Index: want}
+			if info != expected {
+				t.Errorf("Expected %+v, got %+v", expected, info)
+			}
+			if got := lb.Backends()[info.Index]; got != b {
+				t.Errorf("Expected index %d to point at %s, got %s", info.Index, b.URL.Host, got.URL.Host)
+			}
+		}
+	})
+
+	t.Run("Names The Strategy", func(t *testing.T) {
+		lb, err := New([]*backend.Backend{first}, WithStrategy(NewLeastResponseTime()))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		if _, info, _ := lb.SelectBackendDetailed(); info.Strategy != "least-response-time" {
+			t.Errorf("Expected strategy least-response-time, got %q", info.Strategy)
+		}
+	})
+
+	t.Run("No Index Without A Selection", func(t *testing.T) {
+		lb, err := New([]*backend.Backend{dead})
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		_, info, err := lb.SelectBackendDetailed()
+		if !errors.Is(err, ErrAllBackendsOffline) {
+			t.Errorf("Expected ErrAllBackendsOffline, got %v", err)
+		}
+		if info.Index != -1 || info.Considered != 0 || info.Skipped != 1 {
+			t.Errorf("Expected nothing considered and one skipped, got %+v", info)
+		}
+	})
+}
+
+// TestSelectBackendExcluding tests that retries skip the backends they exclude without skewing the rotation
+func TestSelectBackendExcluding(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("Retry Never Repeats The Failed Backend", func(t *testing.T) {
+		failing := backends[1]
+		for i := 0; i < 300; i++ {
+			tried := []*backend.Backend{}
+			for attempt := 0; attempt < len(backends); attempt++ {
+				selected, err := lb.SelectBackendExcluding(tried...)
+				if err != nil {
+					t.Fatalf("Attempt %d failed: %v", attempt, err)
+				}
+				for _, b := range tried {
+					if selected == b {
+						t.Fatalf("Attempt %d returned %s again", attempt, b.URL.Host)
+					}
+				}
+				if selected != failing {
+					break
+				}
+				tried = append(tried, selected)
+			}
+		}
+	})
+
+	t.Run("Rotation Stays Even", func(t *testing.T) {
+		count := make(map[*backend.Backend]int)
+		for i := 0; i < 300; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			count[selected]++
+			if _, err := lb.SelectBackendExcluding(selected); err != nil {
+				t.Fatalf("SelectBackendExcluding failed: %v", err)
+			}
+		}
+		for _, b := range backends {
+			if count[b] != 100 {
+				t.Errorf("Expected %s to get 100 normal selections, got %d", b.URL.Host, count[b])
+			}
+		}
+	})
+
+	t.Run("Skips Dead And Excluded Backends", func(t *testing.T) {
+		backends[0].SetAlive(false)
+		defer backends[0].SetAlive(true)
+
+		for i := 0; i < 10; i++ {
+			selected, err := lb.SelectBackendExcluding(backends[1])
+			if err != nil {
+				t.Fatalf("SelectBackendExcluding failed: %v", err)
+			}
+			if selected != backends[2] {
+				t.Errorf("Expected %s, got %s", backends[2].URL.Host, selected.URL.Host)
+			}
+		}
+	})
+
+	t.Run("Nothing Else Alive", func(t *testing.T) {
+		backends[0].SetAlive(false)
+		backends[1].SetAlive(false)
+		defer backends[0].SetAlive(true)
+		defer backends[1].SetAlive(true)
+
+		if _, err := lb.SelectBackendExcluding(backends[2]); !errors.Is(err, ErrAllBackendsOffline) {
+			t.Errorf("Expected ErrAllBackendsOffline, got %v", err)
+		}
+	})
+
+	t.Run("Everything Excluded", func(t *testing.T) {
+		if _, err := lb.SelectBackendExcluding(backends...); !errors.Is(err, ErrAllBackendsOffline) {
+			t.Errorf("Expected ErrAllBackendsOffline, got %v", err)
+		}
+	})
+
+	t.Run("Allocations Do Not Grow With Exclusions", func(t *testing.T) {
+		one := testing.AllocsPerRun(100, func() { lb.SelectBackendExcluding(backends[0]) })
+		two := testing.AllocsPerRun(100, func() { lb.SelectBackendExcluding(backends[0], backends[1]) })
+		if two > one {
+			t.Errorf("Expected the same allocations for one and two exclusions, got %.0f and %.0f", one, two)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package balancer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+)
+
+// defaultSessionTTL is how long a sticky session outlives its last request
+// unless WithSessionTTL says otherwise.
+const defaultSessionTTL = time.Hour
+
+// defaultReapInterval is how often a MemorySessionStore sweeps out expired
+// sessions when created with a non-positive interval.
+const defaultReapInterval = time.Minute
+
+// SessionStore maps sticky session IDs to the backends they are pinned to.
+// Implementations must be safe for concurrent use. A store shared between
+// balancer instances, e.g. one backed by Redis, lets them honor each other's
+// sessions.
+//
+// A store may also implement Pinned(host string) int, reporting how many
+// sessions are pinned to a backend for AffinityImpact, and
+// DeleteBackend(*backend.Backend), dropping a removed backend's sessions.
+type SessionStore interface {
+	// Get returns the backend the session is pinned to, if it hasn't expired.
+	Get(key string) (*backend.Backend, bool)
+	// Set pins the session to b until ttl has passed; zero means no expiry.
+	Set(key string, b *backend.Backend, ttl time.Duration)
+	// Delete forgets the session.
+	Delete(key string)
+}
+
+// sessionCounter is implemented by stores that can report AffinityImpact.
+type sessionCounter interface {
+	Pinned(host string) int
+}
+
+// backendUnpinner is implemented by stores that can drop every session of a
+// backend at once.
+type backendUnpinner interface {
+	DeleteBackend(b *backend.Backend)
+}
+
+// MemorySessionStore is the default in-process SessionStore. Expired
+// sessions are never returned and are swept out every reap interval, so the
+// map doesn't grow with every client ever seen.
+type MemorySessionStore struct {
+	reapEvery time.Duration
+	clock     clock.Clock // nil means the real clock
+
+	mu       sync.Mutex
+	sessions map[string]pinnedSession
+	perHost  map[string]int // backend host → pinned sessions
+	lastReap time.Time
+}
+
+type pinnedSession struct {
+	backend *backend.Backend
+	expires time.Time // zero if the session never expires
+}
+
+func (ps pinnedSession) expired(now time.Time) bool {
+	return !ps.expires.IsZero() && now.After(ps.expires)
+}
+
+// NewMemorySessionStore creates an in-memory store that sweeps out expired
+// sessions every reapEvery, or every minute if reapEvery is not positive.
+// Sweeps piggyback on store calls, so an idle store holds no goroutine.
+func NewMemorySessionStore(reapEvery time.Duration) *MemorySessionStore {
+	if reapEvery <= 0 {
+		reapEvery = defaultReapInterval
+	}
+	return &MemorySessionStore{
+		reapEvery: reapEvery,
+		sessions:  make(map[string]pinnedSession),
+		perHost:   make(map[string]int),
+		lastReap:  time.Now(),
+	}
+}
+
+// SetClock makes the store expire and sweep out sessions by c, e.g. a
+// clock.Fake in tests, instead of the real clock. The default store of a
+// balancer built WithClock uses its clock. Call it before the store is used.
+func (m *MemorySessionStore) SetClock(c clock.Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = c
+	m.lastReap = m.now()
+}
+
+func (m *MemorySessionStore) Get(key string) (*backend.Backend, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.maybeReap()
+	session, ok := m.sessions[key]
+	if !ok {
+		return nil, false
+	}
+	if session.expired(now) {
+		m.deleteLocked(key)
+		return nil, false
+	}
+	return session.backend, true
+}
+
+func (m *MemorySessionStore) Set(key string, b *backend.Backend, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.maybeReap()
+	m.deleteLocked(key)
+
+	session := pinnedSession{backend: b}
+	if ttl > 0 {
+		session.expires = now.Add(ttl)
+	}
+	m.sessions[key] = session
+	m.perHost[b.URL.Host]++
+}
+
+func (m *MemorySessionStore) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteLocked(key)
+}
+
+// Pinned returns how many sessions are pinned to the backend with the given
+// URL host. Expired sessions may be counted until the next sweep.
+func (m *MemorySessionStore) Pinned(host string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maybeReap()
+	return m.perHost[host]
+}
+
+// DeleteBackend forgets every session pinned to b.
+func (m *MemorySessionStore) DeleteBackend(b *backend.Backend) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, session := range m.sessions {
+		if session.backend == b {
+			delete(m.sessions, key)
+		}
+	}
+	delete(m.perHost, b.URL.Host)
+}
+
+// Len returns how many sessions the store holds, including expired ones that
+// haven't been swept out yet.
+func (m *MemorySessionStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sessions)
+}
+
+// deleteLocked removes a session; callers must hold mu.
+func (m *MemorySessionStore) deleteLocked(key string) {
+	session, ok := m.sessions[key]
+	if !ok {
+		return
+	}
+	delete(m.sessions, key)
+	if host := session.backend.URL.Host; m.perHost[host] > 1 {
+		m.perHost[host]--
+	} else {
+		delete(m.perHost, host)
+	}
+}
+
+// maybeReap sweeps out expired sessions if the reap interval has passed and
+// returns the current time; callers must hold mu.
+func (m *MemorySessionStore) maybeReap() time.Time {
+	now := m.now()
+	if now.Sub(m.lastReap) < m.reapEvery {
+		return now
+	}
+	m.lastReap = now
+	for key, session := range m.sessions {
+		if session.expired(now) {
+			m.deleteLocked(key)
+		}
+	}
+	return now
+}
+
+// now reads the store's clock; callers must hold mu.
+func (m *MemorySessionStore) now() time.Time {
+	if m.clock == nil {
+		return time.Now()
+	}
+	return m.clock.Now()
+}
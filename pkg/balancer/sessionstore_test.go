@@ -0,0 +1,184 @@
+package balancer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+)
+
+// TestMemorySessionStore tests expiry, reaping and per-host counts of the default store
+func TestMemorySessionStore(t *testing.T) {
+	b1 := newTestBackend(t, "http://localhost:3000")
+	b2 := newTestBackend(t, "http://localhost:3001")
+
+	t.Run("Expires After TTL", func(t *testing.T) {
+		clk := clock.NewFake(time.Now())
+		store := NewMemorySessionStore(time.Hour)
+		store.SetClock(clk)
+		store.Set("a", b1, time.Minute)
+		store.Set("b", b1, 0)
+		if got, ok := store.Get("a"); !ok || got != b1 {
+			t.Fatalf("Expected fresh session to be pinned to b1, got %v, %v", got, ok)
+		}
+
+		clk.Advance(2 * time.Minute)
+		if _, ok := store.Get("a"); ok {
+			t.Error("Expected expired session to be gone")
+		}
+		if _, ok := store.Get("b"); !ok {
+			t.Error("Expected session without TTL to be kept")
+		}
+	})
+
+	t.Run("Reaps Expired Sessions", func(t *testing.T) {
+		clk := clock.NewFake(time.Now())
+		store := NewMemorySessionStore(10 * time.Second)
+		store.SetClock(clk)
+		for i := 0; i < 100; i++ {
+			store.Set(fmt.Sprint(i), b1, time.Second)
+		}
+		clk.Advance(20 * time.Second)
+
+		store.Set("fresh", b2, time.Hour)
+		if n := store.Len(); n != 1 {
+			t.Errorf("Expected expired sessions to be reaped, %d left", n)
+		}
+		if n := store.Pinned(b1.URL.Host); n != 0 {
+			t.Errorf("Expected no sessions pinned to b1, got %d", n)
+		}
+	})
+
+	t.Run("Counts Re-pinned Sessions Once", func(t *testing.T) {
+		store := NewMemorySessionStore(time.Hour)
+		store.Set("a", b1, time.Hour)
+		store.Set("a", b2, time.Hour)
+		store.Set("b", b2, time.Hour)
+		if n1, n2 := store.Pinned(b1.URL.Host), store.Pinned(b2.URL.Host); n1 != 0 || n2 != 2 {
+			t.Errorf("Expected 0 and 2 pinned sessions, got %d and %d", n1, n2)
+		}
+
+		store.DeleteBackend(b2)
+		if _, ok := store.Get("b"); ok || store.Pinned(b2.URL.Host) != 0 {
+			t.Error("Expected DeleteBackend to drop b2's sessions")
+		}
+	})
+}
+
+// mapSessionStore is a minimal SessionStore without the optional methods
+type mapSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*backend.Backend
+}
+
+func (m *mapSessionStore) Get(key string) (*backend.Backend, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.sessions[key]
+	return b, ok
+}
+
+func (m *mapSessionStore) Set(key string, b *backend.Backend, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[key] = b
+}
+
+func (m *mapSessionStore) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, key)
+}
+
+// TestSessionStoreOptions tests custom stores and the session TTL through the balancer
+func TestSessionStoreOptions(t *testing.T) {
+	const cookieName = "lb_session"
+	backends := []*backend.Backend{
+		newTestBackend(t, newNamedServer(t, "backend-0").URL),
+		newTestBackend(t, newNamedServer(t, "backend-1").URL),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	// send makes a request with the session cookie, if any, and returns the
+	// serving backend and the session cookie set on the response
+	send := func(lb *LoadBalancer, session string) (string, string) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if session != "" {
+			req.AddCookie(&http.Cookie{Name: cookieName, Value: session})
+		}
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, req)
+		for _, c := range rec.Result().Cookies() {
+			if c.Name == cookieName {
+				return rec.Body.String(), c.Value
+			}
+		}
+		return rec.Body.String(), ""
+	}
+
+	t.Run("Custom Store", func(t *testing.T) {
+		store := &mapSessionStore{sessions: make(map[string]*backend.Backend)}
+		lb, err := New(backends, WithStickySessions(cookieName), WithSessionStore(store))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		name, session := send(lb, "")
+		if got, _ := store.Get(session); got == nil {
+			t.Fatal("Expected the session to be written to the custom store")
+		}
+		for i := 0; i < 3; i++ {
+			if got, _ := send(lb, session); got != name {
+				t.Fatalf("Session moved from %s to %s", name, got)
+			}
+		}
+		if n := lb.AffinityImpact(backends[0].URL.Host); n != 0 {
+			t.Errorf("Expected AffinityImpact 0 for a store that can't count, got %d", n)
+		}
+	})
+
+	t.Run("Removed Backend Is Re-pinned", func(t *testing.T) {
+		store := &mapSessionStore{sessions: make(map[string]*backend.Backend)}
+		lb, err := New(backends, WithStickySessions(cookieName), WithSessionStore(store))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		_, session := send(lb, "") // round-robin pins the first session to backend-0
+		if _, err := lb.RemoveBackend(backends[0].URL.Host); err != nil {
+			t.Fatalf("RemoveBackend failed: %v", err)
+		}
+		if got, _ := send(lb, session); got != "backend-1" {
+			t.Errorf("Expected the session to move to backend-1, got %s", got)
+		}
+	})
+
+	t.Run("Sessions Expire After TTL", func(t *testing.T) {
+		clk := clock.NewFake(time.Now())
+		lb, err := New(backends, WithStickySessions(cookieName), WithSessionTTL(time.Minute), WithClock(clk))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		name, session := send(lb, "")
+		// Each request starts the TTL over
+		for i := 0; i < 5; i++ {
+			clk.Advance(40 * time.Second)
+			if got, _ := send(lb, session); got != name {
+				t.Fatalf("Active session moved from %s to %s", name, got)
+			}
+		}
+
+		clk.Advance(2 * time.Minute)
+		if _, reissued := send(lb, session); reissued != session {
+			t.Errorf("Expected the expired session to be pinned again with the same ID, got cookie %q", reissued)
+		}
+	})
+}
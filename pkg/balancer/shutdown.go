@@ -0,0 +1,35 @@
+package balancer
+
+import (
+	"context"
+)
+
+// Shutdown stops the balancer gracefully: every backend is drained so no new
+// requests are proxied, in-flight requests are given until ctx is done to
+// finish, and the health checker (see HealthChecker) is stopped. It returns
+// the context's error if requests were still in flight, so the caller can
+// force an exit. Run it alongside http.Server.Shutdown, which stops the
+// server from accepting new connections:
+//
+//	go srv.Shutdown(ctx)
+//	if err := lb.Shutdown(ctx); err != nil { ... }
+func (lb *LoadBalancer) Shutdown(ctx context.Context) error {
+	backends := lb.Backends()
+	for _, b := range backends {
+		b.Drain()
+	}
+
+	var err error
+	for _, b := range backends {
+		if err = lb.WaitDrained(ctx, b); err != nil {
+			lb.logger.Warn("shutdown deadline passed with requests in flight",
+				"backend", b.URL.Host, "active", b.ActiveConnections())
+			break
+		}
+	}
+
+	if checker := lb.HealthChecker(); checker != nil {
+		checker.Stop()
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/healthcheck"
+)
+
+// TestShutdown tests that Shutdown drains in-flight requests, rejects new ones and stops health checks
+func TestShutdown(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			return
+		}
+		started <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+
+	newBalancer := func(t *testing.T) (*LoadBalancer, *healthcheck.HealthChecker) {
+		b := newTestBackend(t, server.URL)
+		b.SetAlive(true)
+		hc := healthcheck.NewHealthChecker([]*backend.Backend{b}, time.Hour)
+		lb, err := New([]*backend.Backend{b}, WithHealthChecker(hc))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		return lb, hc
+	}
+
+	// inFlight starts a request that blocks in the backend until release
+	inFlight := func(lb *LoadBalancer) <-chan int {
+		done := make(chan int, 1)
+		go func() {
+			rec := httptest.NewRecorder()
+			lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			done <- rec.Code
+		}()
+		<-started
+		return done
+	}
+
+	t.Run("Waits For In-Flight Requests", func(t *testing.T) {
+		lb, hc := newBalancer(t)
+		hc.Start()
+		done := inFlight(lb)
+
+		shutdown := make(chan error, 1)
+		go func() { shutdown <- lb.Shutdown(context.Background()) }()
+
+		// Give Shutdown time to drain before trying a new request
+		time.Sleep(20 * time.Millisecond)
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected 503 for a request during shutdown, got %d", rec.Code)
+		}
+		select {
+		case err := <-shutdown:
+			t.Fatalf("Shutdown returned %v with a request still in flight", err)
+		default:
+		}
+
+		release <- struct{}{}
+		if code := <-done; code != http.StatusOK {
+			t.Errorf("Expected the in-flight request to complete with 200, got %d", code)
+		}
+		if err := <-shutdown; err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+		if hc.Running() {
+			t.Error("Expected the health checker to be stopped")
+		}
+	})
+
+	t.Run("Deadline Passes", func(t *testing.T) {
+		lb, hc := newBalancer(t)
+		hc.Start()
+		done := inFlight(lb)
+		defer func() {
+			release <- struct{}{}
+			<-done
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+		defer cancel()
+		if err := lb.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+		if hc.Running() {
+			t.Error("Expected the health checker to be stopped even when the drain times out")
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package balancer
+
+import (
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// warmupFactor returns the fraction of its normal share b should receive at
+// now: 0 right after recovery, rising linearly to 1 once the slow-start
+// window has passed.
+func (lb *LoadBalancer) warmupFactor(b *backend.Backend, now time.Time) float64 {
+	if lb.slowStart <= 0 {
+		return 1
+	}
+
+	recoveredAt := b.RecoveredAt()
+	if recoveredAt.IsZero() {
+		return 1
+	}
+
+	elapsed := now.Sub(recoveredAt)
+	if elapsed >= lb.slowStart {
+		return 1
+	}
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(elapsed) / float64(lb.slowStart)
+}
+
+// now reads the WithClock clock, or the real one.
+func (lb *LoadBalancer) now() time.Time {
+	if lb.clock == nil {
+		return time.Now()
+	}
+	return lb.clock.Now()
+}
+
+// admitWarming lets a warming backend take a request with probability equal
+// to its warm-up factor; when it loses, the strategy moves on to the next.
+func (lb *LoadBalancer) admitWarming(b *backend.Backend) bool {
+	factor := lb.warmupFactor(b, lb.now())
+	return factor >= 1 || lb.randFloat64() < factor
+}
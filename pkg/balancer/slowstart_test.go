@@ -0,0 +1,136 @@
+package balancer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+)
+
+// TestSlowStart tests that recovered backends ramp up instead of taking a full share immediately
+func TestSlowStart(t *testing.T) {
+	newBackends := func() []*backend.Backend {
+		backends := []*backend.Backend{
+			newTestBackend(t, "http://localhost:3000"),
+			newTestBackend(t, "http://localhost:3001"),
+			newTestBackend(t, "http://localhost:3002"),
+		}
+		for _, b := range backends {
+			b.SetAlive(true)
+		}
+		return backends
+	}
+	// flap takes b down and back up, which starts its ramp
+	flap := func(b *backend.Backend) {
+		b.SetAlive(false)
+		b.SetAlive(true)
+	}
+
+	t.Run("Linear Ramp", func(t *testing.T) {
+		backends := newBackends()
+		lb, err := New(backends, WithSlowStart(30*time.Second))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		flap(backends[0])
+		recoveredAt := backends[0].RecoveredAt()
+		steps := []struct {
+			after    time.Duration
+			expected float64
+		}{
+			{0, 0},
+			{15 * time.Second, 0.5},
+			{30 * time.Second, 1},
+			{time.Minute, 1},
+		}
+		for _, step := range steps {
+			got := lb.warmupFactor(backends[0], recoveredAt.Add(step.after))
+			if got < step.expected-0.01 || got > step.expected+0.01 {
+				t.Errorf("After %v: warm-up factor %.2f, expected %.2f", step.after, got, step.expected)
+			}
+		}
+	})
+
+	t.Run("Recovered Backend Ramps Up", func(t *testing.T) {
+		backends := newBackends()
+		clk := clock.NewFake(time.Now())
+		lb, err := New(backends, WithSlowStart(30*time.Second), WithClock(clk), WithSeed(1))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		flap(backends[0])
+
+		share := func() int {
+			count := make(map[*backend.Backend]int)
+			for i := 0; i < 3000; i++ {
+				selected, err := lb.SelectBackend()
+				if err != nil {
+					t.Fatalf("Request %d failed: %v", i, err)
+				}
+				count[selected]++
+			}
+			return count[backends[0]]
+		}
+
+		// A full share is 1000 of 3000
+		if got := share(); got != 0 {
+			t.Errorf("Expected no traffic right after recovery, got %d of 3000", got)
+		}
+		clk.Advance(15 * time.Second)
+		if got := share(); got < 300 || got > 700 {
+			t.Errorf("Expected about half a share halfway through the ramp, got %d of 3000", got)
+		}
+		clk.Advance(15 * time.Second)
+		if got := share(); got != 1000 {
+			t.Errorf("Expected a full share once warm, got %d of 3000", got)
+		}
+	})
+
+	t.Run("Warming Backends Still Serve When Nothing Else Is Up", func(t *testing.T) {
+		backends := newBackends()
+		for _, b := range backends {
+			flap(b)
+		}
+		lb, err := New(backends, WithSlowStart(time.Hour))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		if _, err := lb.SelectBackend(); err != nil {
+			t.Errorf("Expected a warming backend rather than an error, got %v", err)
+		}
+	})
+
+	t.Run("Boot Does Not Ramp", func(t *testing.T) {
+		backends := newBackends()
+		lb, err := New(backends, WithSlowStart(time.Hour))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		for _, b := range backends {
+			if !b.RecoveredAt().IsZero() {
+				t.Errorf("Expected %s, up since its first check, not to count as recovered", b.URL.Host)
+			}
+			if got := lb.warmupFactor(b, lb.now()); got != 1 {
+				t.Errorf("Expected a full share for %s at boot, got %.2f", b.URL.Host, got)
+			}
+		}
+	})
+
+	t.Run("Disabled By Default", func(t *testing.T) {
+		backends := newBackends()
+		flap(backends[0])
+		lb, err := New(backends)
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		if got := lb.warmupFactor(backends[0], backends[0].RecoveredAt()); got != 1 {
+			t.Errorf("Expected full share without slow start, got %.2f", got)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package balancer
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// MetricsSnapshot is a point-in-time copy of the balancer's selection
+// counters, for logging or a JSON endpoint without Prometheus.
+type MetricsSnapshot struct {
+	Time              time.Time         `json:"time"`
+	Selections        uint64            `json:"selections"`         // successful selections
+	SelectionFailures uint64            `json:"selection_failures"` // selections that found no backend
+	HealthyBackends   int               `json:"healthy_backends"`
+	Backends          []BackendSnapshot `json:"backends"` // the current pool, in order
+}
+
+// BackendSnapshot is one backend's part of a MetricsSnapshot.
+type BackendSnapshot struct {
+	URL               string `json:"url"`
+	Healthy           bool   `json:"healthy"`
+	Selections        uint64 `json:"selections"`
+	ActiveConnections int64  `json:"active_connections"`
+}
+
+// selectionStats counts selections. Counting only takes the read lock and
+// adds atomically, so selections never wait on each other; a snapshot takes
+// the write lock so no selection is counted halfway while it reads.
+type selectionStats struct {
+	mu         sync.RWMutex
+	selections atomic.Uint64
+	failures   atomic.Uint64
+}
+
+// count records the outcome of one selection.
+func (s *selectionStats) count(selected *backend.Backend, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err != nil {
+		s.failures.Add(1)
+		return
+	}
+	s.selections.Add(1)
+	selected.MarkSelected()
+}
+
+// Metrics returns the balancer's selection counters and the current pool's
+// health. The counters are read while no selection is being counted, so they
+// agree with each other: Selections is the sum of the backends' counts unless
+// backends have left the pool or are shared with another balancer.
+func (lb *LoadBalancer) Metrics() MetricsSnapshot {
+	pool := lb.Backends()
+	snapshot := MetricsSnapshot{
+		Backends: make([]BackendSnapshot, len(pool)),
+	}
+
+	lb.stats.mu.Lock()
+	snapshot.Time = time.Now()
+	snapshot.Selections = lb.stats.selections.Load()
+	snapshot.SelectionFailures = lb.stats.failures.Load()
+	for i, b := range pool {
+		snapshot.Backends[i] = BackendSnapshot{URL: b.URL.String(), Selections: b.Selections()}
+	}
+	lb.stats.mu.Unlock()
+
+	for i, b := range pool {
+		snapshot.Backends[i].Healthy = lb.isAvailable(b)
+		snapshot.Backends[i].ActiveConnections = b.ActiveConnections()
+		if snapshot.Backends[i].Healthy {
+			snapshot.HealthyBackends++
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,65 @@
+package balancer
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestMetricsSnapshot tests that selections and failures are counted and copied out consistently
+func TestMetricsSnapshot(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+	lb, err := New(backends)
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("Counts Selections", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			lb.SelectBackend()
+		}
+		backends[0].SetAlive(false)
+		backends[1].SetAlive(false)
+		lb.SelectBackend()
+		backends[0].SetAlive(true)
+		backends[1].SetAlive(true)
+
+		m := lb.Metrics()
+		if m.Selections != 4 || m.SelectionFailures != 1 || m.HealthyBackends != 2 {
+			t.Errorf("Expected 4 selections, 1 failure and 2 healthy, got %+v", m)
+		}
+		if len(m.Backends) != 2 || m.Backends[0].Selections != 2 || m.Backends[1].Selections != 2 {
+			t.Errorf("Expected 2 selections per backend, got %+v", m.Backends)
+		}
+		if m.Backends[0].URL != "http://localhost:3000" || !m.Backends[0].Healthy {
+			t.Errorf("Unexpected backend entry: %+v", m.Backends[0])
+		}
+	})
+
+	t.Run("Consistent Under Load", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					lb.SelectBackend()
+				}
+			}()
+		}
+		for i := 0; i < 100; i++ {
+			m := lb.Metrics()
+			if sum := m.Backends[0].Selections + m.Backends[1].Selections; sum != m.Selections {
+				t.Fatalf("Snapshot %d: total %d disagrees with per-backend sum %d", i, m.Selections, sum)
+			}
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,113 @@
+package balancer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/internal/clock"
+)
+
+// stickySessions pins clients to backends through a session cookie.
+type stickySessions struct {
+	cookieName string
+	store      SessionStore
+	ttl        time.Duration
+}
+
+func newStickySessions(cookieName string, store SessionStore, ttl time.Duration, clk clock.Clock) *stickySessions {
+	if store == nil {
+		memory := NewMemorySessionStore(0)
+		memory.SetClock(clk)
+		store = memory
+	}
+	return &stickySessions{cookieName: cookieName, store: store, ttl: ttl}
+}
+
+// lookup returns the request's session ID and the backend it is pinned to,
+// either of which may be empty. A live session's TTL starts over.
+func (s *stickySessions) lookup(r *http.Request) (string, *backend.Backend) {
+	cookie, err := r.Cookie(s.cookieName)
+	if err != nil || cookie.Value == "" {
+		return "", nil
+	}
+
+	b, ok := s.store.Get(cookie.Value)
+	if !ok {
+		return cookie.Value, nil
+	}
+	s.store.Set(cookie.Value, b, s.ttl)
+	return cookie.Value, b
+}
+
+// pin maps the session to b, starting a new session if id is empty, and sets
+// the session cookie on the response to r. It returns the session ID.
+func (s *stickySessions) pin(w http.ResponseWriter, r *http.Request, id string, b *backend.Backend) string {
+	if id == "" {
+		id = newSessionID()
+	}
+	s.store.Set(id, b, s.ttl)
+
+	// A retry re-pins; replace the cookie of the previous attempt only
+	header := w.Header()
+	kept := header["Set-Cookie"][:0]
+	for _, line := range header["Set-Cookie"] {
+		if c, err := http.ParseSetCookie(line); err == nil && c.Name == s.cookieName {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if len(kept) == 0 {
+		header.Del("Set-Cookie")
+	} else {
+		header["Set-Cookie"] = kept
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.cookieName,
+		Value:    id,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
+
+// unpinBackend drops every session pinned to b, if the store supports it.
+// Sessions left behind in other stores are re-pinned on their next request,
+// since b is no longer in the pool.
+func (s *stickySessions) unpinBackend(b *backend.Backend) {
+	if store, ok := s.store.(backendUnpinner); ok {
+		store.DeleteBackend(b)
+	}
+}
+
+// count returns how many sessions are pinned to host, or 0 if the store
+// can't tell.
+func (s *stickySessions) count(host string) int {
+	if store, ok := s.store.(sessionCounter); ok {
+		return store.Pinned(host)
+	}
+	return 0
+}
+
+// newSessionID returns a random 128-bit hex session ID.
+func newSessionID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// AffinityImpact returns how many sticky sessions are currently pinned to
+// the backend with the given URL host, i.e. how many clients would lose
+// their affinity if it went down. It is 0 when sticky sessions are disabled
+// or the session store can't count them.
+func (lb *LoadBalancer) AffinityImpact(host string) int {
+	if lb.sticky == nil {
+		return 0
+	}
+	return lb.sticky.count(host)
+}
@@ -0,0 +1,129 @@
+package balancer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestStickySessions tests session pinning and the AffinityImpact report
+func TestStickySessions(t *testing.T) {
+	const cookieName = "lb_session"
+
+	backends := make([]*backend.Backend, 3)
+	for i := range backends {
+		name := fmt.Sprintf("backend-%d", i)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, name)
+		}))
+		defer server.Close()
+		backends[i] = newTestBackend(t, server.URL)
+		backends[i].SetAlive(true)
+	}
+
+	lb, err := New(backends, WithStickySessions(cookieName))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	// Start 9 sessions; round-robin pins 3 to each backend
+	sessions := make(map[string]string) // cookie → backend name
+	for i := 0; i < 9; i++ {
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		cookies := rec.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != cookieName {
+			t.Fatalf("Expected a %s cookie, got %v", cookieName, cookies)
+		}
+		sessions[cookies[0].Value] = rec.Body.String()
+	}
+
+	t.Run("Pinned Clients Keep Their Backend", func(t *testing.T) {
+		for id, name := range sessions {
+			for i := 0; i < 5; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.AddCookie(&http.Cookie{Name: cookieName, Value: id})
+				rec := httptest.NewRecorder()
+				lb.ServeHTTP(rec, req)
+
+				if rec.Body.String() != name {
+					t.Fatalf("Session %s moved from %s to %s", id, name, rec.Body.String())
+				}
+				if len(rec.Result().Cookies()) != 0 {
+					t.Errorf("Expected no new cookie for an existing session")
+				}
+			}
+		}
+	})
+
+	t.Run("Affinity Impact", func(t *testing.T) {
+		for _, b := range backends {
+			if got := lb.AffinityImpact(b.URL.Host); got != 3 {
+				t.Errorf("%s: AffinityImpact = %d, expected 3", b.URL.Host, got)
+			}
+		}
+		if got := lb.AffinityImpact("unknown:1"); got != 0 {
+			t.Errorf("Unknown host: AffinityImpact = %d, expected 0", got)
+		}
+	})
+
+	t.Run("Dead Backend Re-pins Its Sessions", func(t *testing.T) {
+		backends[0].SetAlive(false)
+		defer backends[0].SetAlive(true)
+
+		for id := range sessions {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(&http.Cookie{Name: cookieName, Value: id})
+			lb.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		if got := lb.AffinityImpact(backends[0].URL.Host); got != 0 {
+			t.Errorf("Expected dead backend to have no pinned sessions, got %d", got)
+		}
+		total := lb.AffinityImpact(backends[1].URL.Host) + lb.AffinityImpact(backends[2].URL.Host)
+		if total != 9 {
+			t.Errorf("Expected all 9 sessions on the survivors, got %d", total)
+		}
+	})
+}
+
+// TestStickyCookie tests the attributes of the session cookie and that
+// re-pinning leaves other cookies on the response alone
+func TestStickyCookie(t *testing.T) {
+	const cookieName = "lb_session"
+
+	b := newTestBackend(t, "http://localhost:3000")
+	b.SetAlive(true)
+	sticky := newStickySessions(cookieName, nil, defaultSessionTTL, nil)
+
+	tests := []struct {
+		name   string
+		target string
+		secure bool
+	}{
+		{"Plain HTTP", "http://example.com/", false},
+		{"TLS", "https://example.com/", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			http.SetCookie(rec, &http.Cookie{Name: "app_session", Value: "keep"})
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+
+			id := sticky.pin(rec, req, "", b)
+			sticky.pin(rec, req, id, b)
+
+			cookies := rec.Result().Cookies()
+			if len(cookies) != 2 || cookies[0].Name != "app_session" || cookies[1].Name != cookieName {
+				t.Fatalf("Expected app_session kept and one %s cookie, got %v", cookieName, cookies)
+			}
+			if got := cookies[1]; got.Secure != tt.secure || got.SameSite != http.SameSiteLaxMode || !got.HttpOnly {
+				t.Errorf("Expected Secure=%v, SameSite=Lax and HttpOnly, got %v", tt.secure, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package balancer
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// Strategy decides which backend receives the next request.
+//
+// backends is the balancer's pool in its configured order and available
+// reports whether a backend may currently receive traffic. Pick must only
+// return available backends, and return ErrAllBackendsOffline if there are
+// none, so the balancer can tell why the selection failed. It may be called
+// from many goroutines at once, so any state it keeps must be safe for
+// concurrent use; backends is shared and must not be modified. A custom
+// strategy is installed with WithStrategy.
+type Strategy interface {
+	Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error)
+}
+
+// RequestStrategy is implemented by strategies that route on request data
+// such as headers or the client address.
+type RequestStrategy interface {
+	Strategy
+	PickForRequest(r *http.Request, backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error)
+}
+
+// KeyStrategy is implemented by strategies that route on an affinity key
+// given directly, as by LoadBalancer.SelectBackendForKey.
+type KeyStrategy interface {
+	Strategy
+	PickForKey(key string, backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error)
+}
+
+// RoundRobin hands out available backends in order. The counter always moves
+// just past the backend it hands out, so each call advances by exactly one
+// available backend and dead backends never shift extra traffic onto their
+// neighbours. It holds the index of the next backend to try, kept modulo the
+// pool size so it never grows without bound or wraps mid-sequence.
+type RoundRobin struct {
+	current atomic.Uint64
+}
+
+// NewRoundRobin creates a round-robin strategy starting at the first backend,
+// so a fresh balancer's picks follow pool order: 0, 1, 2, ... That sequence
+// is deterministic, which tests asserting round-robin order rely on.
+func NewRoundRobin() *RoundRobin {
+	return &RoundRobin{}
+}
+
+// Pick returns the next available backend in round-robin order.
+func (rr *RoundRobin) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	totalBackends := uint64(len(backends))
+	if totalBackends == 0 {
+		return nil, ErrAllBackendsOffline
+	}
+
+	for {
+		current := rr.current.Load()
+		// The pool may have shrunk since the counter was stored
+		start := current % totalBackends
+		found := false
+
+		for offset := uint64(0); offset < totalBackends; offset++ {
+			idx := (start + offset) % totalBackends
+			selectedBackend := backends[idx]
+			if !available(selectedBackend) {
+				continue
+			}
+
+			found = true
+			// Another goroutine moved the counter first; rescan from its position
+			if rr.current.CompareAndSwap(current, (idx+1)%totalBackends) {
+				return selectedBackend, nil
+			}
+			break
+		}
+
+		if !found {
+			return nil, ErrAllBackendsOffline
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package balancer
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestRandomStart tests that fresh balancers start at backend 0 by default and anywhere with WithRandomStart
+func TestRandomStart(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	firstPicks := func(opts ...Option) map[*backend.Backend]int {
+		count := make(map[*backend.Backend]int)
+		for i := 0; i < 300; i++ {
+			lb, err := New(backends, opts...)
+			if err != nil {
+				t.Fatalf("Failed to create load balancer: %v", err)
+			}
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("SelectBackend failed: %v", err)
+			}
+			count[selected]++
+		}
+		return count
+	}
+
+	t.Run("Deterministic By Default", func(t *testing.T) {
+		if count := firstPicks(); count[backends[0]] != 300 {
+			t.Errorf("Expected every first request on backend 0, got %v", count)
+		}
+	})
+
+	t.Run("Random Start Spreads First Requests", func(t *testing.T) {
+		count := firstPicks(WithRandomStart())
+		for i, b := range backends {
+			if count[b] < 50 {
+				t.Errorf("Backend %d: got %d of 300 first requests, expected about 100", i, count[b])
+			}
+		}
+	})
+
+	t.Run("Seeded Source Is Reproducible", func(t *testing.T) {
+		starts := make(map[*backend.Backend]int)
+		for i := 0; i < 20; i++ {
+			lb, err := New(backends, WithRandomStart(), WithRandSource(rand.NewPCG(1, 2)))
+			if err != nil {
+				t.Fatalf("Failed to create load balancer: %v", err)
+			}
+			selected, _ := lb.SelectBackend()
+			starts[selected]++
+		}
+		if len(starts) != 1 {
+			t.Errorf("Expected every balancer seeded alike to start at the same backend, got %v", starts)
+		}
+	})
+
+	t.Run("Sequence Stays Round-Robin", func(t *testing.T) {
+		lb, err := New(backends, WithRandomStart())
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		first, _ := lb.SelectBackend()
+		start := slices.Index(backends, first)
+		for i := 1; i < 6; i++ {
+			selected, _ := lb.SelectBackend()
+			if want := backends[(start+i)%len(backends)]; selected != want {
+				t.Fatalf("Pick %d: expected %s, got %s", i, want.URL.Host, selected.URL.Host)
+			}
+		}
+	})
+}
+
+// TestRoundRobinWrap tests that a counter seeded near math.MaxUint64 keeps an even rotation and stays bounded
+func TestRoundRobinWrap(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	// 2^64 is not a multiple of 3, so a raw counter would repeat a backend at the wrap
+	rr := NewRoundRobin()
+	rr.current.Store(math.MaxUint64 - 1)
+
+	count := make(map[*backend.Backend]int)
+	var previous *backend.Backend
+	for i := 0; i < 3000; i++ {
+		selected, err := rr.Pick(backends, (*backend.Backend).IsAlive)
+		if err != nil {
+			t.Fatalf("Pick failed: %v", err)
+		}
+		if selected == previous {
+			t.Fatalf("Pick %d: %s selected twice in a row", i, selected.URL.Host)
+		}
+		previous = selected
+		count[selected]++
+
+		if c := rr.current.Load(); c >= uint64(len(backends)) {
+			t.Fatalf("Pick %d: counter %d not kept below the pool size", i, c)
+		}
+	}
+	for i, b := range backends {
+		if count[b] != 1000 {
+			t.Errorf("Backend %d: got %d requests, expected exactly 1000", i, count[b])
+		}
+	}
+}
+
+// TestStrategiesSmallPools tests that every strategy copes with zero, one and two available backends
+func TestStrategiesSmallPools(t *testing.T) {
+	pool := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+
+	tests := []struct {
+		name     string
+		backends []*backend.Backend
+		alive    []*backend.Backend
+	}{
+		{"Empty Pool", nil, nil},
+		{"None Alive", pool, nil},
+		{"Single Backend", pool[:1], pool[:1]},
+		{"One Alive", pool, pool[1:2]},
+		{"Two Alive", pool, []*backend.Backend{pool[0], pool[2]}},
+	}
+	for _, name := range StrategyNames() {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				strategy, err := StrategyByName(name)
+				if err != nil {
+					t.Fatalf("Failed to create strategy: %v", err)
+				}
+				available := func(b *backend.Backend) bool { return slices.Contains(tt.alive, b) }
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+				for i := 0; i < 20; i++ {
+					// Request strategies are exercised through both entry points
+					var picked *backend.Backend
+					if rs, ok := strategy.(RequestStrategy); ok && i%2 == 1 {
+						picked, err = rs.PickForRequest(req, tt.backends, available)
+					} else {
+						picked, err = strategy.Pick(tt.backends, available)
+					}
+
+					if len(tt.alive) == 0 {
+						if !errors.Is(err, ErrAllBackendsOffline) {
+							t.Fatalf("Expected ErrAllBackendsOffline, got %v, %v", picked, err)
+						}
+						continue
+					}
+					if err != nil {
+						t.Fatalf("Expected a backend, got error: %v", err)
+					}
+					if !slices.Contains(tt.alive, picked) {
+						t.Fatalf("Expected an alive backend, got %s", picked.URL)
+					}
+				}
+			})
+		}
+	}
+}
+
+// firstAvailable is a custom strategy that always picks the first available backend
+type firstAvailable struct {
+	picks atomic.Int64
+}
+
+func (f *firstAvailable) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	f.picks.Add(1)
+	for _, b := range backends {
+		if available(b) {
+			return b, nil
+		}
+	}
+	return nil, ErrAllBackendsOffline
+}
+
+// TestCustomStrategy tests that a user-supplied strategy replaces round-robin
+func TestCustomStrategy(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	strategy := &firstAvailable{}
+	lb, err := New(backends, WithStrategy(strategy))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("Every Pick Goes Through It", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					selected, err := lb.SelectBackend()
+					if err != nil {
+						t.Errorf("SelectBackend failed: %v", err)
+						return
+					}
+					if selected != backends[0] {
+						t.Errorf("Expected %s, got %s", backends[0].URL.Host, selected.URL.Host)
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if picks := strategy.picks.Load(); picks != 800 {
+			t.Errorf("Expected 800 picks, got %d", picks)
+		}
+	})
+
+	t.Run("Sees Only Available Backends", func(t *testing.T) {
+		backends[0].SetAlive(false)
+		defer backends[0].SetAlive(true)
+
+		selected, err := lb.SelectBackend()
+		if err != nil {
+			t.Fatalf("SelectBackend failed: %v", err)
+		}
+		if selected != backends[1] {
+			t.Errorf("Expected %s, got %s", backends[1].URL.Host, selected.URL.Host)
+		}
+	})
+
+	t.Run("Errors Reach The Caller", func(t *testing.T) {
+		for _, b := range backends {
+			b.SetAlive(false)
+		}
+		defer func() {
+			for _, b := range backends {
+				b.SetAlive(true)
+			}
+		}()
+
+		if _, err := lb.SelectBackend(); !errors.Is(err, ErrAllBackendsOffline) {
+			t.Errorf("Expected ErrAllBackendsOffline, got %v", err)
+		}
+	})
+
+	t.Run("Named By Type", func(t *testing.T) {
+		_, info, err := lb.SelectBackendDetailed()
+		if err != nil {
+			t.Fatalf("SelectBackendDetailed failed: %v", err)
+		}
+		if info.Strategy != "*balancer.firstAvailable" {
+			t.Errorf("Expected strategy *balancer.firstAvailable, got %q", info.Strategy)
+		}
+	})
+}
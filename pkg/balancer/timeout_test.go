@@ -0,0 +1,80 @@
+package balancer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// newHungServer returns a server that doesn't answer until the client gives up
+func newHungServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestRequestTimeout tests that hung backends are abandoned, retried elsewhere and reported as failures
+func TestRequestTimeout(t *testing.T) {
+	hung := newTestBackend(t, newHungServer(t).URL)
+	live := newTestBackend(t, newNamedServer(t, "live").URL)
+	hung.SetAlive(true)
+	live.SetAlive(true)
+
+	t.Run("Retried On Next Backend", func(t *testing.T) {
+		lb, err := New([]*backend.Backend{hung, live}, WithRequestTimeout(50*time.Millisecond))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		start := time.Now()
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != "live" {
+			t.Fatalf("Expected 200 from live backend, got %d %q", rec.Code, rec.Body)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("Expected the hung attempt to be abandoned, took %v", elapsed)
+		}
+		if ok, at := hung.PassiveHealth(); ok || at.IsZero() {
+			t.Error("Expected the timeout to be reported as a passive failure")
+		}
+	})
+
+	t.Run("All Attempts Time Out", func(t *testing.T) {
+		lb, err := New([]*backend.Backend{hung}, WithRequestTimeout(20*time.Millisecond), WithMaxRetries(1))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		rec := httptest.NewRecorder()
+		lb.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rec.Code != http.StatusGatewayTimeout {
+			t.Errorf("Expected 504, got %d", rec.Code)
+		}
+	})
+
+	t.Run("Counts Toward Outlier Detection", func(t *testing.T) {
+		lb, err := New([]*backend.Backend{hung, live}, WithRequestTimeout(20*time.Millisecond),
+			WithOutlierDetection(OutlierDetection{MinRequests: 2}))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		defer hung.Eject(0)
+
+		for i := 0; i < 4; i++ {
+			lb.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}
+		if !hung.IsEjected() {
+			t.Error("Expected the hung backend to be ejected")
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package balancer
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// warnRecorder keeps the messages of every warning logged
+type warnRecorder struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (l *warnRecorder) Debug(msg string, kv ...any) {}
+func (l *warnRecorder) Info(msg string, kv ...any)  {}
+func (l *warnRecorder) Error(msg string, kv ...any) {}
+
+func (l *warnRecorder) Warn(msg string, kv ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.messages = append(l.messages, msg)
+}
+
+// TestUnknownBackends tests selection while backends have not been health checked yet
+func TestUnknownBackends(t *testing.T) {
+	newUnchecked := func(t *testing.T) []*backend.Backend {
+		return []*backend.Backend{
+			newTestBackend(t, "http://localhost:3000"),
+			newTestBackend(t, "http://localhost:3001"),
+		}
+	}
+
+	t.Run("Reports That Nothing Was Checked", func(t *testing.T) {
+		lb, err := New(newUnchecked(t))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		_, err = lb.SelectBackend()
+		if !errors.Is(err, ErrNoBackendsChecked) || !errors.Is(err, ErrAllBackendsOffline) {
+			t.Fatalf("Expected ErrNoBackendsChecked wrapping ErrAllBackendsOffline, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "no backends have passed a health check yet") {
+			t.Errorf("Expected the error to name the cause, got %q", err)
+		}
+	})
+
+	t.Run("Confirmed Dead Is Offline", func(t *testing.T) {
+		backends := newUnchecked(t)
+		backends[0].SetAlive(false)
+		lb, err := New(backends)
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		if _, err := lb.SelectBackend(); errors.Is(err, ErrNoBackendsChecked) || !errors.Is(err, ErrAllBackendsOffline) {
+			t.Errorf("Expected plain ErrAllBackendsOffline, got %v", err)
+		}
+	})
+
+	t.Run("Selectable With Warning When Opted In", func(t *testing.T) {
+		backends := newUnchecked(t)
+		backends[1].SetAlive(false)
+		logger := &warnRecorder{}
+		lb, err := New(backends, WithSelectUnknown(), WithLogger(logger))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		for i := 0; i < 3; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("Request %d failed: %v", i, err)
+			}
+			if selected != backends[0] {
+				t.Errorf("Request %d: expected the unknown backend, got dead %s", i, selected.URL.Host)
+			}
+		}
+		if len(logger.messages) != 1 {
+			t.Errorf("Expected a single warning, got %q", logger.messages)
+		}
+	})
+}
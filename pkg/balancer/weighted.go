@@ -0,0 +1,109 @@
+package balancer
+
+import (
+	"sync"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// WeightedRoundRobin spreads requests in proportion to backend weights using
+// nginx's smooth weighted round-robin, which interleaves picks instead of
+// sending runs of requests to the heaviest backend. A backend that declares a
+// MaxRPS is weighted by that capacity rather than by its Weight.
+//
+// Weights may be changed with SetWeight while the balancer serves, e.g. to
+// shift traffic to a canary; the next pick uses them. A change restarts the
+// interleaving from scratch so the split converges on the new weights within
+// one cycle instead of working off credit built up under the old ones.
+type WeightedRoundRobin struct {
+	mu      sync.Mutex
+	current map[*backend.Backend]float64
+	weights map[*backend.Backend]int // configured weight seen at the last pick
+	pool    []*backend.Backend       // pool snapshot the maps were last pruned for
+}
+
+// NewWeightedRoundRobin creates a smooth weighted round-robin strategy.
+func NewWeightedRoundRobin() *WeightedRoundRobin {
+	return &WeightedRoundRobin{
+		current: make(map[*backend.Backend]float64),
+		weights: make(map[*backend.Backend]int),
+	}
+}
+
+// Pick returns the available backend that is furthest behind its share.
+func (w *WeightedRoundRobin) Pick(backends []*backend.Backend, available func(*backend.Backend) bool) (*backend.Backend, error) {
+	return w.pick(backends, available, effectiveWeight)
+}
+
+// pick runs smooth weighted round-robin with the weights given by weightOf.
+func (w *WeightedRoundRobin) pick(backends []*backend.Backend, available func(*backend.Backend) bool, weightOf func(*backend.Backend) float64) (*backend.Backend, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pruneOnPoolChange(backends)
+	w.resetOnWeightChange(backends)
+	var best *backend.Backend
+	total := 0.0
+	for _, b := range backends {
+		if !available(b) {
+			continue
+		}
+		weight := weightOf(b)
+		w.current[b] += weight
+		total += weight
+		if best == nil || w.current[b] > w.current[best] {
+			best = b
+		}
+	}
+
+	if best == nil {
+		return nil, ErrAllBackendsOffline
+	}
+	w.current[best] -= total
+	return best, nil
+}
+
+// pruneOnPoolChange drops the state of backends that are no longer in the
+// pool, so a pool that keeps changing doesn't grow the maps forever.
+func (w *WeightedRoundRobin) pruneOnPoolChange(backends []*backend.Backend) {
+	if samePool(w.pool, backends) {
+		return
+	}
+	w.pool = backends
+
+	present := make(map[*backend.Backend]bool, len(backends))
+	for _, b := range backends {
+		present[b] = true
+	}
+	for b := range w.weights {
+		if !present[b] {
+			delete(w.weights, b)
+			delete(w.current, b)
+		}
+	}
+}
+
+// resetOnWeightChange clears the accumulated current weights if any
+// backend's configured weight changed since the last pick.
+func (w *WeightedRoundRobin) resetOnWeightChange(backends []*backend.Backend) {
+	changed := false
+	for _, b := range backends {
+		weight := b.Weight()
+		if last, ok := w.weights[b]; ok && last != weight {
+			changed = true
+		}
+		w.weights[b] = weight
+	}
+	if changed {
+		clear(w.current)
+	}
+}
+
+// effectiveWeight returns the backend's declared capacity if it has one,
+// otherwise its configured weight.
+func effectiveWeight(b *backend.Backend) float64 {
+	if rps := b.MaxRPS(); rps > 0 {
+		return rps
+	}
+	return float64(b.Weight())
+}
@@ -0,0 +1,187 @@
+package balancer
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+)
+
+// TestWeightedRoundRobin tests that traffic follows configured weights
+func TestWeightedRoundRobin(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+		newTestBackend(t, "http://localhost:3002"),
+	}
+
+	for i, b := range backends {
+		b.SetAlive(true)
+		b.SetWeight(i + 1)
+	}
+
+	lb, err := New(backends, WithStrategy(NewWeightedRoundRobin()))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	count := make(map[*backend.Backend]int)
+	for i := 0; i < 600; i++ {
+		selected, err := lb.SelectBackend()
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i, err)
+		}
+		count[selected]++
+	}
+
+	// Smooth WRR is exact over whole cycles of total weight
+	for i, b := range backends {
+		expected := 100 * (i + 1)
+		if count[b] != expected {
+			t.Errorf("Backend %d (weight %d): got %d requests, expected %d", i, i+1, count[b], expected)
+		}
+	}
+}
+
+// TestWeightedRoundRobinWeightChange tests that weights changed mid-run take effect on the next cycle
+func TestWeightedRoundRobinWeightChange(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+
+	lb, err := New(backends, WithStrategy(NewWeightedRoundRobin()))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	run := func(requests int) map[*backend.Backend]int {
+		count := make(map[*backend.Backend]int)
+		for i := 0; i < requests; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("Request %d failed: %v", i, err)
+			}
+			count[selected]++
+		}
+		return count
+	}
+
+	if count := run(100); count[backends[0]] != 50 || count[backends[1]] != 50 {
+		t.Fatalf("Expected a 50/50 split at equal weights, got %d/%d", count[backends[0]], count[backends[1]])
+	}
+
+	backends[0].SetWeight(10)
+	if count := run(220); count[backends[0]] != 200 || count[backends[1]] != 20 {
+		t.Errorf("Expected a 200/20 split after ramping to weight 10, got %d/%d", count[backends[0]], count[backends[1]])
+	}
+}
+
+// TestWeightedRoundRobinPoolChange tests that backends leaving the pool leave no state behind
+func TestWeightedRoundRobinPoolChange(t *testing.T) {
+	keep := newTestBackend(t, "http://localhost:3000")
+	keep.SetAlive(true)
+	lb, err := New([]*backend.Backend{keep}, WithStrategy(NewWeightedRoundRobin()))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+	wrr := lb.strategy.(*WeightedRoundRobin)
+
+	for i := 0; i < 50; i++ {
+		b := newTestBackend(t, fmt.Sprintf("http://localhost:%d", 4000+i))
+		b.SetAlive(true)
+		if err := lb.AddBackend(b); err != nil {
+			t.Fatalf("AddBackend failed: %v", err)
+		}
+		if _, err := lb.SelectBackend(); err != nil {
+			t.Fatalf("SelectBackend failed: %v", err)
+		}
+		if _, err := lb.RemoveBackend(b.URL.Host); err != nil {
+			t.Fatalf("RemoveBackend failed: %v", err)
+		}
+	}
+	if _, err := lb.SelectBackend(); err != nil {
+		t.Fatalf("SelectBackend failed: %v", err)
+	}
+
+	if len(wrr.current) != 1 || len(wrr.weights) != 1 {
+		t.Errorf("Expected state for the remaining backend only, got %d current and %d weights", len(wrr.current), len(wrr.weights))
+	}
+}
+
+// TestWeightedMaxRPS tests that declared capacities set the split and cap each backend's rate
+func TestWeightedMaxRPS(t *testing.T) {
+	backends := []*backend.Backend{
+		newTestBackend(t, "http://localhost:3000"),
+		newTestBackend(t, "http://localhost:3001"),
+	}
+
+	for _, b := range backends {
+		b.SetAlive(true)
+	}
+	backends[0].SetMaxRPS(100)
+	backends[1].SetMaxRPS(200)
+
+	lb, err := New(backends, WithStrategy(NewWeightedRoundRobin()))
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	t.Run("Distribution Follows Capacity", func(t *testing.T) {
+		count := make(map[*backend.Backend]int)
+		// Stay well under both caps
+		for i := 0; i < 30; i++ {
+			selected, err := lb.SelectBackend()
+			if err != nil {
+				t.Fatalf("Request %d failed: %v", i, err)
+			}
+			count[selected]++
+		}
+
+		if count[backends[0]] != 10 || count[backends[1]] != 20 {
+			t.Errorf("Expected 10/20 split, got %d/%d", count[backends[0]], count[backends[1]])
+		}
+	})
+
+	t.Run("Overload Never Exceeds Declared Rate", func(t *testing.T) {
+		time.Sleep(200 * time.Millisecond) // refill the buckets
+
+		count := make(map[*backend.Backend]int)
+		shed := 0
+		start := time.Now()
+		for time.Since(start) < 500*time.Millisecond {
+			selected, err := lb.SelectBackend()
+			if errors.Is(err, ErrAllBackendsRateLimited) {
+				shed++
+				time.Sleep(100 * time.Microsecond)
+				continue
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			count[selected]++
+		}
+		elapsed := time.Since(start).Seconds()
+
+		if shed == 0 {
+			t.Error("Expected excess requests to be shed under overload")
+		}
+		for i, rps := range []float64{100, 200} {
+			limit := int(rps*elapsed + rps/10) // rate over the run plus one burst
+			if count[backends[i]] > limit {
+				t.Errorf("Backend %d served %d requests, above its cap of %d", i, count[backends[i]], limit)
+			}
+		}
+
+		ratio := float64(count[backends[1]]) / float64(count[backends[0]])
+		if ratio < 1.7 || ratio > 2.3 {
+			t.Errorf("Expected ~1:2 split under overload, got %d/%d (ratio=%.2f)",
+				count[backends[0]], count[backends[1]], ratio)
+		}
+	})
+}
@@ -0,0 +1,119 @@
+// Package bench measures how backend selection spreads requests over the
+// pool and how fast it runs. It backs the selection benchmarks and the
+// distribution demo in atomic_example.go. Selections run against backends on
+// made-up addresses that are never dialled, so only the balancer is measured.
+package bench
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/pkg/balancer"
+)
+
+// NewBackends returns n alive backends on made-up addresses. Their weights
+// are taken from weights in turn, repeating it if it is shorter than n; with
+// no weights every backend weighs 1.
+func NewBackends(n int, weights ...int) []*backend.Backend {
+	backends := make([]*backend.Backend, n)
+	for i := range backends {
+		b, err := backend.NewBackend(fmt.Sprintf("http://10.0.0.%d:8080", i+1))
+		if err != nil {
+			panic(err) // the URL is always valid
+		}
+		if len(weights) > 0 {
+			b.SetWeight(weights[i%len(weights)])
+		}
+		b.SetAlive(true)
+		backends[i] = b
+	}
+	return backends
+}
+
+// Distribution is how a run of selections spread over the pool.
+type Distribution struct {
+	Counts   map[*backend.Backend]int // successful selections per backend
+	Total    int                      // successful selections
+	Failures int                      // selections that returned an error
+	Elapsed  time.Duration
+}
+
+// Run makes requests selections on lb, spread over concurrency goroutines,
+// and returns where they went.
+func Run(lb *balancer.LoadBalancer, requests, concurrency int) Distribution {
+	concurrency = max(concurrency, 1)
+	counts := make([]map[*backend.Backend]int, concurrency)
+	failures := make([]int, concurrency)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		// Spread the remainder over the first workers
+		n := requests / concurrency
+		if w < requests%concurrency {
+			n++
+		}
+		counts[w] = make(map[*backend.Backend]int)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				b, err := lb.SelectBackend()
+				if err != nil {
+					failures[w]++
+					continue
+				}
+				counts[w][b]++
+			}
+		}()
+	}
+	wg.Wait()
+
+	d := Distribution{Counts: make(map[*backend.Backend]int), Elapsed: time.Since(start)}
+	for w := range counts {
+		for b, n := range counts[w] {
+			d.Counts[b] += n
+			d.Total += n
+		}
+		d.Failures += failures[w]
+	}
+	return d
+}
+
+// Share returns the fraction of the successful selections that went to b.
+func (d Distribution) Share(b *backend.Backend) float64 {
+	if d.Total == 0 {
+		return 0
+	}
+	return float64(d.Counts[b]) / float64(d.Total)
+}
+
+// Skew returns how far the run strayed from splitting the selections between
+// backends by weight: the largest deviation of a backend's share from its
+// fair share, relative to that fair share. 0 is a perfectly fair split and
+// 0.1 means some backend got 10% more or less than it should have. Weighted
+// strategies should score close to 0 on any pool; unweighted ones only on
+// pools of equal weights.
+func (d Distribution) Skew(backends []*backend.Backend) float64 {
+	total := 0
+	for _, b := range backends {
+		total += b.Weight()
+	}
+	skew := 0.0
+	for _, b := range backends {
+		fair := float64(b.Weight()) / float64(total)
+		skew = max(skew, math.Abs(d.Share(b)-fair)/fair)
+	}
+	return skew
+}
+
+// Throughput returns the selections made per second.
+func (d Distribution) Throughput() float64 {
+	if d.Elapsed <= 0 {
+		return 0
+	}
+	return float64(d.Total+d.Failures) / d.Elapsed.Seconds()
+}
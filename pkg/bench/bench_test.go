@@ -0,0 +1,104 @@
+package bench
+
+import (
+	"math"
+	"testing"
+
+	"github.com/akshaykumarthakur/load-balancer/pkg/balancer"
+)
+
+// newBalancer creates a balancer over backends using the named strategy
+func newBalancer(tb testing.TB, name string, opts ...balancer.Option) *balancer.LoadBalancer {
+	tb.Helper()
+	strategy, err := balancer.StrategyByName(name)
+	if err != nil {
+		tb.Fatalf("Failed to create strategy: %v", err)
+	}
+	lb, err := balancer.New(NewBackends(4, 1, 2, 3, 4), append(opts, balancer.WithStrategy(strategy))...)
+	if err != nil {
+		tb.Fatalf("Failed to create load balancer: %v", err)
+	}
+	return lb
+}
+
+// TestRun tests that runs count every selection and measure skew against backend weights
+func TestRun(t *testing.T) {
+	t.Run("Round Robin Is Even", func(t *testing.T) {
+		backends := NewBackends(3)
+		lb, err := balancer.New(backends)
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+		d := Run(lb, 301, 7)
+		if d.Total != 301 || d.Failures != 0 {
+			t.Fatalf("Expected 301 selections and no failures, got %d and %d", d.Total, d.Failures)
+		}
+		for _, b := range backends {
+			if n := d.Counts[b]; n < 100 || n > 101 {
+				t.Errorf("Expected %s to get 100 or 101 selections, got %d", b.URL.Host, n)
+			}
+		}
+		if skew := d.Skew(backends); skew > 0.01 {
+			t.Errorf("Expected no skew, got %.3f", skew)
+		}
+	})
+
+	t.Run("Skew Follows Weights", func(t *testing.T) {
+		lb := newBalancer(t, "weighted-round-robin")
+		d := Run(lb, 1000, 1)
+		if skew := d.Skew(lb.Backends()); skew != 0 {
+			t.Errorf("Expected weighted round-robin to split exactly by weight, got skew %.3f", skew)
+		}
+
+		lb = newBalancer(t, "round-robin")
+		d = Run(lb, 1000, 1)
+		// The weight-1 backend gets a quarter instead of a tenth
+		if skew := d.Skew(lb.Backends()); math.Abs(skew-1.5) > 0.01 {
+			t.Errorf("Expected round-robin to ignore weights with skew 1.5, got %.3f", skew)
+		}
+	})
+
+	t.Run("Failures Counted", func(t *testing.T) {
+		lb := newBalancer(t, "round-robin")
+		for _, b := range lb.Backends() {
+			b.SetAlive(false)
+		}
+		d := Run(lb, 50, 5)
+		if d.Total != 0 || d.Failures != 50 {
+			t.Errorf("Expected 50 failures, got %d selections and %d failures", d.Total, d.Failures)
+		}
+		if share := d.Share(lb.Backends()[0]); share != 0 {
+			t.Errorf("Expected no share without selections, got %v", share)
+		}
+	})
+}
+
+// BenchmarkSelectBackend measures the selection hot path of every strategy under parallel load
+func BenchmarkSelectBackend(b *testing.B) {
+	for _, name := range balancer.StrategyNames() {
+		b.Run(name, func(b *testing.B) {
+			lb := newBalancer(b, name, balancer.WithSeed(1))
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					lb.SelectBackend()
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkDistribution reports how far each strategy strays from a split by weight
+func BenchmarkDistribution(b *testing.B) {
+	for _, name := range balancer.StrategyNames() {
+		b.Run(name, func(b *testing.B) {
+			lb := newBalancer(b, name, balancer.WithSeed(1))
+			var d Distribution
+			for b.Loop() {
+				d = Run(lb, 10000, 8)
+			}
+			b.ReportMetric(d.Skew(lb.Backends()), "skew")
+			b.ReportMetric(d.Throughput(), "selections/s")
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Package grpchealth exposes a LoadBalancer's health over the standard gRPC
+// Health Checking Protocol (grpc.health.v1), so gRPC-native orchestrators
+// can watch the balancer the same way they watch any other gRPC service.
+//
+// The overall service ("") is SERVING while the balancer is Ready. With
+// WithPerBackend, each backend is also reported under its host:port.
+package grpchealth
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/pkg/balancer"
+)
+
+// Server mirrors a LoadBalancer's health into a gRPC health service.
+type Server struct {
+	lb         *balancer.LoadBalancer
+	health     *health.Server
+	perBackend bool
+
+	mu    sync.Mutex
+	known map[string]bool // backend services reported so far
+	stop  chan struct{}
+}
+
+// Option configures optional Server behavior.
+type Option func(*Server)
+
+// WithPerBackend also reports each backend's health under a service named
+// after its URL host (e.g. "10.0.0.5:8080").
+func WithPerBackend() Option {
+	return func(s *Server) {
+		s.perBackend = true
+	}
+}
+
+// NewServer creates a health server for lb. Its status is refreshed by
+// Update, or periodically once Start is called.
+func NewServer(lb *balancer.LoadBalancer, opts ...Option) *Server {
+	s := &Server{
+		lb:     lb,
+		health: health.NewServer(),
+		known:  make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Update()
+	return s
+}
+
+// Register installs the health service on gs.
+func (s *Server) Register(gs *grpc.Server) {
+	healthpb.RegisterHealthServer(gs, s.health)
+}
+
+// Update publishes the balancer's current health. Watchers are only notified
+// when a status actually changes.
+func (s *Server) Update() {
+	s.health.SetServingStatus("", servingStatus(s.lb.Ready()))
+	if !s.perBackend {
+		return
+	}
+
+	healthy := make(map[*backend.Backend]bool)
+	for _, b := range s.lb.GetHealthyBackends() {
+		healthy[b] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := make(map[string]bool)
+	for _, b := range s.lb.Backends() {
+		current[b.URL.Host] = true
+		s.health.SetServingStatus(b.URL.Host, servingStatus(healthy[b]))
+	}
+	// Backends removed from the pool are reported as down rather than
+	// left frozen at their last status
+	for host := range s.known {
+		if !current[host] {
+			s.health.SetServingStatus(host, healthpb.HealthCheckResponse_NOT_SERVING)
+		}
+	}
+	s.known = current
+}
+
+// Start refreshes the published health every interval until Stop is called.
+func (s *Server) Start(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	s.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Update()
+			}
+		}
+	}()
+}
+
+// Stop halts periodic updates and reports every service as NOT_SERVING, so
+// watchers stop routing to the balancer while it shuts down.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	s.mu.Unlock()
+	s.health.Shutdown()
+}
+
+func servingStatus(ok bool) healthpb.HealthCheckResponse_ServingStatus {
+	if ok {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
@@ -0,0 +1,101 @@
+package grpchealth
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/pkg/balancer"
+)
+
+// newHealthClient serves s over an in-memory listener and returns a client for it
+func newHealthClient(t *testing.T, s *Server) healthpb.HealthClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	s.Register(gs)
+	go gs.Serve(listener)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial health server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return healthpb.NewHealthClient(conn)
+}
+
+// TestHealthService tests that gRPC health checks follow backend availability
+func TestHealthService(t *testing.T) {
+	b, err := backend.NewBackend("http://localhost:3000")
+	if err != nil {
+		t.Fatalf("Failed to create backend: %v", err)
+	}
+	b.SetAlive(true)
+
+	lb, err := balancer.New([]*backend.Backend{b})
+	if err != nil {
+		t.Fatalf("Failed to create load balancer: %v", err)
+	}
+
+	s := NewServer(lb, WithPerBackend())
+	client := newHealthClient(t, s)
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Health check for %q failed: %v", service, err)
+		}
+		return resp.GetStatus()
+	}
+
+	t.Run("Serving With Alive Backend", func(t *testing.T) {
+		if got := check(""); got != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Expected SERVING, got %v", got)
+		}
+		if got := check(b.URL.Host); got != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Expected backend SERVING, got %v", got)
+		}
+	})
+
+	t.Run("Not Serving When All Down", func(t *testing.T) {
+		b.SetAlive(false)
+		s.Update()
+
+		if got := check(""); got != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("Expected NOT_SERVING, got %v", got)
+		}
+		if got := check(b.URL.Host); got != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("Expected backend NOT_SERVING, got %v", got)
+		}
+	})
+
+	t.Run("Periodic Updates", func(t *testing.T) {
+		s.Start(10 * time.Millisecond)
+		defer s.Stop()
+		b.SetAlive(true)
+
+		deadline := time.Now().Add(2 * time.Second)
+		for check("") != healthpb.HealthCheckResponse_SERVING {
+			if time.Now().After(deadline) {
+				t.Fatal("Health status never returned to SERVING")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
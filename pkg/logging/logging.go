@@ -0,0 +1,70 @@
+// Package logging defines the small structured logger the balancer and
+// health checker write to. Its methods match *slog.Logger, so a slog logger
+// with a JSON handler can be passed in directly.
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger records a message with alternating key-value fields, e.g.
+//
+//	logger.Warn("backend unhealthy", "backend", "10.0.0.5:8080", "status", 503)
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+	Info(msg string, keysAndValues ...any)
+	Warn(msg string, keysAndValues ...any)
+	Error(msg string, keysAndValues ...any)
+}
+
+// stdLogger writes "LEVEL msg key=value ..." lines to a standard library logger.
+type stdLogger struct {
+	l *log.Logger
+}
+
+// NewStdLogger wraps l, or the standard library's default logger if l is nil.
+func NewStdLogger(l *log.Logger) Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return stdLogger{l: l}
+}
+
+// Default returns the logger used when none is configured: the standard
+// library's default logger.
+func Default() Logger {
+	return NewStdLogger(nil)
+}
+
+func (s stdLogger) Debug(msg string, kv ...any) { s.output("DEBUG", msg, kv) }
+func (s stdLogger) Info(msg string, kv ...any)  { s.output("INFO", msg, kv) }
+func (s stdLogger) Warn(msg string, kv ...any)  { s.output("WARN", msg, kv) }
+func (s stdLogger) Error(msg string, kv ...any) { s.output("ERROR", msg, kv) }
+
+func (s stdLogger) output(level, msg string, kv []any) {
+	var sb strings.Builder
+	sb.WriteString(level)
+	sb.WriteByte(' ')
+	sb.WriteString(msg)
+	for i := 0; i < len(kv); i += 2 {
+		sb.WriteByte(' ')
+		if i+1 == len(kv) {
+			// A dangling value is kept rather than silently dropped
+			fmt.Fprintf(&sb, "!BADKEY=%v", kv[i])
+			break
+		}
+		fmt.Fprintf(&sb, "%v=%s", kv[i], formatValue(kv[i+1]))
+	}
+	s.l.Output(3, sb.String())
+}
+
+// formatValue quotes values containing spaces so lines stay parseable.
+func formatValue(v any) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
@@ -0,0 +1,37 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+// TestStdLogger tests the line format of the standard library wrapper
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewStdLogger(log.New(&buf, "", 0))
+
+	tests := []struct {
+		name     string
+		log      func()
+		expected string
+	}{
+		{"Fields", func() { logger.Warn("backend is now unhealthy", "backend", "localhost:3000", "status", 503) },
+			`WARN backend is now unhealthy backend=localhost:3000 status=503`},
+		{"Quoted Value", func() { logger.Error("probe failed", "error", "connection refused") },
+			`ERROR probe failed error="connection refused"`},
+		{"Dangling Value", func() { logger.Info("odd", "backend") },
+			`INFO odd !BADKEY=backend`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			tt.log()
+			if got := strings.TrimSpace(buf.String()); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Package replay drives an http.Handler, typically a balancer.LoadBalancer,
+// with a recorded request trace and collects per-backend statistics, for load
+// testing and comparing selection strategies.
+package replay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultBackendHeader is the response header test backends use to identify
+// themselves to the replayer.
+const DefaultBackendHeader = "X-Backend"
+
+// Entry is one recorded request.
+type Entry struct {
+	At       time.Duration // offset from the start of the trace
+	Method   string
+	Path     string
+	ClientIP string
+}
+
+// traceLine is the on-disk JSON form of an Entry, one object per line:
+// {"at_ms": 120, "method": "GET", "path": "/users/1", "client_ip": "10.0.0.7"}
+type traceLine struct {
+	AtMs     int64  `json:"at_ms"`
+	Method   string `json:"method"`
+	Path     string `json:"path"`
+	ClientIP string `json:"client_ip"`
+}
+
+// ReadTrace parses a JSON-lines trace. Blank lines are skipped and entries are
+// returned in timestamp order.
+func ReadTrace(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var line traceLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("trace line %d: %w", lineNum, err)
+		}
+		if line.Method == "" {
+			line.Method = http.MethodGet
+		}
+		if line.Path == "" {
+			return nil, fmt.Errorf("trace line %d: missing path", lineNum)
+		}
+
+		entries = append(entries, Entry{
+			At:       time.Duration(line.AtMs) * time.Millisecond,
+			Method:   line.Method,
+			Path:     line.Path,
+			ClientIP: line.ClientIP,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading trace: %w", err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].At < entries[j].At })
+	return entries, nil
+}
+
+// Options controls how a trace is replayed.
+type Options struct {
+	// Speed scales the recorded timing: 1 replays in real time, 2 twice as
+	// fast. Zero sends every request as fast as possible.
+	Speed float64
+	// BackendHeader names the response header identifying the backend that
+	// served a request. Defaults to DefaultBackendHeader.
+	BackendHeader string
+}
+
+// BackendStats summarizes the requests served by one backend.
+type BackendStats struct {
+	Requests     int
+	TotalLatency time.Duration
+}
+
+// AvgLatency returns the mean latency of the backend's requests.
+func (s BackendStats) AvgLatency() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Requests)
+}
+
+// Stats is the outcome of a replay.
+type Stats struct {
+	Requests int
+	// Errors counts responses with a 5xx status or no backend header.
+	Errors     int
+	PerBackend map[string]BackendStats
+}
+
+// Run replays entries against handler, sending each request at its recorded
+// offset (scaled by opts.Speed), and returns the collected statistics.
+// Requests that were already sent complete even if ctx is cancelled.
+func Run(ctx context.Context, handler http.Handler, entries []Entry, opts Options) (Stats, error) {
+	if opts.BackendHeader == "" {
+		opts.BackendHeader = DefaultBackendHeader
+	}
+
+	stats := Stats{PerBackend: make(map[string]BackendStats)}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for _, entry := range entries {
+		if opts.Speed > 0 {
+			due := start.Add(time.Duration(float64(entry.At) / opts.Speed))
+			select {
+			case <-ctx.Done():
+				wg.Wait()
+				return stats, ctx.Err()
+			case <-time.After(time.Until(due)):
+			}
+		} else if err := ctx.Err(); err != nil {
+			wg.Wait()
+			return stats, err
+		}
+
+		wg.Add(1)
+		go func(entry Entry) {
+			defer wg.Done()
+
+			req := httptest.NewRequest(entry.Method, entry.Path, nil)
+			if entry.ClientIP != "" {
+				req.RemoteAddr = entry.ClientIP + ":40000"
+			}
+			rec := httptest.NewRecorder()
+
+			sent := time.Now()
+			handler.ServeHTTP(rec, req)
+			latency := time.Since(sent)
+
+			served := rec.Header().Get(opts.BackendHeader)
+
+			mu.Lock()
+			defer mu.Unlock()
+			stats.Requests++
+			if rec.Code >= http.StatusInternalServerError || served == "" {
+				stats.Errors++
+				return
+			}
+			bs := stats.PerBackend[served]
+			bs.Requests++
+			bs.TotalLatency += latency
+			stats.PerBackend[served] = bs
+		}(entry)
+	}
+
+	wg.Wait()
+	return stats, nil
+}
@@ -0,0 +1,118 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akshaykumarthakur/load-balancer/internal/backend"
+	"github.com/akshaykumarthakur/load-balancer/pkg/balancer"
+)
+
+const trace = `{"at_ms": 0, "method": "GET", "path": "/users/1", "client_ip": "10.0.0.1"}
+{"at_ms": 5, "method": "GET", "path": "/users/2", "client_ip": "10.0.0.2"}
+{"at_ms": 10, "method": "POST", "path": "/orders", "client_ip": "10.0.0.1"}
+
+{"at_ms": 15, "method": "GET", "path": "/users/3", "client_ip": "10.0.0.3"}
+{"at_ms": 20, "method": "GET", "path": "/users/1", "client_ip": "10.0.0.2"}
+{"at_ms": 25, "method": "GET", "path": "/health", "client_ip": "10.0.0.1"}
+`
+
+// newBackends starts n test servers that identify themselves via DefaultBackendHeader
+func newBackends(t *testing.T, n int) []*backend.Backend {
+	t.Helper()
+	backends := make([]*backend.Backend, n)
+	for i := range backends {
+		name := fmt.Sprintf("backend-%d", i)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(DefaultBackendHeader, name)
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(server.Close)
+
+		b, err := backend.NewBackend(server.URL)
+		if err != nil {
+			t.Fatalf("Failed to create backend: %v", err)
+		}
+		b.SetAlive(true)
+		backends[i] = b
+	}
+	return backends
+}
+
+// TestReadTrace tests trace parsing and validation
+func TestReadTrace(t *testing.T) {
+	entries, err := ReadTrace(strings.NewReader(trace))
+	if err != nil {
+		t.Fatalf("ReadTrace failed: %v", err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("Expected 6 entries, got %d", len(entries))
+	}
+	if entries[2].Method != http.MethodPost || entries[2].At != 10*time.Millisecond {
+		t.Errorf("Unexpected entry: %+v", entries[2])
+	}
+
+	if _, err := ReadTrace(strings.NewReader(`{"at_ms": 0}`)); err == nil {
+		t.Error("Expected error for entry without a path")
+	}
+	if _, err := ReadTrace(strings.NewReader(`not json`)); err == nil {
+		t.Error("Expected error for malformed line")
+	}
+}
+
+// TestReplayDistribution tests that replayed traffic matches the configured strategy
+func TestReplayDistribution(t *testing.T) {
+	entries, err := ReadTrace(strings.NewReader(trace))
+	if err != nil {
+		t.Fatalf("ReadTrace failed: %v", err)
+	}
+
+	t.Run("Round Robin Spreads Evenly", func(t *testing.T) {
+		lb, err := balancer.New(newBackends(t, 3))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		stats, err := Run(context.Background(), lb, entries, Options{Speed: 10})
+		if err != nil {
+			t.Fatalf("Replay failed: %v", err)
+		}
+
+		if stats.Requests != 6 || stats.Errors != 0 {
+			t.Fatalf("Expected 6 successful requests, got %d with %d errors", stats.Requests, stats.Errors)
+		}
+		for name, bs := range stats.PerBackend {
+			if bs.Requests != 2 {
+				t.Errorf("%s served %d requests, expected 2", name, bs.Requests)
+			}
+		}
+	})
+
+	t.Run("IP Hash Pins Clients", func(t *testing.T) {
+		lb, err := balancer.New(newBackends(t, 3), balancer.WithStrategy(balancer.NewIPHash(false)))
+		if err != nil {
+			t.Fatalf("Failed to create load balancer: %v", err)
+		}
+
+		// Client 10.0.0.1 sends 3 requests, so some backend must serve at least 3
+		stats, err := Run(context.Background(), lb, entries, Options{})
+		if err != nil {
+			t.Fatalf("Replay failed: %v", err)
+		}
+
+		most := 0
+		for _, bs := range stats.PerBackend {
+			if bs.Requests > most {
+				most = bs.Requests
+			}
+		}
+		if most < 3 {
+			t.Errorf("Expected one backend to serve all 3 requests of 10.0.0.1, got distribution %v", stats.PerBackend)
+		}
+	})
+}